@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/chanzuckerberg/reaper/pkg/config"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/spf13/afero"
+	"github.com/spf13/cobra"
+)
+
+func init() {
+	addConfigFlag(configMigrateCmd)
+	configMigrateCmd.Flags().Bool(dryRunFlag, false, "Print the migrated files instead of rewriting them.")
+	configCmd.AddCommand(configMigrateCmd)
+	configCmd.AddCommand(configSchemaCmd)
+	rootCmd.AddCommand(configCmd)
+}
+
+var configCmd = &cobra.Command{
+	Use:   "config",
+	Short: "Work with reaper config files",
+}
+
+var configSchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Print the JSON Schema for the reaper config",
+	Long:  "Will print the JSON Schema reaper validates config files against, for use with editors",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		b, err := json.MarshalIndent(config.Schema(), "", "  ")
+		if err != nil {
+			return errors.Wrap(err, "could not marshal schema")
+		}
+		fmt.Println(string(b))
+		return nil
+	},
+}
+
+var configMigrateCmd = &cobra.Command{
+	Use:   "migrate",
+	Short: "Rewrite version 1 config files as version 2",
+	Long:  "Will rewrite the config and every file it includes as version 2, keeping aws_regions as the default regions of every account",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		configFile, err := cmd.Flags().GetString(configFlag)
+		if err != nil {
+			return errors.Wrapf(err, "Missing required argument %s", configFlag)
+		}
+		dryRun, err := cmd.Flags().GetBool(dryRunFlag)
+		if err != nil {
+			return errors.Wrapf(err, "error reading the `%s` flag", dryRunFlag)
+		}
+
+		fs := afero.NewOsFs()
+		files, err := config.Files(fs, configFile)
+		if err != nil {
+			return errors.Wrap(err, "could not read config")
+		}
+		for _, f := range files {
+			in, err := afero.ReadFile(fs, f)
+			if err != nil {
+				return errors.Wrapf(err, "could not read %s", f)
+			}
+			out, err := config.Migrate(in)
+			if err != nil {
+				return errors.Wrapf(err, "could not migrate %s", f)
+			}
+			if dryRun {
+				fmt.Printf("# %s\n%s\n", f, out)
+				continue
+			}
+			err = afero.WriteFile(fs, f, out, 0644)
+			if err != nil {
+				return errors.Wrapf(err, "could not write %s", f)
+			}
+			log.Infof("migrated %s", f)
+		}
+		return nil
+	},
+}
@@ -0,0 +1,242 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"time"
+
+	cziAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	units "github.com/docker/go-units"
+	"github.com/olekukonko/tablewriter"
+	"github.com/pkg/errors"
+	"github.com/spf13/cobra"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func init() {
+	addConfigFlag(explainCmd)
+	explainCmd.Flags().String(policyFlag, "", "Name of the policy to explain.")
+	explainCmd.Flags().String(accountFlag, "", "Name or id of the account the resource lives in.")
+	explainCmd.Flags().String(regionFlag, cziAws.DefaultRegion, "Region the resource lives in. Taken from the ARN when one is given.")
+	explainCmd.Flags().String(resourceFlag, "", "Id or ARN of the resource to explain.")
+	explainCmd.Flags().String(resourceTypeFlag, "", "Resource type, for when it can not be inferred from the resource or policy.")
+	rootCmd.AddCommand(explainCmd)
+}
+
+var explainCmd = &cobra.Command{
+	Use:   "explain",
+	Short: "Explain how a policy evaluates against a single resource",
+	Long:  "Will fetch a single resource and print its tags, labels and how each part of the policy evaluated against it",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return Explain(cmd, args)
+	},
+}
+
+// Explain fetches a single resource and explains how a policy evaluates against it.
+func Explain(cmd *cobra.Command, args []string) error {
+	conf, err := getConfig(cmd)
+	if err != nil {
+		return errors.Wrap(err, "could not read config")
+	}
+
+	valid := validateConfigVersion(conf.Version, validConfigVersions)
+	if !valid {
+		return errors.Errorf("invalid config version: %d. Valid options are %v", conf.Version, validConfigVersions)
+	}
+
+	flags := map[string]string{}
+	for _, f := range []string{policyFlag, accountFlag, regionFlag, resourceFlag, resourceTypeFlag} {
+		flags[f], err = cmd.Flags().GetString(f)
+		if err != nil {
+			return errors.Wrapf(err, "error reading the `%s` flag", f)
+		}
+	}
+	for _, f := range []string{policyFlag, accountFlag, resourceFlag} {
+		if flags[f] == "" {
+			return errors.Errorf("the `%s` flag is required", f)
+		}
+	}
+
+	policies, err := conf.GetPolicies()
+	if err != nil {
+		return err
+	}
+	p, err := findPolicy(policies, flags[policyFlag])
+	if err != nil {
+		return err
+	}
+
+	accounts, err := conf.GetAccounts()
+	if err != nil {
+		return err
+	}
+	account, err := findAccount(accounts, flags[accountFlag])
+	if err != nil {
+		return err
+	}
+
+	resourceID := cziAws.ParseResourceID(flags[resourceFlag])
+	region := flags[regionFlag]
+	if resourceID.Region != "" {
+		region = resourceID.Region
+	}
+
+	resourceType := flags[resourceTypeFlag]
+	if resourceType == "" {
+		resourceType = resourceID.Type
+	}
+	if resourceType == "" {
+		resourceType, err = policyResourceType(p)
+		if err != nil {
+			return err
+		}
+	}
+
+	awsClient, err := cziAws.NewClient(accounts, []string{region})
+	if err != nil {
+		return err
+	}
+	subject, err := awsClient.GetSubject(account, region, resourceType, resourceID.ID, p.LookbackPeriod())
+	if err != nil {
+		return errors.Wrapf(err, "could not fetch %s %s", resourceType, resourceID.ID)
+	}
+
+	iMap, err := conf.GetIdentityMap()
+	if err != nil {
+		return err
+	}
+
+	printExplanation(os.Stdout, p, account, subject, p.Explain(resourceType, subject, account), iMap)
+	return nil
+}
+
+func findPolicy(policies []policy.Policy, name string) (*policy.Policy, error) {
+	for i := range policies {
+		if policies[i].Name == name {
+			return &policies[i], nil
+		}
+	}
+	return nil, errors.Errorf("no policy named %s in config", name)
+}
+
+func findAccount(accounts []*policy.Account, nameOrID string) (*policy.Account, error) {
+	for _, a := range accounts {
+		if a.Name == nameOrID || strconv.FormatInt(a.ID, 10) == nameOrID {
+			return a, nil
+		}
+	}
+	return nil, errors.Errorf("no account with name or id %s in config", nameOrID)
+}
+
+// policyResourceType returns the resource type the policy selects, if it selects exactly one
+func policyResourceType(p *policy.Policy) (string, error) {
+	var matches []string
+	for _, t := range cziAws.ResourceTypes {
+		if p.MatchResource(labels.Set{"name": t.Name}) {
+			matches = append(matches, t.Name)
+		}
+	}
+	if len(matches) != 1 {
+		return "", errors.Errorf("could not infer the resource type (policy %s selects %v), please pass --%s", p.Name, matches, resourceTypeFlag)
+	}
+	return matches[0], nil
+}
+
+func printExplanation(w io.Writer, p *policy.Policy, account *policy.Account, s policy.Subject, e policy.Explanation, iMap map[string]string) {
+	fmt.Fprintf(w, "Resource:   %s %s\n", e.ResourceType, s.GetID())
+	if s.GetName() != "" {
+		fmt.Fprintf(w, "Name:       %s\n", s.GetName())
+	}
+	fmt.Fprintf(w, "Account:    %s (%d)\n", account.Name, account.ID)
+	if s.GetRegion() != "" {
+		fmt.Fprintf(w, "Region:     %s\n", s.GetRegion())
+	}
+	fmt.Fprintf(w, "Console:    %s\n", s.GetConsoleURL())
+	if createdAt := s.GetCreatedAt(); createdAt != nil {
+		fmt.Fprintf(w, "Created at: %s\n", createdAt.Format(time.RFC3339))
+	}
+
+	fmt.Fprintln(w, "\nTAGS")
+	printSet(w, []string{"Tag", "Value"}, s.GetTags())
+	fmt.Fprintln(w, "\nLABELS")
+	printSet(w, []string{"Label", "Value"}, s.GetLabels())
+
+	fmt.Fprintf(w, "\nPOLICY %s\n", p.Name)
+	table := tablewriter.NewWriter(w)
+	table.SetHeader([]string{"Selector", "Requirement", "Result"})
+	appendSelector(table, "resource_selector", &e.Resource)
+	appendSelector(table, "tag_selector", e.Tags)
+	appendSelector(table, "label_selector", e.Labels)
+	table.Render()
+	if !e.AccountEvaluates {
+		fmt.Fprintf(w, "Account:    account %s does not evaluate policy %s, see its policies include or exclude\n", account.Name, p.Name)
+	}
+	fmt.Fprintf(w, "Matched:    %t\n", e.Matched)
+
+	if e.IgnoreAge {
+		fmt.Fprintln(w, "Expired:    ignored (remediation has ignore_age)")
+	} else if e.MaxAge == nil {
+		fmt.Fprintln(w, "Expired:    false (policy has no max_age)")
+	} else if e.Age == nil {
+		fmt.Fprintln(w, "Expired:    false (resource has no creation time)")
+	} else {
+		fmt.Fprintf(w, "Expired:    %t (age %s, max_age %s)\n", e.Expired, units.HumanDuration(*e.Age), units.HumanDuration(*e.MaxAge))
+	}
+
+	switch {
+	case p.Remediation == nil:
+		fmt.Fprintln(w, "Remediate:  false (policy has no remediation)")
+	case e.ShouldRemediate:
+		fmt.Fprintf(w, "Remediate:  true (would %s it)\n", p.Remediation.Action)
+	case !e.Matched:
+		fmt.Fprintln(w, "Remediate:  false (policy does not match)")
+	default:
+		fmt.Fprintln(w, "Remediate:  false (resource has not expired)")
+	}
+
+	switch {
+	case e.Owner == "":
+		fmt.Fprintf(w, "Owner:      none (resource has no owner and account %s has no owner)\n", account.Name)
+	case iMap[e.Owner] != "":
+		fmt.Fprintf(w, "Owner:      %s (from %s), notified via slack channel %s from identity_map\n", e.Owner, e.OwnerSource, iMap[e.Owner])
+	default:
+		fmt.Fprintf(w, "Owner:      %s (from %s), notified via slack user lookup by email\n", e.Owner, e.OwnerSource)
+	}
+}
+
+func printSet(w io.Writer, header []string, set labels.Set) {
+	keys := make([]string, 0, len(set))
+	for k := range set {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	table := tablewriter.NewWriter(w)
+	table.SetHeader(header)
+	for _, k := range keys {
+		table.Append([]string{k, set[k]})
+	}
+	table.Render()
+}
+
+func appendSelector(table *tablewriter.Table, name string, e *policy.SelectorExplanation) {
+	if e == nil {
+		table.Append([]string{name, "(not set, policy never matches)", result(false)})
+		return
+	}
+	table.Append([]string{name, e.Selector, result(e.Matched)})
+	for _, r := range e.Requirements {
+		table.Append([]string{"", r.Requirement, result(r.Matched)})
+	}
+}
+
+func result(matched bool) string {
+	if matched {
+		return "match"
+	}
+	return "no match"
+}
@@ -0,0 +1,192 @@
+package cmd
+
+import (
+	"bytes"
+	"testing"
+	"time"
+
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type subject struct {
+	createdAt *time.Time
+	tags      labels.Set
+	labels    labels.Set
+}
+
+func (s *subject) Delete() error            { return nil }
+func (s *subject) GetCreatedAt() *time.Time { return s.createdAt }
+func (s *subject) GetID() string            { return "eipalloc-123" }
+func (s *subject) GetLabels() labels.Set    { return s.labels }
+func (s *subject) GetName() string          { return "" }
+func (s *subject) GetOwner() string         { return s.tags["owner"] }
+func (s *subject) GetTags() labels.Set      { return s.tags }
+func (s *subject) GetConsoleURL() string    { return "https://console.aws.amazon.com" }
+func (s *subject) GetRegion() string        { return "us-west-2" }
+
+// newPolicy returns a policy with the selectors, an empty string leaves a selector unset
+func newPolicy(t *testing.T, name string, resourceSelector string, tagSelector string) *policy.Policy {
+	p := &policy.Policy{Name: name}
+	var err error
+	p.ResourceSelector, err = labels.Parse(resourceSelector)
+	assert.NoError(t, err)
+	if tagSelector != "" {
+		p.TagSelector, err = labels.Parse(tagSelector)
+		assert.NoError(t, err)
+	}
+	p.LabelSelector = labels.Everything()
+	return p
+}
+
+func TestFindAccount(t *testing.T) {
+	accounts := []*policy.Account{
+		{Name: "hub", ID: 1},
+		{Name: "2", ID: 3},
+	}
+	tests := []struct {
+		name      string
+		nameOrID  string
+		accountID int64
+		err       bool
+	}{
+		{"by name", "hub", 1, false},
+		{"by id", "3", 3, false},
+		{"name that looks like an id", "2", 3, false},
+		{"unknown", "other", 0, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			account, err := findAccount(accounts, test.nameOrID)
+			if test.err {
+				a.Error(err)
+				a.Contains(err.Error(), "no account with name or id "+test.nameOrID)
+				return
+			}
+			a.NoError(err)
+			a.Equal(test.accountID, account.ID)
+		})
+	}
+}
+
+func TestPolicyResourceType(t *testing.T) {
+	tests := []struct {
+		name             string
+		resourceSelector string
+		resourceType     string
+		err              string
+	}{
+		{"one type", "name in (elastic_ip)", "elastic_ip", ""},
+		{"no types", "name in (typo)", "", "could not infer the resource type (policy test selects [])"},
+		{"several types", "name in (rds_instance, rds_cluster)", "", "could not infer the resource type (policy test selects [rds_instance rds_cluster])"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			resourceType, err := policyResourceType(newPolicy(t, "test", test.resourceSelector, "owner"))
+			if test.err != "" {
+				a.Error(err)
+				a.Contains(err.Error(), test.err)
+				a.Contains(err.Error(), "please pass --resource-type")
+				return
+			}
+			a.NoError(err)
+			a.Equal(test.resourceType, resourceType)
+		})
+	}
+}
+
+func TestPrintExplanation(t *testing.T) {
+	createdAt := time.Now().Add(-48 * time.Hour)
+	maxAge := 24 * time.Hour
+	s := &subject{
+		createdAt: &createdAt,
+		tags:      labels.Set{"env": "test"},
+		labels:    labels.Set{"is_associated": "true"},
+	}
+
+	tests := []struct {
+		name     string
+		policy   func(t *testing.T) *policy.Policy
+		account  *policy.Account
+		iMap     map[string]string
+		contains []string
+	}{
+		{
+			name: "expired",
+			policy: func(t *testing.T) *policy.Policy {
+				p := newPolicy(t, "old-eips", "name in (elastic_ip)", "env=test")
+				p.MaxAge = &maxAge
+				return p
+			},
+			account: &policy.Account{Name: "hub", ID: 1, Owner: "infra@example.com"},
+			iMap:    map[string]string{"infra@example.com": "#infra"},
+			contains: []string{
+				"Resource:   elastic_ip eipalloc-123",
+				"Account:    hub (1)",
+				"Region:     us-west-2",
+				"POLICY old-eips",
+				"| env | test",
+				"| is_associated | true",
+				"Matched:    true",
+				"Expired:    true (age 2 days, max_age 24 hours)",
+				"Remediate:  false (policy has no remediation)",
+				"Owner:      infra@example.com (from account), notified via slack channel #infra from identity_map",
+			},
+		},
+		{
+			name: "no tag selector",
+			policy: func(t *testing.T) *policy.Policy {
+				return newPolicy(t, "old-eips", "name in (elastic_ip)", "")
+			},
+			account: &policy.Account{Name: "hub", ID: 1},
+			contains: []string{
+				"| tag_selector      | (not set, policy never         | no match |",
+				"Matched:    false",
+				"Expired:    false (policy has no max_age)",
+				"Owner:      none (resource has no owner and account hub has no owner)",
+			},
+		},
+		{
+			name: "ignoring the age",
+			policy: func(t *testing.T) *policy.Policy {
+				p := newPolicy(t, "unassociated-eips", "name in (elastic_ip)", "env=test")
+				p.Remediation = &policy.Remediation{Action: policy.ActionRelease, IgnoreAge: true}
+				return p
+			},
+			account: &policy.Account{Name: "hub", ID: 1, Owner: "infra@example.com"},
+			contains: []string{
+				"Expired:    ignored (remediation has ignore_age)",
+				"Remediate:  true (would release it)",
+				"Owner:      infra@example.com (from account), notified via slack user lookup by email",
+			},
+		},
+		{
+			name: "excluded by the account",
+			policy: func(t *testing.T) *policy.Policy {
+				p := newPolicy(t, "unassociated-eips", "name in (elastic_ip)", "env=test")
+				p.Remediation = &policy.Remediation{Action: policy.ActionRelease, IgnoreAge: true}
+				return p
+			},
+			account: &policy.Account{Name: "hub", ID: 1, ExcludePolicies: []string{"unassociated-eips"}},
+			contains: []string{
+				"Account:    account hub does not evaluate policy unassociated-eips",
+				"Matched:    false",
+				"Remediate:  false (policy does not match)",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			p := test.policy(t)
+			out := &bytes.Buffer{}
+			printExplanation(out, p, test.account, s, p.Explain("elastic_ip", s, test.account), test.iMap)
+			for _, c := range test.contains {
+				a.Contains(out.String(), c)
+			}
+		})
+	}
+}
@@ -72,6 +72,7 @@ func Run(cmd *cobra.Command, args []string) error {
 	notifier := notifier.New(slackToken, ui, iMap)
 
 	runner := runner.New(conf)
+	runner.Record = mode != "dry"
 	violations, err := runner.Run(only)
 	if err != nil {
 		return err
@@ -87,5 +88,28 @@ func Run(cmd *cobra.Command, args []string) error {
 		// TODO report this to sentry
 		log.Error(err)
 	}
+
+	for _, v := range violations {
+		if !v.ShouldRemediate() {
+			continue
+		}
+		action := v.Policy.Remediation.Action
+		if mode == "dry" {
+			fmt.Printf("would %s resource %s for policy %s\n", action, v.Subject.GetID(), v.Policy.Name)
+			continue
+		}
+		if mode == "interactive" && !ui.Confirm(fmt.Sprintf("%s resource %s for policy %s?", action, v.Subject.GetID(), v.Policy.Name)) {
+			continue
+		}
+		err = v.Policy.Remediation.Apply(v.Subject)
+		if err != nil {
+			log.Errorf("could not %s %s: %s", action, v.Subject.GetID(), err)
+		}
+	}
+
+	err = runner.WaitForRemediations()
+	if err != nil {
+		log.Error(err)
+	}
 	return nil
 }
@@ -8,17 +8,26 @@ import (
 )
 
 const (
-	configFlag = "config"
-	modeFlag   = "mode"
-	onlyFlag   = "only"
+	configFlag       = "config"
+	modeFlag         = "mode"
+	onlyFlag         = "only"
+	policyFlag       = "policy"
+	accountFlag      = "account"
+	regionFlag       = "region"
+	resourceFlag     = "resource"
+	resourceTypeFlag = "resource-type"
+	dryRunFlag       = "dry-run"
 )
 
-var validConfigVersions = []int{1}
+var validConfigVersions = config.ValidVersions
 
 func addCommonFlags(cmd *cobra.Command) {
-	cmd.Flags().StringP(configFlag, "c", "config.yml", "Use this to override the reaper config file.")
+	addConfigFlag(cmd)
 	cmd.Flags().StringArrayP(onlyFlag, "o", []string{}, "Run only listed policies.")
+}
 
+func addConfigFlag(cmd *cobra.Command) {
+	cmd.Flags().StringP(configFlag, "c", "config.yml", "Use this to override the reaper config file.")
 }
 
 func getConfig(cmd *cobra.Command) (*config.Config, error) {
@@ -7,7 +7,7 @@ require (
 	github.com/Masterminds/semver v1.4.2 // indirect
 	github.com/Masterminds/sprig v2.20.0+incompatible
 	github.com/apparentlymart/go-cidr v1.0.1
-	github.com/aws/aws-sdk-go v1.30.1
+	github.com/aws/aws-sdk-go v1.44.0
 	github.com/chanzuckerberg/go-misc v0.0.0-20200401135417-0c78554600ba
 	github.com/docker/go-units v0.4.0
 	github.com/go-errors/errors v1.0.1
@@ -26,5 +26,6 @@ require (
 	github.com/stretchr/testify v1.5.1
 	github.com/tcnksm/go-input v0.0.0-20180404061846-548a7d7a8ee8
 	gopkg.in/yaml.v2 v2.2.8
+	gopkg.in/yaml.v3 v3.0.1
 	k8s.io/apimachinery v0.0.0-20181009084401-76721d167b70
 )
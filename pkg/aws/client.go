@@ -6,8 +6,18 @@ import (
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/credentials/stscreds"
 	"github.com/aws/aws-sdk-go/aws/session"
-	cziAws "github.com/chanzuckerberg/go-misc/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/aws/aws-sdk-go/service/support"
+	"github.com/aws/aws-sdk-go/service/support/supportiface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
 )
 
 const (
@@ -17,6 +27,22 @@ const (
 
 // Client is an AWS client
 type Client struct {
+	// discoveredRegions caches the regions enabled in each account
+	discoveredRegions map[int64][]string
+	// ebsVolumeFirstSeen are the first seen tag changes evaluation found volumes need, by volume id
+	ebsVolumeFirstSeen map[string]ebsVolumeFirstSeenUpdate
+	// stackDeletes are the cloudformation stacks whose delete started, see WaitForStackDeletes
+	stackDeletes []*CloudFormationStack
+}
+
+// AccountClient holds the account, region and role specific clients of the services most
+// resource types use. Use GetSession for the others.
+type AccountClient struct {
+	EC2     ec2iface.EC2API
+	IAM     iamiface.IAMAPI
+	Lambda  lambdaiface.LambdaAPI
+	S3      s3iface.S3API
+	Support supportiface.SupportAPI
 }
 
 // WalkFun is a walk function over AWS entities
@@ -24,11 +50,27 @@ type WalkFun func(*Entity, error) error
 
 // NewClient returns a new aws client
 func NewClient(accounts []*policy.Account, regions []string) (*Client, error) {
-	return &Client{}, nil
+	return &Client{
+		discoveredRegions:  map[int64][]string{},
+		ebsVolumeFirstSeen: map[string]ebsVolumeFirstSeenUpdate{},
+	}, nil
 }
 
 // Get will return a new account, region and role specific AWS client.
-func (c *Client) Get(accountID int64, roleName, externalID string, region string) *cziAws.Client {
+func (c *Client) Get(accountID int64, roleName, externalID string, region string) *AccountClient {
+	sess, conf := c.GetSession(accountID, roleName, externalID, region)
+	return &AccountClient{
+		EC2:     ec2.New(sess, conf),
+		IAM:     iam.New(sess, conf),
+		Lambda:  lambda.New(sess, conf),
+		S3:      s3.New(sess, conf),
+		Support: support.New(sess, conf),
+	}
+}
+
+// GetSession will return a session and an account, region and role specific config. Use it
+// for services AccountClient doesn't cover.
+func (c *Client) GetSession(accountID int64, roleName, externalID string, region string) (*session.Session, *aws.Config) {
 	sess := session.Must(session.NewSessionWithOptions(session.Options{
 		SharedConfigState: session.SharedConfigEnable,
 	}))
@@ -46,20 +88,27 @@ func (c *Client) Get(accountID int64, roleName, externalID string, region string
 		Credentials: roleCreds,
 		Region:      aws.String(region),
 	}
-	return cziAws.New(sess).WithAllServices(conf)
+	return sess, conf
 }
 
 func roleArn(accountID int64, roleName string) string {
 	return fmt.Sprintf("arn:aws:iam::%d:role/%s", accountID, roleName)
 }
 
-// WalkAccountsAndRegions will invoke f for each region in each account supplied.
-func (c *Client) WalkAccountsAndRegions(accounts []*policy.Account, regions []string, f func(*cziAws.Client, *policy.Account, string)) error {
+// WalkAccountsAndRegions will invoke f for each region in each account supplied. See
+// AccountRegions for how the regions for each account are picked.
+func (c *Client) WalkAccountsAndRegions(accounts []*policy.Account, regions []string, f func(*AccountClient, *policy.Account, string)) error {
+	var errs *multierror.Error
 	for _, account := range accounts {
-		for _, region := range regions {
+		accountRegions, err := c.AccountRegions(account, regions)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		for _, region := range accountRegions {
 			client := c.Get(account.ID, account.Role, account.ExternalID, region)
 			f(client, account, region)
 		}
 	}
-	return nil
+	return errs.ErrorOrNil()
 }
@@ -0,0 +1,252 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// cloudformation_stack specific labels
+const (
+	stackLabelStatus                TypeEntityLabel = "status"
+	stackLabelDriftStatus           TypeEntityLabel = "drift_status"
+	stackLabelTerminationProtection TypeEntityLabel = "termination_protection"
+	stackLabelIsNested              TypeEntityLabel = "is_nested"
+	stackLabelLastUpdated           TypeEntityLabel = "last_updated"
+	stackLabelDaysSinceUpdated      TypeEntityLabel = "days_since_updated"
+)
+
+// how often and for how long we poll the stacks we are deleting
+const (
+	stackDeletePollInterval = 15 * time.Second
+	stackDeleteTimeout      = time.Hour
+)
+
+// CloudFormationStack is an evaluation entity representing a cloudformation stack
+type CloudFormationStack struct {
+	Entity
+	stackID               string
+	status                string
+	statusReason          string
+	terminationProtection bool
+	nested                bool
+	svc                   cloudformationiface.CloudFormationAPI
+	// deletes collects the stack for Client.WaitForStackDeletes once its delete started
+	deletes *[]*CloudFormationStack
+}
+
+// GetID returns the stack name
+func (s *CloudFormationStack) GetID() string {
+	return s.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (s *CloudFormationStack) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/cloudformation/home?region=%s#/stacks/stackinfo?stackId=%s"
+	return fmt.Sprintf(t, s.Region, s.Region, url.QueryEscape(s.stackID))
+}
+
+// NewCloudFormationStack returns a new cloudformation stack entity
+func NewCloudFormationStack(stack *cloudformation.Stack, region string, svc cloudformationiface.CloudFormationAPI) *CloudFormationStack {
+	entity := &CloudFormationStack{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if stack == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if stack.StackName != nil {
+		entity.ID = *stack.StackName
+		entity.Name = *stack.StackName
+	}
+	entity.stackID = aws.StringValue(stack.StackId)
+	entity.status = aws.StringValue(stack.StackStatus)
+	entity.statusReason = aws.StringValue(stack.StackStatusReason)
+	entity.terminationProtection = aws.BoolValue(stack.EnableTerminationProtection)
+	entity.nested = stack.ParentId != nil
+
+	for _, tag := range stack.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(labelARN, stack.StackId).
+		AddLabel(stackLabelStatus, stack.StackStatus).
+		AddBoolLabel(stackLabelTerminationProtection, stack.EnableTerminationProtection).
+		AddBoolLabel(stackLabelIsNested, &entity.nested).
+		AddCreatedAt(stack.CreationTime)
+	if stack.DriftInformation != nil {
+		entity.AddLabel(stackLabelDriftStatus, stack.DriftInformation.StackDriftStatus)
+	}
+
+	updated := stack.LastUpdatedTime
+	if updated == nil {
+		updated = stack.CreationTime
+	}
+	if updated != nil {
+		days := int64(math.Floor(time.Since(*updated).Hours() / 24))
+		entity.
+			AddLabel(stackLabelLastUpdated, aws.String(updated.UTC().Format("2006-01-02"))).
+			AddInt64Label(stackLabelDaysSinceUpdated, &days)
+	}
+
+	return entity
+}
+
+// Delete starts deleting the stack, along with every resource it created. Deletes take a while, so
+// it doesn't wait for them; Client.WaitForStackDeletes waits for every delete of the run.
+func (s *CloudFormationStack) Delete() error {
+	if s.terminationProtection {
+		return errors.Errorf("stack %s has termination protection enabled", s.ID)
+	}
+	if s.nested {
+		return errors.Errorf("stack %s is nested, delete its root stack instead", s.ID)
+	}
+	switch {
+	case s.status == cloudformation.StackStatusDeleteInProgress:
+		log.Infof("Stack %s is already deleting", s.ID)
+		s.trackDelete()
+		return nil
+	case s.status == cloudformation.StackStatusDeleteFailed:
+		// retrying would fail the same way until someone deals with whatever blocked the delete
+		return errors.Errorf("an earlier delete of stack %s failed: %s", s.ID, s.statusReason)
+	case strings.HasSuffix(s.status, "_IN_PROGRESS"):
+		return errors.Errorf("stack %s is %s, not deleting it until that finishes", s.ID, s.status)
+	}
+
+	log.Warnf("Deleting stack %s", s.ID)
+	_, err := s.svc.DeleteStackWithContext(context.Background(), &cloudformation.DeleteStackInput{StackName: &s.stackID})
+	if err != nil {
+		return errors.Wrapf(err, "could not delete stack %s", s.ID)
+	}
+	s.trackDelete()
+	return nil
+}
+
+// trackDelete hands the stack to the client which evaluated it, to wait for its delete
+func (s *CloudFormationStack) trackDelete() {
+	if s.deletes != nil {
+		*s.deletes = append(*s.deletes, s)
+	}
+}
+
+// pollDelete returns true while the stack is deleting, and an error if its delete failed. We
+// describe the stack by id since deleted stacks can't be described by name.
+func (s *CloudFormationStack) pollDelete(ctx context.Context) (bool, error) {
+	output, err := s.svc.DescribeStacksWithContext(ctx, &cloudformation.DescribeStacksInput{StackName: &s.stackID})
+	if err != nil {
+		return false, errors.Wrapf(err, "could not describe stack %s while deleting it", s.ID)
+	}
+	if len(output.Stacks) == 0 {
+		return false, nil
+	}
+
+	stack := output.Stacks[0]
+	switch aws.StringValue(stack.StackStatus) {
+	case cloudformation.StackStatusDeleteComplete:
+		log.Infof("Deleted stack %s", s.ID)
+		return false, nil
+	case cloudformation.StackStatusDeleteFailed:
+		return false, errors.Errorf("could not delete stack %s: %s", s.ID, aws.StringValue(stack.StackStatusReason))
+	}
+	return true, nil
+}
+
+// WaitForCloudFormationStackDeletes waits for the deletes of stacks to finish, for at most
+// timeout, and returns an error for every stack that failed to delete or is still deleting
+func WaitForCloudFormationStackDeletes(stacks []*CloudFormationStack, timeout time.Duration) error {
+	var errs *multierror.Error
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	for len(stacks) > 0 {
+		stillDeleting := []*CloudFormationStack{}
+		for _, s := range stacks {
+			deleting, err := s.pollDelete(ctx)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			if deleting {
+				stillDeleting = append(stillDeleting, s)
+			}
+		}
+		stacks = stillDeleting
+		if len(stacks) == 0 {
+			break
+		}
+
+		log.Infof("Waiting for %d stacks to delete", len(stacks))
+		select {
+		case <-ctx.Done():
+			for _, s := range stacks {
+				errs = multierror.Append(errs, errors.Errorf("gave up waiting for stack %s to delete", s.ID))
+			}
+			return errs.ErrorOrNil()
+		case <-time.After(stackDeletePollInterval):
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// WaitForStackDeletes waits for the deletes of the stacks this client evaluated to finish, for at
+// most an hour. Every delete is started before we wait on any of them.
+func (c *Client) WaitForStackDeletes() error {
+	stacks := c.stackDeletes
+	c.stackDeletes = nil
+	return WaitForCloudFormationStackDeletes(stacks, stackDeleteTimeout)
+}
+
+// EvalCloudFormationStack walks through all cloudformation stacks
+func (c *Client) EvalCloudFormationStack(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := cloudformation.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		input := &cloudformation.DescribeStacksInput{}
+		err := svc.DescribeStacksPagesWithContext(ctx, input, func(output *cloudformation.DescribeStacksOutput, lastPage bool) bool {
+			for _, stack := range output.Stacks {
+				s := NewCloudFormationStack(stack, region, svc)
+				s.deletes = &c.stackDeletes
+				if p.Match(s) {
+					violation := policy.NewViolation(p, s, p.Expired(s), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getCloudFormationStack(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := cloudformation.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	output, err := svc.DescribeStacksWithContext(ctx, &cloudformation.DescribeStacksInput{StackName: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe stack %s", id)
+	}
+	if len(output.Stacks) == 0 {
+		return nil, errors.Errorf("stack %s not found in %s", id, region)
+	}
+	s := NewCloudFormationStack(output.Stacks[0], region, svc)
+	s.deletes = &c.stackDeletes
+	return s, nil
+}
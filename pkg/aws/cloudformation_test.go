@@ -0,0 +1,126 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCloudFormationStackDelete(t *testing.T) {
+	a := assert.New(t)
+	stackID := "arn:aws:cloudformation:us-west-2:123456789012:stack/web/1234"
+
+	tests := []struct {
+		name  string
+		stack cloudformation.Stack
+		calls []string
+		err   bool
+	}{
+		{
+			name:  "deletes by stack id",
+			stack: cloudformation.Stack{StackStatus: aws.String(cloudformation.StackStatusCreateComplete)},
+			calls: []string{"delete " + stackID},
+		},
+		{
+			name:  "rolled back update",
+			stack: cloudformation.Stack{StackStatus: aws.String(cloudformation.StackStatusUpdateRollbackComplete)},
+			calls: []string{"delete " + stackID},
+		},
+		{
+			name: "termination protection",
+			stack: cloudformation.Stack{
+				StackStatus:                 aws.String(cloudformation.StackStatusCreateComplete),
+				EnableTerminationProtection: aws.Bool(true),
+			},
+			err: true,
+		},
+		{
+			name: "nested",
+			stack: cloudformation.Stack{
+				StackStatus: aws.String(cloudformation.StackStatusCreateComplete),
+				ParentId:    aws.String("arn:aws:cloudformation:us-west-2:123456789012:stack/root/5678"),
+			},
+			err: true,
+		},
+		{
+			name:  "already deleting",
+			stack: cloudformation.Stack{StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress)},
+		},
+		{
+			name: "earlier delete failed",
+			stack: cloudformation.Stack{
+				StackStatus:       aws.String(cloudformation.StackStatusDeleteFailed),
+				StackStatusReason: aws.String("The bucket you tried to delete is not empty"),
+			},
+			err: true,
+		},
+		{
+			name:  "updating",
+			stack: cloudformation.Stack{StackStatus: aws.String(cloudformation.StackStatusUpdateInProgress)},
+			err:   true,
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeCloudFormation{}
+		stack := test.stack
+		stack.StackName = aws.String("web")
+		stack.StackId = aws.String(stackID)
+		err := reaperAws.NewCloudFormationStack(&stack, "us-west-2", svc).Delete()
+		if test.err {
+			a.Error(err, test.name)
+		} else {
+			a.NoError(err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
+
+func TestWaitForCloudFormationStackDeletes(t *testing.T) {
+	a := assert.New(t)
+
+	stack := func(name string, svc *fakeCloudFormation) *reaperAws.CloudFormationStack {
+		return reaperAws.NewCloudFormationStack(&cloudformation.Stack{
+			StackName:   aws.String(name),
+			StackId:     aws.String("arn:aws:cloudformation:us-west-2:123456789012:stack/" + name + "/1234"),
+			StackStatus: aws.String(cloudformation.StackStatusDeleteInProgress),
+		}, "us-west-2", svc)
+	}
+	deleted := &fakeCloudFormation{status: cloudformation.StackStatusDeleteComplete}
+	failed := &fakeCloudFormation{status: cloudformation.StackStatusDeleteFailed, statusReason: "The bucket you tried to delete is not empty"}
+	deleting := &fakeCloudFormation{status: cloudformation.StackStatusDeleteInProgress}
+
+	a.NoError(reaperAws.WaitForCloudFormationStackDeletes(nil, time.Minute))
+	a.NoError(reaperAws.WaitForCloudFormationStackDeletes([]*reaperAws.CloudFormationStack{stack("web", deleted)}, time.Minute))
+
+	// every stack is polled, and only those that didn't delete are reported
+	err := reaperAws.WaitForCloudFormationStackDeletes([]*reaperAws.CloudFormationStack{
+		stack("web", deleted),
+		stack("db", failed),
+		stack("queue", deleting),
+	}, 0)
+	a.Error(err)
+	a.NotContains(err.Error(), "web")
+	a.Contains(err.Error(), "could not delete stack db: The bucket you tried to delete is not empty")
+	a.Contains(err.Error(), "gave up waiting for stack queue to delete")
+}
+
+func TestNewCloudFormationStack(t *testing.T) {
+	a := assert.New(t)
+
+	stack := reaperAws.NewCloudFormationStack(&cloudformation.Stack{
+		StackName:                   aws.String("web"),
+		StackStatus:                 aws.String(cloudformation.StackStatusCreateComplete),
+		EnableTerminationProtection: aws.Bool(true),
+		ParentId:                    aws.String("arn:aws:cloudformation:us-west-2:123456789012:stack/root/5678"),
+	}, "us-west-2", nil)
+	labels := stack.GetLabels()
+	a.Equal("CREATE_COMPLETE", labels["status"])
+	a.Equal("true", labels["termination_protection"])
+	a.Equal("true", labels["is_nested"])
+	a.NotContains(labels, "days_since_updated")
+}
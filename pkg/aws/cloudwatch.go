@@ -0,0 +1,89 @@
+package aws
+
+import (
+	"context"
+	"math"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/pkg/errors"
+)
+
+// metricPeriod is the granularity we ask CloudWatch for. A day keeps us well under the 1440
+// datapoints GetMetricStatistics returns for any lookback under a few years.
+const metricPeriod = 24 * time.Hour
+
+// metricQuery identifies a CloudWatch metric and how to aggregate it over the lookback period
+type metricQuery struct {
+	Namespace  string
+	Metric     string
+	Dimensions map[string]string
+	// Statistic is cloudwatch.StatisticSum to add up the datapoints, or
+	// cloudwatch.StatisticMaximum to take the largest
+	Statistic string
+}
+
+// getMetric aggregates a metric over the lookback period. A metric without datapoints is 0.
+func getMetric(ctx context.Context, svc cloudwatchiface.CloudWatchAPI, q metricQuery, lookback time.Duration) (float64, error) {
+	value, _, err := lookupMetric(ctx, svc, q, lookback)
+	return value, err
+}
+
+// lookupMetric aggregates a metric over the lookback period, and reports whether it had any
+// datapoints, for metrics where none means we don't know rather than 0
+func lookupMetric(ctx context.Context, svc cloudwatchiface.CloudWatchAPI, q metricQuery, lookback time.Duration) (float64, bool, error) {
+	end := time.Now()
+	input := &cloudwatch.GetMetricStatisticsInput{
+		Namespace:  aws.String(q.Namespace),
+		MetricName: aws.String(q.Metric),
+		StartTime:  aws.Time(end.Add(-lookback)),
+		EndTime:    aws.Time(end),
+		Period:     aws.Int64(int64(metricPeriod.Seconds())),
+		Statistics: aws.StringSlice([]string{q.Statistic}),
+	}
+	for name, value := range q.Dimensions {
+		input.Dimensions = append(input.Dimensions, &cloudwatch.Dimension{
+			Name:  aws.String(name),
+			Value: aws.String(value),
+		})
+	}
+
+	output, err := svc.GetMetricStatisticsWithContext(ctx, input)
+	if err != nil {
+		return 0, false, errors.Wrapf(err, "could not get %s %s metric", q.Namespace, q.Metric)
+	}
+
+	var res float64
+	for _, datapoint := range output.Datapoints {
+		switch q.Statistic {
+		case cloudwatch.StatisticSum:
+			res += aws.Float64Value(datapoint.Sum)
+		case cloudwatch.StatisticMaximum:
+			res = math.Max(res, aws.Float64Value(datapoint.Maximum))
+		default:
+			return 0, false, errors.Errorf("unsupported statistic %s", q.Statistic)
+		}
+	}
+	return res, len(output.Datapoints) > 0, nil
+}
+
+// sumMetrics adds up several metrics over the lookback period
+func sumMetrics(ctx context.Context, svc cloudwatchiface.CloudWatchAPI, queries []metricQuery, lookback time.Duration) (float64, error) {
+	var res float64
+	for _, q := range queries {
+		value, err := getMetric(ctx, svc, q, lookback)
+		if err != nil {
+			return 0, err
+		}
+		res += value
+	}
+	return res, nil
+}
+
+// formatMetric formats a metric as a label value
+func formatMetric(value float64) *string {
+	return aws.String(strconv.FormatInt(int64(math.Round(value)), 10))
+}
@@ -0,0 +1,215 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/dynamodb/dynamodbiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// dynamodb_table specific labels
+const (
+	dynamoDBLabelStatus                TypeEntityLabel = "status"
+	dynamoDBLabelBillingMode           TypeEntityLabel = "billing_mode"
+	dynamoDBLabelReadCapacity          TypeEntityLabel = "read_capacity"
+	dynamoDBLabelWriteCapacity         TypeEntityLabel = "write_capacity"
+	dynamoDBLabelItemCount             TypeEntityLabel = "item_count"
+	dynamoDBLabelSizeBytes             TypeEntityLabel = "size_bytes"
+	dynamoDBLabelEncryption            TypeEntityLabel = "encryption"
+	dynamoDBLabelConsumedReadCapacity  TypeEntityLabel = "consumed_read_capacity"
+	dynamoDBLabelConsumedWriteCapacity TypeEntityLabel = "consumed_write_capacity"
+)
+
+// dynamodb tables are always encrypted, by default with a key owned by AWS
+const (
+	dynamoDBEncryptionAWSOwned = "aws_owned"
+	dynamoDBEncryptionKMS      = "kms"
+)
+
+// DynamoDBTable is an evaluation entity representing a dynamodb table
+type DynamoDBTable struct {
+	Entity
+}
+
+// GetID returns the table name
+func (d *DynamoDBTable) GetID() string {
+	return d.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (d *DynamoDBTable) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/dynamodb/home?region=%s#tables:selected=%s"
+	return fmt.Sprintf(t, d.Region, d.Region, d.ID)
+}
+
+// DynamoDBTableDetails are looked up separately from the table description
+type DynamoDBTableDetails struct {
+	Tags                  []*dynamodb.Tag
+	ConsumedReadCapacity  float64
+	ConsumedWriteCapacity float64
+}
+
+// NewDynamoDBTable returns a new dynamodb table entity
+func NewDynamoDBTable(table *dynamodb.TableDescription, details *DynamoDBTableDetails, region string) *DynamoDBTable {
+	entity := &DynamoDBTable{
+		Entity: NewEntity(),
+	}
+	if table == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if table.TableName != nil {
+		entity.ID = *table.TableName
+		entity.Name = *table.TableName
+	}
+
+	for _, tag := range details.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+
+	// tables created before on-demand billing have no billing mode summary
+	billingMode := dynamodb.BillingModeProvisioned
+	if table.BillingModeSummary != nil && table.BillingModeSummary.BillingMode != nil {
+		billingMode = *table.BillingModeSummary.BillingMode
+	}
+	encryption := dynamoDBEncryptionAWSOwned
+	if table.SSEDescription != nil && aws.StringValue(table.SSEDescription.Status) == dynamodb.SSEStatusEnabled {
+		encryption = dynamoDBEncryptionKMS
+	}
+
+	entity.
+		AddLabel(labelARN, table.TableArn).
+		AddLabel(dynamoDBLabelStatus, table.TableStatus).
+		AddLabel(dynamoDBLabelBillingMode, &billingMode).
+		AddInt64Label(dynamoDBLabelItemCount, table.ItemCount).
+		AddInt64Label(dynamoDBLabelSizeBytes, table.TableSizeBytes).
+		AddLabel(dynamoDBLabelEncryption, &encryption).
+		AddLabel(dynamoDBLabelConsumedReadCapacity, formatMetric(details.ConsumedReadCapacity)).
+		AddLabel(dynamoDBLabelConsumedWriteCapacity, formatMetric(details.ConsumedWriteCapacity)).
+		AddCreatedAt(table.CreationDateTime)
+	if billingMode == dynamodb.BillingModeProvisioned && table.ProvisionedThroughput != nil {
+		entity.
+			AddInt64Label(dynamoDBLabelReadCapacity, table.ProvisionedThroughput.ReadCapacityUnits).
+			AddInt64Label(dynamoDBLabelWriteCapacity, table.ProvisionedThroughput.WriteCapacityUnits)
+	}
+
+	return entity
+}
+
+func getDynamoDBTableDetails(ctx context.Context, svc dynamodbiface.DynamoDBAPI, table *dynamodb.TableDescription) (*DynamoDBTableDetails, error) {
+	details := &DynamoDBTableDetails{}
+	input := &dynamodb.ListTagsOfResourceInput{ResourceArn: table.TableArn}
+	for {
+		output, err := svc.ListTagsOfResourceWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not list tags of dynamodb table %s", aws.StringValue(table.TableName))
+		}
+		details.Tags = append(details.Tags, output.Tags...)
+		if output.NextToken == nil {
+			break
+		}
+		input.NextToken = output.NextToken
+	}
+	return details, nil
+}
+
+// dynamoDBMetricLabels are the labels getDynamoDBTableMetrics looks up
+var dynamoDBMetricLabels = []string{string(dynamoDBLabelConsumedReadCapacity), string(dynamoDBLabelConsumedWriteCapacity)}
+
+// getDynamoDBTableMetrics adds the cloudwatch metrics of the table to its details
+func getDynamoDBTableMetrics(ctx context.Context, cw cloudwatchiface.CloudWatchAPI, table *dynamodb.TableDescription, details *DynamoDBTableDetails, lookback time.Duration) error {
+	name := aws.StringValue(table.TableName)
+	dimensions := map[string]string{"TableName": name}
+	var err error
+	details.ConsumedReadCapacity, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/DynamoDB",
+		Metric:     "ConsumedReadCapacityUnits",
+		Dimensions: dimensions,
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	if err != nil {
+		return errors.Wrapf(err, "could not get consumed read capacity of dynamodb table %s", name)
+	}
+	details.ConsumedWriteCapacity, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/DynamoDB",
+		Metric:     "ConsumedWriteCapacityUnits",
+		Dimensions: dimensions,
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	return errors.Wrapf(err, "could not get consumed write capacity of dynamodb table %s", name)
+}
+
+func describeDynamoDBTable(ctx context.Context, svc dynamodbiface.DynamoDBAPI, name *string) (*dynamodb.TableDescription, *DynamoDBTableDetails, error) {
+	output, err := svc.DescribeTableWithContext(ctx, &dynamodb.DescribeTableInput{TableName: name})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not describe dynamodb table %s", aws.StringValue(name))
+	}
+	details, err := getDynamoDBTableDetails(ctx, svc, output.Table)
+	if err != nil {
+		return nil, nil, err
+	}
+	return output.Table, details, nil
+}
+
+// EvalDynamoDBTable walks through all dynamodb tables
+func (c *Client) EvalDynamoDBTable(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+		svc := dynamodb.New(sess, conf)
+		cw := cloudwatch.New(sess, conf)
+		err := svc.ListTablesPagesWithContext(ctx, &dynamodb.ListTablesInput{}, func(output *dynamodb.ListTablesOutput, lastPage bool) bool {
+			for _, name := range output.TableNames {
+				table, details, err := describeDynamoDBTable(ctx, svc, name)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if !p.MightMatch(NewDynamoDBTable(table, details, region), dynamoDBMetricLabels, false) {
+					continue
+				}
+				err = getDynamoDBTableMetrics(ctx, cw, table, details, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				d := NewDynamoDBTable(table, details, region)
+				if p.Match(d) {
+					violation := policy.NewViolation(p, d, p.Expired(d), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getDynamoDBTable(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+	table, details, err := describeDynamoDBTable(ctx, dynamodb.New(sess, conf), &id)
+	if err != nil {
+		return nil, err
+	}
+	err = getDynamoDBTableMetrics(ctx, cloudwatch.New(sess, conf), table, details, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewDynamoDBTable(table, details, region), nil
+}
@@ -0,0 +1,72 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewDynamoDBTable(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name   string
+		table  *dynamodb.TableDescription
+		labels map[string]string
+		unset  []string
+	}{
+		{
+			name: "provisioned, from before billing modes",
+			table: &dynamodb.TableDescription{
+				TableStatus:           aws.String(dynamodb.TableStatusActive),
+				ItemCount:             aws.Int64(10),
+				TableSizeBytes:        aws.Int64(2048),
+				ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{ReadCapacityUnits: aws.Int64(5), WriteCapacityUnits: aws.Int64(1)},
+			},
+			labels: map[string]string{
+				"status":                  "ACTIVE",
+				"billing_mode":            "PROVISIONED",
+				"read_capacity":           "5",
+				"write_capacity":          "1",
+				"item_count":              "10",
+				"size_bytes":              "2048",
+				"encryption":              "aws_owned",
+				"consumed_read_capacity":  "3",
+				"consumed_write_capacity": "0",
+			},
+		},
+		{
+			name: "on demand with a kms key",
+			table: &dynamodb.TableDescription{
+				BillingModeSummary:    &dynamodb.BillingModeSummary{BillingMode: aws.String(dynamodb.BillingModePayPerRequest)},
+				SSEDescription:        &dynamodb.SSEDescription{Status: aws.String(dynamodb.SSEStatusEnabled)},
+				ProvisionedThroughput: &dynamodb.ProvisionedThroughputDescription{ReadCapacityUnits: aws.Int64(0), WriteCapacityUnits: aws.Int64(0)},
+			},
+			labels: map[string]string{
+				"billing_mode": "PAY_PER_REQUEST",
+				"encryption":   "kms",
+			},
+			unset: []string{"read_capacity", "write_capacity"},
+		},
+	}
+
+	for _, test := range tests {
+		test.table.TableName = aws.String("sessions")
+		table := reaperAws.NewDynamoDBTable(test.table, &reaperAws.DynamoDBTableDetails{
+			Tags:                 []*dynamodb.Tag{{Key: aws.String("owner"), Value: aws.String("web@example.com")}},
+			ConsumedReadCapacity: 2.6,
+		}, "us-west-2")
+		a.Equal("sessions", table.GetID(), test.name)
+		a.Equal("web@example.com", table.GetTags()["owner"], test.name)
+		labels := table.GetLabels()
+		for label, value := range test.labels {
+			a.Equal(value, labels[label], "%s: %s", test.name, label)
+		}
+		for _, label := range test.unset {
+			a.NotContains(labels, label, test.name)
+		}
+	}
+}
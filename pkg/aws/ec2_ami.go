@@ -0,0 +1,319 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ami specific labels
+const (
+	amiLabelState                TypeEntityLabel = "state"
+	amiLabelSize                 TypeEntityLabel = "size"
+	amiLabelIsPublic             TypeEntityLabel = "is_public"
+	amiLabelSnapshotCount        TypeEntityLabel = "snapshot_count"
+	amiLabelInUse                TypeEntityLabel = "in_use"
+	amiLabelUsedByInstance       TypeEntityLabel = "used_by_instance"
+	amiLabelUsedByLaunchTemplate TypeEntityLabel = "used_by_launch_template"
+)
+
+// AMIUsage records what uses an ami
+type AMIUsage struct {
+	// UsedByInstance is set when an instance that isn't terminated was launched from the ami
+	UsedByInstance bool
+	// UsedByLaunchTemplate is set when the default or latest version of a launch template uses the ami
+	UsedByLaunchTemplate bool
+}
+
+// AMI is an evaluation entity representing an ami owned by the account
+type AMI struct {
+	Entity
+	snapshotIDs []string
+	usage       AMIUsage
+	svc         ec2iface.EC2API
+}
+
+// GetID returns the ami id
+func (a *AMI) GetID() string {
+	return a.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (a *AMI) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#Images:search=%s"
+	return fmt.Sprintf(t, a.Region, a.Region, a.ID)
+}
+
+// NewAMI returns a new ami entity
+func NewAMI(image *ec2.Image, usage AMIUsage, region string, svc ec2iface.EC2API) *AMI {
+	entity := &AMI{
+		Entity: NewEntity(),
+		usage:  usage,
+		svc:    svc,
+	}
+	if image == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if image.ImageId != nil {
+		entity.ID = *image.ImageId
+	}
+	if image.Name != nil {
+		entity.Name = *image.Name
+	}
+
+	for _, tag := range image.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+
+	var size int64
+	for _, mapping := range image.BlockDeviceMappings {
+		if mapping == nil || mapping.Ebs == nil {
+			continue
+		}
+		size += aws.Int64Value(mapping.Ebs.VolumeSize)
+		if mapping.Ebs.SnapshotId != nil {
+			entity.snapshotIDs = append(entity.snapshotIDs, *mapping.Ebs.SnapshotId)
+		}
+	}
+	snapshotCount := int64(len(entity.snapshotIDs))
+
+	inUse := usage.UsedByInstance || usage.UsedByLaunchTemplate
+
+	entity.
+		AddLabel(amiLabelState, image.State).
+		AddInt64Label(amiLabelSize, &size).
+		AddBoolLabel(amiLabelIsPublic, image.Public).
+		AddInt64Label(amiLabelSnapshotCount, &snapshotCount).
+		AddBoolLabel(amiLabelInUse, &inUse).
+		AddBoolLabel(amiLabelUsedByInstance, &usage.UsedByInstance).
+		AddBoolLabel(amiLabelUsedByLaunchTemplate, &usage.UsedByLaunchTemplate)
+
+	if image.CreationDate != nil {
+		createdAt, err := time.Parse(time.RFC3339, *image.CreationDate)
+		if err != nil {
+			log.Warnf("could not parse creation date %s of %s", *image.CreationDate, entity.ID)
+		} else {
+			entity.AddCreatedAt(&createdAt)
+		}
+	}
+
+	return entity
+}
+
+// Delete deregisters the ami and deletes its snapshots. Amis an instance or launch template uses
+// are refused, and instances are checked again in case one was launched since the evaluation.
+func (a *AMI) Delete() error {
+	ctx := context.Background()
+	if a.usage.UsedByLaunchTemplate {
+		return errors.Errorf("ami %s is still used by a launch template", a.ID)
+	}
+	if a.usage.UsedByInstance {
+		return errors.Errorf("ami %s is still used by an instance", a.ID)
+	}
+	instanceID, err := a.instanceUsingImage(ctx)
+	if err != nil {
+		return err
+	}
+	if instanceID != "" {
+		return errors.Errorf("ami %s is still used by instance %s", a.ID, instanceID)
+	}
+
+	log.Warnf("Deregistering ami %s", a.ID)
+	_, err = a.svc.DeregisterImageWithContext(ctx, &ec2.DeregisterImageInput{ImageId: &a.ID})
+	if err != nil {
+		return errors.Wrapf(err, "could not deregister ami %s", a.ID)
+	}
+
+	var errs *multierror.Error
+	for _, snapshotID := range a.snapshotIDs {
+		log.Warnf("Deleting snapshot %s of ami %s", snapshotID, a.ID)
+		_, err = a.svc.DeleteSnapshotWithContext(ctx, &ec2.DeleteSnapshotInput{SnapshotId: aws.String(snapshotID)})
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not delete snapshot %s of ami %s", snapshotID, a.ID))
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// instanceImageStates are the instance states in which an instance counts as using its ami
+var instanceImageStates = []string{
+	ec2.InstanceStateNamePending,
+	ec2.InstanceStateNameRunning,
+	ec2.InstanceStateNameShuttingDown,
+	ec2.InstanceStateNameStopping,
+	ec2.InstanceStateNameStopped,
+}
+
+// instanceUsingImage returns the id of an instance that isn't terminated launched from the ami,
+// or "" if there are none
+func (a *AMI) instanceUsingImage(ctx context.Context) (string, error) {
+	var instanceID string
+	input := &ec2.DescribeInstancesInput{
+		Filters: []*ec2.Filter{
+			{Name: aws.String("image-id"), Values: aws.StringSlice([]string{a.ID})},
+			{Name: aws.String("instance-state-name"), Values: aws.StringSlice(instanceImageStates)},
+		},
+	}
+	err := a.svc.DescribeInstancesPagesWithContext(ctx, input, func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range output.Reservations {
+			if len(reservation.Instances) > 0 {
+				instanceID = aws.StringValue(reservation.Instances[0].InstanceId)
+				return false
+			}
+		}
+		return true
+	})
+	return instanceID, errors.Wrapf(err, "could not describe the instances using ami %s", a.ID)
+}
+
+// ec2ImageUsage records what refers to the images and snapshots in a region
+type ec2ImageUsage struct {
+	// volumes holds the ids of existing volumes
+	volumes map[string]bool
+	// snapshotImages maps from a snapshot id to the owned amis referencing it
+	snapshotImages map[string][]string
+	// instanceImages holds the ids of amis used by instances that aren't terminated
+	instanceImages map[string]bool
+	// launchTemplateImages holds the ids of amis used by the default or latest version of a launch template
+	launchTemplateImages map[string]bool
+	// images holds the owned amis
+	images []*ec2.Image
+}
+
+// getEC2ImageUsage looks up what refers to the images and snapshots in a region
+func getEC2ImageUsage(ctx context.Context, svc ec2iface.EC2API) (*ec2ImageUsage, error) {
+	usage := &ec2ImageUsage{
+		volumes:              map[string]bool{},
+		snapshotImages:       map[string][]string{},
+		instanceImages:       map[string]bool{},
+		launchTemplateImages: map[string]bool{},
+	}
+
+	err := svc.DescribeVolumesPagesWithContext(ctx, &ec2.DescribeVolumesInput{}, func(output *ec2.DescribeVolumesOutput, lastPage bool) bool {
+		for _, vol := range output.Volumes {
+			usage.volumes[aws.StringValue(vol.VolumeId)] = true
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe volumes")
+	}
+
+	// DescribeImages isn't paginated in the sdk version we use, without MaxResults it returns every
+	// image in one response
+	images, err := svc.DescribeImagesWithContext(ctx, &ec2.DescribeImagesInput{Owners: aws.StringSlice([]string{"self"})})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe images")
+	}
+	usage.images = images.Images
+	for _, image := range images.Images {
+		for _, mapping := range image.BlockDeviceMappings {
+			if mapping == nil || mapping.Ebs == nil || mapping.Ebs.SnapshotId == nil {
+				continue
+			}
+			snapshotID := *mapping.Ebs.SnapshotId
+			usage.snapshotImages[snapshotID] = append(usage.snapshotImages[snapshotID], aws.StringValue(image.ImageId))
+		}
+	}
+
+	err = svc.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{}, func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+		for _, reservation := range output.Reservations {
+			for _, instance := range reservation.Instances {
+				if instance.State != nil && aws.StringValue(instance.State.Name) == ec2.InstanceStateNameTerminated {
+					continue
+				}
+				usage.instanceImages[aws.StringValue(instance.ImageId)] = true
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe instances")
+	}
+
+	var templateIDs []*string
+	err = svc.DescribeLaunchTemplatesPagesWithContext(ctx, &ec2.DescribeLaunchTemplatesInput{}, func(output *ec2.DescribeLaunchTemplatesOutput, lastPage bool) bool {
+		for _, template := range output.LaunchTemplates {
+			templateIDs = append(templateIDs, template.LaunchTemplateId)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe launch templates")
+	}
+	for _, templateID := range templateIDs {
+		input := &ec2.DescribeLaunchTemplateVersionsInput{
+			LaunchTemplateId: templateID,
+			Versions:         aws.StringSlice([]string{"$Default", "$Latest"}),
+		}
+		output, err := svc.DescribeLaunchTemplateVersionsWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not describe versions of launch template %s", aws.StringValue(templateID))
+		}
+		for _, version := range output.LaunchTemplateVersions {
+			if version.LaunchTemplateData != nil && version.LaunchTemplateData.ImageId != nil {
+				usage.launchTemplateImages[*version.LaunchTemplateData.ImageId] = true
+			}
+		}
+	}
+
+	return usage, nil
+}
+
+// ami returns what uses the ami
+func (u *ec2ImageUsage) ami(id string) AMIUsage {
+	return AMIUsage{
+		UsedByInstance:       u.instanceImages[id],
+		UsedByLaunchTemplate: u.launchTemplateImages[id],
+	}
+}
+
+// EvalAMI walks through all amis owned by the accounts
+func (c *Client) EvalAMI(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		usage, err := getEC2ImageUsage(ctx, client.EC2)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate amis in %s %s", account.Name, region))
+			return
+		}
+		for _, image := range usage.images {
+			a := NewAMI(image, usage.ami(aws.StringValue(image.ImageId)), region, client.EC2)
+			if p.Match(a) {
+				violation := policy.NewViolation(p, a, p.Expired(a), account)
+				f(violation)
+			}
+		}
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getAMI(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	usage, err := getEC2ImageUsage(ctx, client.EC2)
+	if err != nil {
+		return nil, err
+	}
+	for _, image := range usage.images {
+		if aws.StringValue(image.ImageId) == id {
+			return NewAMI(image, usage.ami(id), region, client.EC2), nil
+		}
+	}
+	return nil, errors.Errorf("ami %s not found in %s", id, region)
+}
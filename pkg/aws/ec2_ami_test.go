@@ -0,0 +1,108 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func testImage() *ec2.Image {
+	return &ec2.Image{
+		ImageId:      aws.String("ami-1"),
+		Name:         aws.String("base"),
+		State:        aws.String(ec2.ImageStateAvailable),
+		CreationDate: aws.String("2024-01-02T03:04:05.000Z"),
+		BlockDeviceMappings: []*ec2.BlockDeviceMapping{
+			{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-1"), VolumeSize: aws.Int64(8)}},
+			{Ebs: &ec2.EbsBlockDevice{SnapshotId: aws.String("snap-2"), VolumeSize: aws.Int64(100)}},
+			{DeviceName: aws.String("/dev/sdb"), VirtualName: aws.String("ephemeral0")},
+		},
+	}
+}
+
+func TestNewAMI(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name   string
+		usage  reaperAws.AMIUsage
+		labels map[string]string
+		unset  []string
+	}{
+		{
+			name:   "unused",
+			labels: map[string]string{"state": "available", "size": "108", "snapshot_count": "2"},
+			unset:  []string{"in_use", "used_by_instance", "used_by_launch_template", "is_public"},
+		},
+		{
+			name:   "used by an instance",
+			usage:  reaperAws.AMIUsage{UsedByInstance: true},
+			labels: map[string]string{"in_use": "true", "used_by_instance": "true"},
+			unset:  []string{"used_by_launch_template"},
+		},
+		{
+			name:   "used by a launch template",
+			usage:  reaperAws.AMIUsage{UsedByLaunchTemplate: true},
+			labels: map[string]string{"in_use": "true", "used_by_launch_template": "true"},
+			unset:  []string{"used_by_instance"},
+		},
+	}
+
+	for _, test := range tests {
+		ami := reaperAws.NewAMI(testImage(), test.usage, "us-west-2", nil)
+		labels := ami.GetLabels()
+		for label, value := range test.labels {
+			a.Equal(value, labels[label], "%s: %s", test.name, label)
+		}
+		for _, label := range test.unset {
+			a.NotContains(labels, label, test.name)
+		}
+		a.Equal("2024-01-02T03:04:05Z", ami.GetCreatedAt().Format("2006-01-02T15:04:05Z07:00"), test.name)
+	}
+}
+
+func TestAMIDelete(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name      string
+		usage     reaperAws.AMIUsage
+		instances []*ec2.Instance
+		calls     []string
+		err       string
+	}{
+		{
+			name:  "unused",
+			calls: []string{"deregister ami-1", "delete snap-1", "delete snap-2"},
+		},
+		{
+			name:  "used by an instance",
+			usage: reaperAws.AMIUsage{UsedByInstance: true},
+			err:   "ami ami-1 is still used by an instance",
+		},
+		{
+			name:  "used by a launch template",
+			usage: reaperAws.AMIUsage{UsedByLaunchTemplate: true},
+			err:   "ami ami-1 is still used by a launch template",
+		},
+		{
+			name:      "instance launched since the evaluation",
+			instances: []*ec2.Instance{{InstanceId: aws.String("i-1"), ImageId: aws.String("ami-1")}},
+			err:       "ami ami-1 is still used by instance i-1",
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeEC2{instances: test.instances}
+		err := reaperAws.NewAMI(testImage(), test.usage, "us-west-2", svc).Delete()
+		if test.err == "" {
+			a.NoError(err, test.name)
+		} else if a.Error(err, test.name) {
+			a.Contains(err.Error(), test.err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
@@ -0,0 +1,140 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ebs_snapshot specific labels
+const (
+	ebsSnapshotLabelSize            TypeEntityLabel = "size"
+	ebsSnapshotLabelState           TypeEntityLabel = "state"
+	ebsSnapshotLabelIsEncrypted     TypeEntityLabel = "is_encrypted"
+	ebsSnapshotLabelVolumeID        TypeEntityLabel = "volume_id"
+	ebsSnapshotLabelVolumeExists    TypeEntityLabel = "volume_exists"
+	ebsSnapshotLabelReferencedByAMI TypeEntityLabel = "referenced_by_ami"
+)
+
+// EBSSnapshot is an evaluation entity representing an ebs snapshot owned by the account
+type EBSSnapshot struct {
+	Entity
+	amiIDs []string
+	svc    ec2iface.EC2API
+}
+
+// GetID returns the snapshot id
+func (e *EBSSnapshot) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *EBSSnapshot) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#Snapshots:search=%s"
+	return fmt.Sprintf(t, e.Region, e.Region, e.ID)
+}
+
+// NewEBSSnapshot returns a new ebs snapshot entity
+func NewEBSSnapshot(snapshot *ec2.Snapshot, usage *ec2ImageUsage, region string, svc ec2iface.EC2API) *EBSSnapshot {
+	entity := &EBSSnapshot{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if snapshot == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if snapshot.SnapshotId != nil {
+		entity.ID = *snapshot.SnapshotId
+	}
+
+	for _, tag := range snapshot.Tags {
+		if tag == nil {
+			continue
+		}
+		if tag.Key != nil && tag.Value != nil && *tag.Key == "Name" {
+			entity.Name = *tag.Value
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+
+	entity.amiIDs = usage.snapshotImages[entity.ID]
+	volumeExists := usage.volumes[aws.StringValue(snapshot.VolumeId)]
+	referencedByAMI := len(entity.amiIDs) > 0
+
+	entity.
+		AddInt64Label(ebsSnapshotLabelSize, snapshot.VolumeSize).
+		AddLabel(ebsSnapshotLabelState, snapshot.State).
+		AddBoolLabel(ebsSnapshotLabelIsEncrypted, snapshot.Encrypted).
+		AddLabel(ebsSnapshotLabelVolumeID, snapshot.VolumeId).
+		AddBoolLabel(ebsSnapshotLabelVolumeExists, &volumeExists).
+		AddBoolLabel(ebsSnapshotLabelReferencedByAMI, &referencedByAMI).
+		AddCreatedAt(snapshot.StartTime)
+
+	return entity
+}
+
+// Delete deletes the snapshot. Snapshots backing an ami are deleted along with the ami instead.
+func (e *EBSSnapshot) Delete() error {
+	if len(e.amiIDs) > 0 {
+		return errors.Errorf("snapshot %s is referenced by %s, delete the ami instead", e.ID, strings.Join(e.amiIDs, ", "))
+	}
+	log.Warnf("Deleting snapshot %s", e.ID)
+	_, err := e.svc.DeleteSnapshotWithContext(context.Background(), &ec2.DeleteSnapshotInput{SnapshotId: &e.ID})
+	return errors.Wrapf(err, "could not delete snapshot %s", e.ID)
+}
+
+// EvalEBSSnapshot walks through all ebs snapshots owned by the accounts
+func (c *Client) EvalEBSSnapshot(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		usage, err := getEC2ImageUsage(ctx, client.EC2)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate snapshots in %s %s", account.Name, region))
+			return
+		}
+
+		input := &ec2.DescribeSnapshotsInput{OwnerIds: aws.StringSlice([]string{"self"})}
+		err = client.EC2.DescribeSnapshotsPagesWithContext(ctx, input, func(output *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+			for _, snapshot := range output.Snapshots {
+				s := NewEBSSnapshot(snapshot, usage, region, client.EC2)
+				if p.Match(s) {
+					violation := policy.NewViolation(p, s, p.Expired(s), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getEBSSnapshot(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	usage, err := getEC2ImageUsage(ctx, client.EC2)
+	if err != nil {
+		return nil, err
+	}
+	input := &ec2.DescribeSnapshotsInput{SnapshotIds: aws.StringSlice([]string{id})}
+	output, err := client.EC2.DescribeSnapshotsWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe snapshot %s", id)
+	}
+	for _, snapshot := range output.Snapshots {
+		return NewEBSSnapshot(snapshot, usage, region, client.EC2), nil
+	}
+	return nil, errors.Errorf("snapshot %s not found in %s", id, region)
+}
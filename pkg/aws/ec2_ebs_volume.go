@@ -3,11 +3,18 @@ package aws
 import (
 	"context"
 	"fmt"
+	"math"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudtrail/cloudtrailiface"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	cziAws "github.com/chanzuckerberg/go-misc/aws"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -20,11 +27,76 @@ const (
 	ec2EBSVolLabelState       = "state"
 )
 
+// ebs_volume attachment, performance and snapshot labels
+const (
+	ec2EBSVolLabelInstanceID             TypeEntityLabel = "instance_id"
+	ec2EBSVolLabelDeleteOnTermination    TypeEntityLabel = "delete_on_termination"
+	ec2EBSVolLabelIops                   TypeEntityLabel = "iops"
+	ec2EBSVolLabelThroughput             TypeEntityLabel = "throughput"
+	ec2EBSVolLabelLastSnapshot           TypeEntityLabel = "last_snapshot"
+	ec2EBSVolLabelLastSnapshotAgeDays    TypeEntityLabel = "last_snapshot_age_days"
+	ec2EBSVolLabelNeverSnapshotted       TypeEntityLabel = "never_snapshotted"
+	ec2EBSVolLabelAvailableSince         TypeEntityLabel = "available_since"
+	ec2EBSVolLabelAvailableDays          TypeEntityLabel = "available_days"
+	ec2EBSVolLabelAvailableDaysIsMinimum TypeEntityLabel = "available_days_is_minimum"
+	ec2EBSVolLabelAvailableSinceUnknown  TypeEntityLabel = "available_since_unknown"
+)
+
+// ebsVolumeAvailableLabels are the labels that need the volume's events in CloudTrail
+var ebsVolumeAvailableLabels = []string{
+	string(ec2EBSVolLabelAvailableSince),
+	string(ec2EBSVolLabelAvailableDays),
+	string(ec2EBSVolLabelAvailableDaysIsMinimum),
+	string(ec2EBSVolLabelAvailableSinceUnknown),
+}
+
+// CloudTrail event history only goes back 90 days, which bounds how far back we find detachments
+const ebsVolumeDetachLookback = 90 * 24 * time.Hour
+
+// ebsVolumeFirstSeenTag records on a volume when reaper first found it available without being able
+// to tell when it was detached
+const ebsVolumeFirstSeenTag = "reaper:available-first-seen"
+
+const (
+	ebsSnapshotPollInterval = 15 * time.Second
+	ebsSnapshotTimeout      = time.Hour
+)
+
+// ebsVolumeSnapshotTag records on a snapshot reaper took which volume it deleted afterwards
+const ebsVolumeSnapshotTag = "reaper:deleted-volume"
+
 // EC2EBSVol is an evaluation entity representing an ec2 ebs volume
 type EC2EBSVol struct {
 	Entity
-	ID   string
-	Name string
+	ID    string
+	Name  string
+	state string
+	tags  []*ec2.Tag
+	svc   ec2iface.EC2API
+}
+
+// EBSVolumeDetails are what we know about a volume beyond its description
+type EBSVolumeDetails struct {
+	// LastSnapshot is when the volume's most recent completed snapshot was started
+	LastSnapshot *time.Time
+	// AvailableSince is when an available volume was detached, or created if it was never attached.
+	// It is unset until DescribeEBSVolumeAvailableSince looks it up.
+	AvailableSince *time.Time
+	// AvailableSinceIsMinimum is set when CloudTrail could not tell when the volume was detached and
+	// AvailableSince is when reaper first found it available instead
+	AvailableSinceIsMinimum bool
+	// AvailableSinceUnknown is set when neither CloudTrail nor the volume's first seen tag tell when
+	// it was detached
+	AvailableSinceUnknown bool
+}
+
+// ebsVolumeFirstSeenUpdate is a change to a volume's first seen tag. Evaluation only notes them,
+// RecordEbsVolumesFirstSeen makes them.
+type ebsVolumeFirstSeenUpdate struct {
+	svc      ec2iface.EC2API
+	volumeID string
+	// firstSeen is what to tag the volume with, or nil to remove the tag
+	firstSeen *time.Time
 }
 
 // GetID returns the ec2_ebs_vol id
@@ -33,9 +105,10 @@ func (e *EC2EBSVol) GetID() string {
 }
 
 // NewEc2EBSVol returns a new ec2 ebs vol entity
-func NewEc2EBSVol(vol *ec2.Volume, region string) *EC2EBSVol {
+func NewEc2EBSVol(vol *ec2.Volume, details EBSVolumeDetails, region string, now time.Time, svc ec2iface.EC2API) *EC2EBSVol {
 	entity := &EC2EBSVol{
 		Entity: NewEntity(),
+		svc:    svc,
 	}
 	if vol == nil {
 		return entity
@@ -47,6 +120,8 @@ func NewEc2EBSVol(vol *ec2.Volume, region string) *EC2EBSVol {
 	if vol.VolumeId != nil {
 		entity.ID = *vol.VolumeId
 	}
+	entity.state = aws.StringValue(vol.State)
+	entity.tags = vol.Tags
 
 	for _, tag := range vol.Tags {
 		if tag == nil {
@@ -63,35 +138,375 @@ func NewEc2EBSVol(vol *ec2.Volume, region string) *EC2EBSVol {
 		AddInt64Label(ec2EBSVolLabelSize, vol.Size).
 		AddLabel(ec2EBSVolLabelState, vol.State).
 		AddLabel(ec2EBSVolLabelType, vol.VolumeType).
+		AddInt64Label(ec2EBSVolLabelIops, vol.Iops).
+		AddInt64Label(ec2EBSVolLabelThroughput, vol.Throughput).
 		AddCreatedAt(vol.CreateTime)
 
+	for _, attachment := range vol.Attachments {
+		if aws.StringValue(attachment.State) == ec2.VolumeAttachmentStateDetached {
+			continue
+		}
+		entity.
+			AddLabel(ec2EBSVolLabelInstanceID, attachment.InstanceId).
+			AddBoolLabel(ec2EBSVolLabelDeleteOnTermination, attachment.DeleteOnTermination)
+	}
+
+	if details.LastSnapshot != nil {
+		age := int64(math.Floor(now.Sub(*details.LastSnapshot).Hours() / 24))
+		entity.
+			AddLabel(ec2EBSVolLabelLastSnapshot, aws.String(details.LastSnapshot.UTC().Format("2006-01-02"))).
+			AddInt64Label(ec2EBSVolLabelLastSnapshotAgeDays, &age)
+	} else {
+		neverSnapshotted := true
+		entity.AddBoolLabel(ec2EBSVolLabelNeverSnapshotted, &neverSnapshotted)
+	}
+
+	if entity.state == ec2.VolumeStateAvailable && details.AvailableSince != nil {
+		entity.addAvailableSince(*details.AvailableSince, details.AvailableSinceIsMinimum, now)
+	} else if entity.state == ec2.VolumeStateAvailable && details.AvailableSinceUnknown {
+		entity.AddBoolLabel(ec2EBSVolLabelAvailableSinceUnknown, &details.AvailableSinceUnknown)
+	}
+
 	return entity
 }
 
+// addAvailableSince labels an available volume with how long it has been detached
+func (e *EC2EBSVol) addAvailableSince(since time.Time, isMinimum bool, now time.Time) {
+	days := int64(math.Floor(now.Sub(since).Hours() / 24))
+	e.
+		AddLabel(ec2EBSVolLabelAvailableSince, aws.String(since.UTC().Format("2006-01-02"))).
+		AddInt64Label(ec2EBSVolLabelAvailableDays, &days).
+		AddBoolLabel(ec2EBSVolLabelAvailableDaysIsMinimum, &isMinimum)
+}
+
 // GetConsoleURL will return a url to the AWS console for this volume
 func (e *EC2EBSVol) GetConsoleURL() string {
 	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#Volumes:search=%s;sort=state"
 	return fmt.Sprintf(t, e.Region, e.Region, e.ID)
 }
 
-// Delete deletes
+// Delete deletes the volume if it is not attached
 func (e *EC2EBSVol) Delete() error {
-	log.Warnf("Would delete ec2_ebs_vol %s", e.ID)
+	return e.Remediate(policy.Remediation{Action: policy.ActionDelete})
+}
+
+// Remediate deletes the volume if it is not attached, after taking a snapshot of it and waiting for
+// the snapshot to complete when the remediation asks for one
+func (e *EC2EBSVol) Remediate(remediation policy.Remediation) error {
+	if remediation.Action != policy.ActionDelete {
+		return errors.Errorf("volume %s does not support the %s action", e.ID, remediation.Action)
+	}
+	if e.state != ec2.VolumeStateAvailable {
+		return errors.Errorf("volume %s is %s, only available volumes are deleted", e.ID, e.state)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), ebsSnapshotTimeout)
+	defer cancel()
+
+	if remediation.Delete.SnapshotFirst {
+		err := e.snapshot(ctx)
+		if err != nil {
+			return err
+		}
+	}
+
+	log.Warnf("Deleting volume %s", e.ID)
+	_, err := e.svc.DeleteVolumeWithContext(ctx, &ec2.DeleteVolumeInput{VolumeId: &e.ID})
+	return errors.Wrapf(err, "could not delete volume %s", e.ID)
+}
+
+// snapshot takes a snapshot of the volume, with its tags, and waits for it to complete
+func (e *EC2EBSVol) snapshot(ctx context.Context) error {
+	tags := []*ec2.Tag{{Key: aws.String(ebsVolumeSnapshotTag), Value: &e.ID}}
+	for _, tag := range e.tags {
+		// aws: prefixed tags are reserved
+		if tag == nil || strings.HasPrefix(aws.StringValue(tag.Key), "aws:") {
+			continue
+		}
+		tags = append(tags, tag)
+	}
+
+	log.Infof("Taking a snapshot of volume %s before deleting it", e.ID)
+	snapshot, err := e.svc.CreateSnapshotWithContext(ctx, &ec2.CreateSnapshotInput{
+		VolumeId:    &e.ID,
+		Description: aws.String(fmt.Sprintf("Taken by reaper before deleting %s", e.ID)),
+		TagSpecifications: []*ec2.TagSpecification{{
+			ResourceType: aws.String(ec2.ResourceTypeSnapshot),
+			Tags:         tags,
+		}},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not take a snapshot of volume %s", e.ID)
+	}
+
+	snapshotID := aws.StringValue(snapshot.SnapshotId)
+	for {
+		output, err := e.svc.DescribeSnapshotsWithContext(ctx, &ec2.DescribeSnapshotsInput{SnapshotIds: []*string{snapshot.SnapshotId}})
+		if err != nil {
+			return errors.Wrapf(err, "could not describe snapshot %s of volume %s", snapshotID, e.ID)
+		}
+		for _, s := range output.Snapshots {
+			switch aws.StringValue(s.State) {
+			case ec2.SnapshotStateCompleted:
+				log.Infof("Snapshot %s of volume %s completed", snapshotID, e.ID)
+				return nil
+			case ec2.SnapshotStateError:
+				return errors.Errorf("snapshot %s of volume %s failed: %s", snapshotID, e.ID, aws.StringValue(s.StateMessage))
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return errors.Errorf("gave up waiting for snapshot %s of volume %s to complete", snapshotID, e.ID)
+		case <-time.After(ebsSnapshotPollInterval):
+		}
+	}
+}
+
+// getEBSVolumeLastSnapshots finds when the most recent completed snapshot we own of each volume
+// was started
+func getEBSVolumeLastSnapshots(ctx context.Context, svc ec2iface.EC2API, volumeIDs []*string) (map[string]time.Time, error) {
+	lastSnapshots := map[string]time.Time{}
+	input := &ec2.DescribeSnapshotsInput{OwnerIds: aws.StringSlice([]string{"self"})}
+	if len(volumeIDs) > 0 {
+		input.Filters = []*ec2.Filter{{Name: aws.String("volume-id"), Values: volumeIDs}}
+	}
+	err := svc.DescribeSnapshotsPagesWithContext(ctx, input, func(output *ec2.DescribeSnapshotsOutput, lastPage bool) bool {
+		for _, snapshot := range output.Snapshots {
+			if aws.StringValue(snapshot.State) != ec2.SnapshotStateCompleted || snapshot.StartTime == nil {
+				continue
+			}
+			volumeID := aws.StringValue(snapshot.VolumeId)
+			if last, ok := lastSnapshots[volumeID]; !ok || snapshot.StartTime.After(last) {
+				lastSnapshots[volumeID] = *snapshot.StartTime
+			}
+		}
+		return true
+	})
+	return lastSnapshots, errors.Wrap(err, "could not describe snapshots")
+}
+
+// DescribeEBSVolumeAvailableSince looks up when an available volume was detached in CloudTrail's
+// event history: its last DetachVolume, the termination of the instance it was last attached to, or
+// its CreateVolume if it was never attached. When none of them tell, e.g. because the volume was
+// detached before the history starts, came with an instance, or CloudTrail can't be read, it falls
+// back to when the volume's first seen tag says reaper first found it available and marks that as a
+// minimum. Without a tag from after the volume was last attached, it marks it unknown.
+func DescribeEBSVolumeAvailableSince(ctx context.Context, trail cloudtrailiface.CloudTrailAPI, vol *ec2.Volume, details *EBSVolumeDetails, now time.Time) {
+	volumeID := aws.StringValue(vol.VolumeId)
+	events, err := lookupEBSVolumeEvents(ctx, trail, volumeID, now)
+	if err == nil {
+		var since *time.Time
+		since, err = events.availableSince(ctx, trail, now)
+		if err == nil && since != nil {
+			details.AvailableSince = since
+			return
+		}
+	}
+	if err != nil {
+		log.Warnf("Could not tell when volume %s was detached, using when it was first seen available: %s", volumeID, err)
+	}
+
+	firstSeen := ebsVolumeFirstSeen(vol)
+	// a tag from before the volume was last attached is stale
+	if firstSeen == nil || (events.attachedAt != nil && !firstSeen.After(*events.attachedAt)) {
+		details.AvailableSinceUnknown = true
+		return
+	}
+	details.AvailableSince = firstSeen
+	details.AvailableSinceIsMinimum = true
+}
+
+// ebsVolumeEvents are when a volume was last created, attached and detached within CloudTrail's
+// event history
+type ebsVolumeEvents struct {
+	createdAt  *time.Time
+	attachedAt *time.Time
+	detachedAt *time.Time
+	// instanceID is the instance the volume was last attached to
+	instanceID string
+}
+
+// availableSince tells from a volume's events when it was detached, or returns nil if they don't
+func (e ebsVolumeEvents) availableSince(ctx context.Context, svc cloudtrailiface.CloudTrailAPI, now time.Time) (*time.Time, error) {
+	switch {
+	case e.detachedAt != nil && (e.attachedAt == nil || e.detachedAt.After(*e.attachedAt)):
+		return e.detachedAt, nil
+	case e.attachedAt != nil && e.instanceID != "":
+		return lookupEC2InstanceTermination(ctx, svc, e.instanceID, *e.attachedAt, now)
+	case e.attachedAt == nil && e.createdAt != nil:
+		return e.createdAt, nil
+	}
+	return nil, nil
+}
+
+// lookupEBSVolumeEvents finds a volume's CreateVolume, AttachVolume and DetachVolume events
+func lookupEBSVolumeEvents(ctx context.Context, svc cloudtrailiface.CloudTrailAPI, volumeID string, now time.Time) (ebsVolumeEvents, error) {
+	events := ebsVolumeEvents{}
+	input := &cloudtrail.LookupEventsInput{
+		LookupAttributes: []*cloudtrail.LookupAttribute{{
+			AttributeKey:   aws.String(cloudtrail.LookupAttributeKeyResourceName),
+			AttributeValue: aws.String(volumeID),
+		}},
+		StartTime: aws.Time(now.Add(-ebsVolumeDetachLookback)),
+		EndTime:   aws.Time(now),
+	}
+	err := svc.LookupEventsPagesWithContext(ctx, input, func(output *cloudtrail.LookupEventsOutput, lastPage bool) bool {
+		for _, event := range output.Events {
+			at := event.EventTime
+			if at == nil {
+				continue
+			}
+			switch aws.StringValue(event.EventName) {
+			case "CreateVolume":
+				events.createdAt = at
+			case "AttachVolume":
+				if events.attachedAt == nil || at.After(*events.attachedAt) {
+					events.attachedAt = at
+					events.instanceID = cloudTrailResourceName(event, "AWS::EC2::Instance")
+				}
+			case "DetachVolume":
+				if events.detachedAt == nil || at.After(*events.detachedAt) {
+					events.detachedAt = at
+				}
+			}
+		}
+		return true
+	})
+	return events, errors.Wrapf(err, "could not look up the events of volume %s", volumeID)
+}
+
+// lookupEC2InstanceTermination finds when an instance was terminated after a given time, which
+// detaches its volumes without a DetachVolume event. It returns nil if it wasn't.
+func lookupEC2InstanceTermination(ctx context.Context, svc cloudtrailiface.CloudTrailAPI, instanceID string, after time.Time, now time.Time) (*time.Time, error) {
+	var terminatedAt *time.Time
+	input := &cloudtrail.LookupEventsInput{
+		LookupAttributes: []*cloudtrail.LookupAttribute{{
+			AttributeKey:   aws.String(cloudtrail.LookupAttributeKeyResourceName),
+			AttributeValue: aws.String(instanceID),
+		}},
+		StartTime: aws.Time(after),
+		EndTime:   aws.Time(now),
+	}
+	err := svc.LookupEventsPagesWithContext(ctx, input, func(output *cloudtrail.LookupEventsOutput, lastPage bool) bool {
+		for _, event := range output.Events {
+			if aws.StringValue(event.EventName) != "TerminateInstances" || event.EventTime == nil {
+				continue
+			}
+			if terminatedAt == nil || event.EventTime.Before(*terminatedAt) {
+				terminatedAt = event.EventTime
+			}
+		}
+		return true
+	})
+	return terminatedAt, errors.Wrapf(err, "could not look up the events of instance %s", instanceID)
+}
+
+// cloudTrailResourceName returns the name of the first resource of a type an event refers to
+func cloudTrailResourceName(event *cloudtrail.Event, resourceType string) string {
+	for _, resource := range event.Resources {
+		if aws.StringValue(resource.ResourceType) == resourceType {
+			return aws.StringValue(resource.ResourceName)
+		}
+	}
+	return ""
+}
+
+// ebsVolumeFirstSeen returns when the volume is tagged as first seen available, if it is
+func ebsVolumeFirstSeen(vol *ec2.Volume) *time.Time {
+	for _, tag := range vol.Tags {
+		if aws.StringValue(tag.Key) != ebsVolumeFirstSeenTag {
+			continue
+		}
+		firstSeen, err := time.Parse(time.RFC3339, aws.StringValue(tag.Value))
+		if err != nil {
+			return nil
+		}
+		return &firstSeen
+	}
 	return nil
 }
 
+// noteEBSVolumeFirstSeen notes a change to a volume's first seen tag for RecordEbsVolumesFirstSeen
+func (c *Client) noteEBSVolumeFirstSeen(svc ec2iface.EC2API, vol *ec2.Volume, firstSeen *time.Time) {
+	volumeID := aws.StringValue(vol.VolumeId)
+	c.ebsVolumeFirstSeen[volumeID] = ebsVolumeFirstSeenUpdate{svc: svc, volumeID: volumeID, firstSeen: firstSeen}
+}
+
+// RecordEbsVolumesFirstSeen tags the available volumes evaluation could not tell the detach time of
+// with when they were first seen, and removes the tag from volumes that are attached again.
+// Evaluation doesn't change volumes, so only runs that may take action call this.
+func (c *Client) RecordEbsVolumesFirstSeen() error {
+	var errs error
+	ctx := context.Background()
+	for volumeID, update := range c.ebsVolumeFirstSeen {
+		if update.firstSeen == nil {
+			log.Infof("Volume %s is attached again, removing its first seen tag", volumeID)
+			_, err := update.svc.DeleteTagsWithContext(ctx, &ec2.DeleteTagsInput{
+				Resources: []*string{aws.String(volumeID)},
+				Tags:      []*ec2.Tag{{Key: aws.String(ebsVolumeFirstSeenTag)}},
+			})
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not remove the first seen tag of volume %s", volumeID))
+			continue
+		}
+		log.Infof("Tagging volume %s with when it was first seen available", volumeID)
+		_, err := update.svc.CreateTagsWithContext(ctx, &ec2.CreateTagsInput{
+			Resources: []*string{aws.String(volumeID)},
+			Tags: []*ec2.Tag{{
+				Key:   aws.String(ebsVolumeFirstSeenTag),
+				Value: aws.String(update.firstSeen.UTC().Format(time.RFC3339)),
+			}},
+		})
+		errs = multierror.Append(errs, errors.Wrapf(err, "could not tag volume %s with when it was first seen available", volumeID))
+	}
+	c.ebsVolumeFirstSeen = map[string]ebsVolumeFirstSeenUpdate{}
+	return errs
+}
+
+// ebsVolumeDetails picks a volume's details out of those looked up for its region
+func ebsVolumeDetails(volumeID string, lastSnapshots map[string]time.Time) EBSVolumeDetails {
+	details := EBSVolumeDetails{}
+	if lastSnapshot, ok := lastSnapshots[volumeID]; ok {
+		details.LastSnapshot = &lastSnapshot
+	}
+	return details
+}
+
 // EvalEbsVolume walks through all ec2 instances
 func (c *Client) EvalEbsVolume(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
 	var errs error
 	ctx := context.Background()
-	err := c.WalkAccountsAndRegions(accounts, regions, func(client *cziAws.Client, account *policy.Account, region string) {
-		input := &ec2.DescribeVolumesInput{}
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		now := time.Now()
+		lastSnapshots, err := getEBSVolumeLastSnapshots(ctx, client.EC2, nil)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		trail := cloudtrail.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
 
-		err := client.EC2.Svc.DescribeVolumesPagesWithContext(ctx, input, func(output *ec2.DescribeVolumesOutput, cont bool) bool {
+		input := &ec2.DescribeVolumesInput{}
+		err = client.EC2.DescribeVolumesPagesWithContext(ctx, input, func(output *ec2.DescribeVolumesOutput, lastPage bool) bool {
 			for _, vol := range output.Volumes {
-				v := NewEc2EBSVol(vol, region)
+				details := ebsVolumeDetails(aws.StringValue(vol.VolumeId), lastSnapshots)
+				if aws.StringValue(vol.State) != ec2.VolumeStateAvailable {
+					// a volume detached again later has to be seen available anew
+					if ebsVolumeFirstSeen(vol) != nil {
+						c.noteEBSVolumeFirstSeen(client.EC2, vol, nil)
+					}
+				} else {
+					// CloudTrail lookups are slow and throttled, only make them for volumes that might match
+					if !p.MightMatch(NewEc2EBSVol(vol, details, region, now, client.EC2), ebsVolumeAvailableLabels, false) {
+						continue
+					}
+					DescribeEBSVolumeAvailableSince(ctx, trail, vol, &details, now)
+					if details.AvailableSinceUnknown {
+						c.noteEBSVolumeFirstSeen(client.EC2, vol, &now)
+					}
+				}
+				v := NewEc2EBSVol(vol, details, region, now, client.EC2)
 				if p.Match(v) {
-					violation := policy.NewViolation(p, v, false, account)
+					violation := policy.NewViolation(p, v, p.Expired(v), account)
 					f(violation)
 				}
 			}
@@ -104,3 +519,32 @@ func (c *Client) EvalEbsVolume(accounts []*policy.Account, p policy.Policy, regi
 
 	return errs
 }
+
+func (c *Client) getEbsVolume(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	now := time.Now()
+	lastSnapshots, err := getEBSVolumeLastSnapshots(ctx, client.EC2, aws.StringSlice([]string{id}))
+	if err != nil {
+		return nil, err
+	}
+
+	var volume *EC2EBSVol
+	input := &ec2.DescribeVolumesInput{VolumeIds: []*string{aws.String(id)}}
+	output, err := client.EC2.DescribeVolumesWithContext(ctx, input)
+	if err == nil && len(output.Volumes) > 0 {
+		vol := output.Volumes[0]
+		details := ebsVolumeDetails(id, lastSnapshots)
+		if aws.StringValue(vol.State) == ec2.VolumeStateAvailable {
+			trail := cloudtrail.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+			DescribeEBSVolumeAvailableSince(ctx, trail, vol, &details, now)
+		}
+		volume = NewEc2EBSVol(vol, details, region, now, client.EC2)
+	}
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe volume %s", id)
+	}
+	if volume == nil {
+		return nil, errors.Errorf("volume %s not found in %s", id, region)
+	}
+	return volume, nil
+}
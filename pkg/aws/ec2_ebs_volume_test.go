@@ -0,0 +1,216 @@
+package aws_test
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewEc2EBSVol(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	days := func(n int) *time.Time {
+		t := now.AddDate(0, 0, -n)
+		return &t
+	}
+
+	tests := []struct {
+		name    string
+		vol     *ec2.Volume
+		details reaperAws.EBSVolumeDetails
+		labels  map[string]string
+		unset   []string
+	}{
+		{
+			name: "attached gp3",
+			vol: &ec2.Volume{
+				State:      aws.String(ec2.VolumeStateInUse),
+				VolumeType: aws.String(ec2.VolumeTypeGp3),
+				Iops:       aws.Int64(3000),
+				Throughput: aws.Int64(125),
+				CreateTime: days(200),
+				Attachments: []*ec2.VolumeAttachment{{
+					InstanceId:          aws.String("i-1"),
+					State:               aws.String(ec2.VolumeAttachmentStateAttached),
+					DeleteOnTermination: aws.Bool(true),
+				}},
+			},
+			details: reaperAws.EBSVolumeDetails{LastSnapshot: days(3)},
+			labels: map[string]string{
+				"instance_id":            "i-1",
+				"delete_on_termination":  "true",
+				"iops":                   "3000",
+				"throughput":             "125",
+				"last_snapshot":          "2024-05-29",
+				"last_snapshot_age_days": "3",
+			},
+			unset: []string{"never_snapshotted", "available_days", "available_since"},
+		},
+		{
+			name:    "detached within the lookback",
+			vol:     &ec2.Volume{State: aws.String(ec2.VolumeStateAvailable), CreateTime: days(200)},
+			details: reaperAws.EBSVolumeDetails{AvailableSince: days(10)},
+			labels: map[string]string{
+				"available_since":   "2024-05-22",
+				"available_days":    "10",
+				"never_snapshotted": "true",
+			},
+			unset: []string{"instance_id", "available_days_is_minimum", "throughput"},
+		},
+		{
+			name:    "first seen available",
+			vol:     &ec2.Volume{State: aws.String(ec2.VolumeStateAvailable), CreateTime: days(400)},
+			details: reaperAws.EBSVolumeDetails{AvailableSince: days(5), AvailableSinceIsMinimum: true},
+			labels: map[string]string{
+				"available_days":            "5",
+				"available_days_is_minimum": "true",
+			},
+		},
+		{
+			name:    "detach time unknown",
+			vol:     &ec2.Volume{State: aws.String(ec2.VolumeStateAvailable), CreateTime: days(400)},
+			details: reaperAws.EBSVolumeDetails{AvailableSinceUnknown: true},
+			labels:  map[string]string{"available_since_unknown": "true"},
+			unset:   []string{"available_days", "available_since", "available_days_is_minimum"},
+		},
+		{
+			name:  "not looked up",
+			vol:   &ec2.Volume{State: aws.String(ec2.VolumeStateAvailable), CreateTime: days(20)},
+			unset: []string{"available_days", "available_since", "available_days_is_minimum", "available_since_unknown"},
+		},
+	}
+
+	for _, test := range tests {
+		labels := reaperAws.NewEc2EBSVol(test.vol, test.details, "us-west-2", now, nil).GetLabels()
+		for label, value := range test.labels {
+			a.Equal(value, labels[label], "%s: %s", test.name, label)
+		}
+		for _, label := range test.unset {
+			a.NotContains(labels, label, test.name)
+		}
+	}
+}
+
+func TestDescribeEBSVolumeAvailableSince(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2024, 6, 1, 0, 0, 0, 0, time.UTC)
+	days := func(n int) *time.Time {
+		t := now.AddDate(0, 0, -n)
+		return &t
+	}
+	event := func(name string, at *time.Time, resources ...string) *cloudtrail.Event {
+		event := &cloudtrail.Event{EventName: aws.String(name), EventTime: at}
+		for _, resource := range resources {
+			resourceType := "AWS::EC2::Volume"
+			if strings.HasPrefix(resource, "i-") {
+				resourceType = "AWS::EC2::Instance"
+			}
+			event.Resources = append(event.Resources, &cloudtrail.Resource{ResourceName: aws.String(resource), ResourceType: aws.String(resourceType)})
+		}
+		return event
+	}
+	firstSeen := func(at *time.Time) []*ec2.Tag {
+		return []*ec2.Tag{{Key: aws.String("reaper:available-first-seen"), Value: aws.String(at.Format(time.RFC3339))}}
+	}
+
+	tests := []struct {
+		name      string
+		tags      []*ec2.Tag
+		trail     fakeCloudTrail
+		since     *time.Time
+		isMinimum bool
+		unknown   bool
+	}{
+		{
+			name: "detached",
+			trail: fakeCloudTrail{events: []*cloudtrail.Event{
+				event("AttachVolume", days(30), "vol-1", "i-1"),
+				event("DetachVolume", days(10), "vol-1", "i-1"),
+			}},
+			since: days(10),
+		},
+		{
+			name: "detached by terminating its instance",
+			trail: fakeCloudTrail{events: []*cloudtrail.Event{
+				event("AttachVolume", days(30), "vol-1", "i-1"),
+				event("TerminateInstances", days(4), "i-1"),
+			}},
+			since: days(4),
+		},
+		{
+			name:  "never attached",
+			trail: fakeCloudTrail{events: []*cloudtrail.Event{event("CreateVolume", days(20), "vol-1")}},
+			since: days(20),
+		},
+		{
+			name:    "no events",
+			unknown: true,
+		},
+		{
+			name:    "attached to an instance that is still running",
+			tags:    firstSeen(days(40)),
+			trail:   fakeCloudTrail{events: []*cloudtrail.Event{event("AttachVolume", days(30), "vol-1", "i-1")}},
+			unknown: true,
+		},
+		{
+			name:      "seen available before",
+			tags:      firstSeen(days(3)),
+			since:     days(3),
+			isMinimum: true,
+		},
+		{
+			name:      "cloudtrail failing",
+			tags:      firstSeen(days(3)),
+			trail:     fakeCloudTrail{err: errors.New("throttled")},
+			since:     days(3),
+			isMinimum: true,
+		},
+		{
+			name:    "cloudtrail failing without a tag",
+			trail:   fakeCloudTrail{err: errors.New("throttled")},
+			unknown: true,
+		},
+	}
+
+	for _, test := range tests {
+		vol := &ec2.Volume{VolumeId: aws.String("vol-1"), State: aws.String(ec2.VolumeStateAvailable), Tags: test.tags}
+		details := reaperAws.EBSVolumeDetails{}
+		reaperAws.DescribeEBSVolumeAvailableSince(context.Background(), &test.trail, vol, &details, now)
+		if test.unknown {
+			a.Nil(details.AvailableSince, test.name)
+		} else if a.NotNil(details.AvailableSince, test.name) {
+			a.True(test.since.Equal(*details.AvailableSince), "%s: %s", test.name, details.AvailableSince)
+		}
+		a.Equal(test.isMinimum, details.AvailableSinceIsMinimum, test.name)
+		a.Equal(test.unknown, details.AvailableSinceUnknown, test.name)
+	}
+}
+
+func TestEc2EBSVolRemediate(t *testing.T) {
+	a := assert.New(t)
+	now := time.Now()
+
+	svc := &fakeEC2{}
+	vol := reaperAws.NewEc2EBSVol(&ec2.Volume{VolumeId: aws.String("vol-1"), State: aws.String(ec2.VolumeStateAvailable)}, reaperAws.EBSVolumeDetails{}, "us-west-2", now, svc)
+	a.NoError(vol.Remediate(policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{SnapshotFirst: true}}))
+	a.Equal([]string{"snapshot vol-1", "delete vol-1"}, svc.calls)
+
+	svc = &fakeEC2{}
+	vol = reaperAws.NewEc2EBSVol(&ec2.Volume{VolumeId: aws.String("vol-2"), State: aws.String(ec2.VolumeStateAvailable)}, reaperAws.EBSVolumeDetails{}, "us-west-2", now, svc)
+	a.NoError(vol.Remediate(policy.Remediation{Action: policy.ActionDelete}))
+	a.Equal([]string{"delete vol-2"}, svc.calls)
+
+	svc = &fakeEC2{}
+	vol = reaperAws.NewEc2EBSVol(&ec2.Volume{VolumeId: aws.String("vol-3"), State: aws.String(ec2.VolumeStateInUse)}, reaperAws.EBSVolumeDetails{}, "us-west-2", now, svc)
+	a.Error(vol.Remediate(policy.Remediation{Action: policy.ActionDelete}))
+	a.Empty(svc.calls)
+}
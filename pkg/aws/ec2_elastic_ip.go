@@ -0,0 +1,146 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// elastic_ip specific labels
+const (
+	elasticIPLabelPublicIP           TypeEntityLabel = "public_ip"
+	elasticIPLabelDomain             TypeEntityLabel = "domain"
+	elasticIPLabelIsAssociated       TypeEntityLabel = "is_associated"
+	elasticIPLabelInstanceID         TypeEntityLabel = "instance_id"
+	elasticIPLabelNetworkInterfaceID TypeEntityLabel = "network_interface_id"
+)
+
+// ElasticIP is an evaluation entity representing an elastic ip address
+type ElasticIP struct {
+	Entity
+	allocationID string
+	publicIP     string
+	associated   bool
+	svc          ec2iface.EC2API
+}
+
+// GetID returns the allocation id, or the public ip for ec2 classic addresses
+func (e *ElasticIP) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *ElasticIP) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#Addresses:search=%s"
+	return fmt.Sprintf(t, e.Region, e.Region, e.publicIP)
+}
+
+// NewElasticIP returns a new elastic ip entity
+func NewElasticIP(address *ec2.Address, region string, svc ec2iface.EC2API) *ElasticIP {
+	entity := &ElasticIP{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if address == nil {
+		return entity
+	}
+
+	entity.Region = region
+	entity.allocationID = aws.StringValue(address.AllocationId)
+	entity.publicIP = aws.StringValue(address.PublicIp)
+	entity.ID = entity.allocationID
+	if entity.ID == "" {
+		entity.ID = entity.publicIP
+	}
+	entity.associated = address.AssociationId != nil || address.InstanceId != nil
+
+	for _, tag := range address.Tags {
+		if tag == nil {
+			continue
+		}
+		if tag.Key != nil && tag.Value != nil && *tag.Key == "Name" {
+			entity.Name = *tag.Value
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(elasticIPLabelPublicIP, address.PublicIp).
+		AddLabel(elasticIPLabelDomain, address.Domain).
+		AddBoolLabel(elasticIPLabelIsAssociated, &entity.associated).
+		AddLabel(elasticIPLabelInstanceID, address.InstanceId).
+		AddLabel(elasticIPLabelNetworkInterfaceID, address.NetworkInterfaceId)
+
+	return entity
+}
+
+// Delete releases the address
+func (e *ElasticIP) Delete() error {
+	return e.Remediate(policy.Remediation{Action: policy.ActionRelease})
+}
+
+// Remediate releases the address if it is not associated
+func (e *ElasticIP) Remediate(remediation policy.Remediation) error {
+	if remediation.Action != policy.ActionRelease {
+		return errors.Errorf("elastic ip %s does not support the %s action", e.ID, remediation.Action)
+	}
+	if e.associated {
+		return errors.Errorf("elastic ip %s is associated", e.ID)
+	}
+
+	log.Warnf("Releasing elastic ip %s (%s)", e.ID, e.publicIP)
+	input := &ec2.ReleaseAddressInput{}
+	if e.allocationID != "" {
+		input.AllocationId = aws.String(e.allocationID)
+	} else {
+		input.PublicIp = aws.String(e.publicIP)
+	}
+	_, err := e.svc.ReleaseAddressWithContext(context.Background(), input)
+	return errors.Wrapf(err, "could not release elastic ip %s", e.ID)
+}
+
+// EvalElasticIP walks through all elastic ips
+func (c *Client) EvalElasticIP(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		output, err := client.EC2.DescribeAddressesWithContext(ctx, &ec2.DescribeAddressesInput{})
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not describe elastic ips in %s %s", account.Name, region))
+			return
+		}
+		for _, address := range output.Addresses {
+			e := NewElasticIP(address, region, client.EC2)
+			if p.Match(e) {
+				violation := policy.NewViolation(p, e, p.Expired(e), account)
+				f(violation)
+			}
+		}
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getElasticIP(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	input := &ec2.DescribeAddressesInput{AllocationIds: aws.StringSlice([]string{id})}
+	if net.ParseIP(id) != nil {
+		input = &ec2.DescribeAddressesInput{PublicIps: aws.StringSlice([]string{id})}
+	}
+	output, err := client.EC2.DescribeAddressesWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe elastic ip %s", id)
+	}
+	for _, address := range output.Addresses {
+		return NewElasticIP(address, region, client.EC2), nil
+	}
+	return nil, errors.Errorf("elastic ip %s not found in %s", id, region)
+}
@@ -0,0 +1,81 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewElasticIP(t *testing.T) {
+	a := assert.New(t)
+
+	eip := reaperAws.NewElasticIP(&ec2.Address{
+		AllocationId:       aws.String("eipalloc-1"),
+		PublicIp:           aws.String("1.2.3.4"),
+		Domain:             aws.String(ec2.DomainTypeVpc),
+		AssociationId:      aws.String("eipassoc-1"),
+		NetworkInterfaceId: aws.String("eni-1"),
+	}, "us-west-2", nil)
+	a.Equal("eipalloc-1", eip.GetID())
+	a.Nil(eip.GetCreatedAt())
+	labels := eip.GetLabels()
+	a.Equal("true", labels["is_associated"])
+	a.Equal("eni-1", labels["network_interface_id"])
+
+	// ec2 classic addresses have no allocation id
+	eip = reaperAws.NewElasticIP(&ec2.Address{PublicIp: aws.String("1.2.3.4"), Domain: aws.String(ec2.DomainTypeStandard)}, "us-west-2", nil)
+	a.Equal("1.2.3.4", eip.GetID())
+	a.NotContains(eip.GetLabels(), "is_associated")
+}
+
+func TestElasticIPRemediate(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name    string
+		address *ec2.Address
+		action  string
+		calls   []string
+		err     string
+	}{
+		{
+			name:    "release",
+			address: &ec2.Address{AllocationId: aws.String("eipalloc-1"), PublicIp: aws.String("1.2.3.4")},
+			action:  policy.ActionRelease,
+			calls:   []string{"release eipalloc-1"},
+		},
+		{
+			name:    "release ec2 classic",
+			address: &ec2.Address{PublicIp: aws.String("1.2.3.4")},
+			action:  policy.ActionRelease,
+			calls:   []string{"release 1.2.3.4"},
+		},
+		{
+			name:    "associated",
+			address: &ec2.Address{AllocationId: aws.String("eipalloc-1"), InstanceId: aws.String("i-1")},
+			action:  policy.ActionRelease,
+			err:     "elastic ip eipalloc-1 is associated",
+		},
+		{
+			name:    "delete",
+			address: &ec2.Address{AllocationId: aws.String("eipalloc-1")},
+			action:  policy.ActionDelete,
+			err:     "does not support the delete action",
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeEC2{}
+		err := reaperAws.NewElasticIP(test.address, "us-west-2", svc).Remediate(policy.Remediation{Action: test.action})
+		if test.err == "" {
+			a.NoError(err, test.name)
+		} else if a.Error(err, test.name) {
+			a.Contains(err.Error(), test.err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
@@ -4,10 +4,11 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	cziAws "github.com/chanzuckerberg/go-misc/aws"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 )
 
 // ec2_instance specific labels
@@ -70,17 +71,37 @@ func NewEc2Instance(instance *ec2.Instance, region string) *EC2Instance {
 func (c *Client) EvalEc2Instance(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
 	var errs error
 	ctx := context.Background()
-	err := c.WalkAccountsAndRegions(accounts, regions, func(client *cziAws.Client, account *policy.Account, region string) {
-		err := client.EC2.GetAllInstances(ctx, func(instance *ec2.Instance) {
-			i := NewEc2Instance(instance, region)
-			if p.Match(i) {
-				violation := policy.NewViolation(p, i, false, account)
-				f(violation)
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		err := client.EC2.DescribeInstancesPagesWithContext(ctx, &ec2.DescribeInstancesInput{}, func(output *ec2.DescribeInstancesOutput, lastPage bool) bool {
+			for _, reservation := range output.Reservations {
+				for _, instance := range reservation.Instances {
+					i := NewEc2Instance(instance, region)
+					if p.Match(i) {
+						violation := policy.NewViolation(p, i, p.Expired(i), account)
+						f(violation)
+					}
+				}
 			}
+			return true
 		})
-		errs = multierror.Append(errs, err)
+		errs = multierror.Append(errs, errors.Wrap(err, "error when getting all EC2 instances"))
 	})
 	errs = multierror.Append(errs, err)
 
 	return errs
 }
+
+func (c *Client) getEc2Instance(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	input := &ec2.DescribeInstancesInput{InstanceIds: []*string{aws.String(id)}}
+	output, err := client.EC2.DescribeInstancesWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe instance %s", id)
+	}
+	for _, reservation := range output.Reservations {
+		for _, instance := range reservation.Instances {
+			return NewEc2Instance(instance, region), nil
+		}
+	}
+	return nil, errors.Errorf("instance %s not found in %s", id, region)
+}
@@ -0,0 +1,183 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// nat_gateway specific labels
+const (
+	natGatewayLabelState          TypeEntityLabel = "state"
+	natGatewayLabelVpcID          TypeEntityLabel = "vpc_id"
+	natGatewayLabelSubnetID       TypeEntityLabel = "subnet_id"
+	natGatewayLabelPublicIP       TypeEntityLabel = "public_ip"
+	natGatewayLabelBytesProcessed TypeEntityLabel = "bytes_processed"
+)
+
+// NatGateway is an evaluation entity representing a nat gateway
+type NatGateway struct {
+	Entity
+	state string
+	svc   ec2iface.EC2API
+}
+
+// GetID returns the nat gateway id
+func (n *NatGateway) GetID() string {
+	return n.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (n *NatGateway) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/vpc/home?region=%s#NatGateways:search=%s"
+	return fmt.Sprintf(t, n.Region, n.Region, n.ID)
+}
+
+// NewNatGateway returns a new nat gateway entity. bytesProcessed is nil when we have not
+// looked it up, such as for deleted gateways.
+func NewNatGateway(gateway *ec2.NatGateway, bytesProcessed *float64, region string, svc ec2iface.EC2API) *NatGateway {
+	entity := &NatGateway{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if gateway == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if gateway.NatGatewayId != nil {
+		entity.ID = *gateway.NatGatewayId
+	}
+	entity.state = aws.StringValue(gateway.State)
+
+	for _, tag := range gateway.Tags {
+		if tag == nil {
+			continue
+		}
+		if tag.Key != nil && tag.Value != nil && *tag.Key == "Name" {
+			entity.Name = *tag.Value
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(natGatewayLabelState, gateway.State).
+		AddLabel(natGatewayLabelVpcID, gateway.VpcId).
+		AddLabel(natGatewayLabelSubnetID, gateway.SubnetId).
+		AddCreatedAt(gateway.CreateTime)
+	for _, address := range gateway.NatGatewayAddresses {
+		if address != nil && address.PublicIp != nil {
+			entity.AddLabel(natGatewayLabelPublicIP, address.PublicIp)
+			break
+		}
+	}
+	if bytesProcessed != nil {
+		entity.AddLabel(natGatewayLabelBytesProcessed, formatMetric(*bytesProcessed))
+	}
+
+	return entity
+}
+
+// Delete deletes the nat gateway. Its elastic ips are disassociated but not released. Gateways
+// which aren't available, or which a route table still routes through, are refused.
+func (n *NatGateway) Delete() error {
+	ctx := context.Background()
+	switch n.state {
+	case ec2.NatGatewayStateDeleting, ec2.NatGatewayStateDeleted:
+		log.Infof("nat gateway %s is already %s", n.ID, n.state)
+		return nil
+	case ec2.NatGatewayStateAvailable, ec2.NatGatewayStateFailed:
+	default:
+		return errors.Errorf("nat gateway %s is %s, only available and failed gateways are deleted", n.ID, n.state)
+	}
+
+	input := &ec2.DescribeRouteTablesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("route.nat-gateway-id"), Values: aws.StringSlice([]string{n.ID})}},
+	}
+	output, err := n.svc.DescribeRouteTablesWithContext(ctx, input)
+	if err != nil {
+		return errors.Wrapf(err, "could not describe the route tables routing through nat gateway %s", n.ID)
+	}
+	if len(output.RouteTables) > 0 {
+		return errors.Errorf("route table %s still routes through nat gateway %s", aws.StringValue(output.RouteTables[0].RouteTableId), n.ID)
+	}
+
+	log.Warnf("Deleting nat gateway %s", n.ID)
+	_, err = n.svc.DeleteNatGatewayWithContext(ctx, &ec2.DeleteNatGatewayInput{NatGatewayId: &n.ID})
+	return errors.Wrapf(err, "could not delete nat gateway %s", n.ID)
+}
+
+// natGatewayBytesProcessed returns the bytes a nat gateway received from its sources and
+// destinations over the lookback period, which is what AWS charges data processing for
+func natGatewayBytesProcessed(ctx context.Context, svc cloudwatchiface.CloudWatchAPI, gateway *ec2.NatGateway, lookback time.Duration) (*float64, error) {
+	state := aws.StringValue(gateway.State)
+	if state == ec2.NatGatewayStateDeleted || state == ec2.NatGatewayStateFailed {
+		return nil, nil
+	}
+	dimensions := map[string]string{"NatGatewayId": aws.StringValue(gateway.NatGatewayId)}
+	bytes, err := sumMetrics(ctx, svc, []metricQuery{
+		{Namespace: "AWS/NATGateway", Metric: "BytesInFromSource", Dimensions: dimensions, Statistic: cloudwatch.StatisticSum},
+		{Namespace: "AWS/NATGateway", Metric: "BytesInFromDestination", Dimensions: dimensions, Statistic: cloudwatch.StatisticSum},
+	}, lookback)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get bytes processed by %s", aws.StringValue(gateway.NatGatewayId))
+	}
+	return &bytes, nil
+}
+
+// EvalNatGateway walks through all nat gateways
+func (c *Client) EvalNatGateway(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		cw := cloudwatch.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		input := &ec2.DescribeNatGatewaysInput{}
+		err := client.EC2.DescribeNatGatewaysPagesWithContext(ctx, input, func(output *ec2.DescribeNatGatewaysOutput, lastPage bool) bool {
+			for _, gateway := range output.NatGateways {
+				bytesProcessed, err := natGatewayBytesProcessed(ctx, cw, gateway, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				n := NewNatGateway(gateway, bytesProcessed, region, client.EC2)
+				if p.Match(n) {
+					violation := policy.NewViolation(p, n, p.Expired(n), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getNatGateway(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	input := &ec2.DescribeNatGatewaysInput{NatGatewayIds: aws.StringSlice([]string{id})}
+	output, err := client.EC2.DescribeNatGatewaysWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe nat gateway %s", id)
+	}
+	if len(output.NatGateways) == 0 {
+		return nil, errors.Errorf("nat gateway %s not found in %s", id, region)
+	}
+	gateway := output.NatGateways[0]
+	cw := cloudwatch.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	bytesProcessed, err := natGatewayBytesProcessed(ctx, cw, gateway, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewNatGateway(gateway, bytesProcessed, region, client.EC2), nil
+}
@@ -0,0 +1,84 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewNatGateway(t *testing.T) {
+	a := assert.New(t)
+	gateway := &ec2.NatGateway{
+		NatGatewayId: aws.String("nat-1"),
+		State:        aws.String(ec2.NatGatewayStateAvailable),
+		VpcId:        aws.String("vpc-1"),
+		NatGatewayAddresses: []*ec2.NatGatewayAddress{
+			{PublicIp: aws.String("1.2.3.4")},
+		},
+	}
+
+	labels := reaperAws.NewNatGateway(gateway, aws.Float64(0), "us-west-2", nil).GetLabels()
+	a.Equal("available", labels["state"])
+	a.Equal("1.2.3.4", labels["public_ip"])
+	a.Equal("0", labels["bytes_processed"])
+
+	// deleted gateways have no metrics
+	labels = reaperAws.NewNatGateway(gateway, nil, "us-west-2", nil).GetLabels()
+	a.NotContains(labels, "bytes_processed")
+}
+
+func TestNatGatewayDelete(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name        string
+		state       string
+		routeTables []*ec2.RouteTable
+		calls       []string
+		err         string
+	}{
+		{
+			name:  "available",
+			state: ec2.NatGatewayStateAvailable,
+			calls: []string{"delete nat-1"},
+		},
+		{
+			name:  "failed",
+			state: ec2.NatGatewayStateFailed,
+			calls: []string{"delete nat-1"},
+		},
+		{
+			name:  "already deleting",
+			state: ec2.NatGatewayStateDeleting,
+		},
+		{
+			name:  "pending",
+			state: ec2.NatGatewayStatePending,
+			err:   "nat gateway nat-1 is pending",
+		},
+		{
+			name:        "routed through",
+			state:       ec2.NatGatewayStateAvailable,
+			routeTables: []*ec2.RouteTable{{RouteTableId: aws.String("rtb-1")}},
+			err:         "route table rtb-1 still routes through nat gateway nat-1",
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeEC2{routeTables: test.routeTables}
+		gateway := reaperAws.NewNatGateway(&ec2.NatGateway{
+			NatGatewayId: aws.String("nat-1"),
+			State:        aws.String(test.state),
+		}, nil, "us-west-2", svc)
+		err := gateway.Delete()
+		if test.err == "" {
+			a.NoError(err, test.name)
+		} else if a.Error(err, test.name) {
+			a.Contains(err.Error(), test.err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
@@ -0,0 +1,143 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// network_interface specific labels
+const (
+	networkInterfaceLabelStatus             TypeEntityLabel = "status"
+	networkInterfaceLabelIsAttached         TypeEntityLabel = "is_attached"
+	networkInterfaceLabelInstanceID         TypeEntityLabel = "instance_id"
+	networkInterfaceLabelInterfaceType      TypeEntityLabel = "interface_type"
+	networkInterfaceLabelRequesterManaged   TypeEntityLabel = "requester_managed"
+	networkInterfaceLabelHasPublicIP        TypeEntityLabel = "has_public_ip"
+	networkInterfaceLabelVpcID              TypeEntityLabel = "vpc_id"
+	networkInterfaceLabelSubnetID           TypeEntityLabel = "subnet_id"
+	networkInterfaceLabelAvailabilityZone   TypeEntityLabel = "az"
+	networkInterfaceLabelSecurityGroupCount TypeEntityLabel = "security_group_count"
+)
+
+// NetworkInterface is an evaluation entity representing an elastic network interface
+type NetworkInterface struct {
+	Entity
+	attached         bool
+	requesterManaged bool
+	svc              ec2iface.EC2API
+}
+
+// GetID returns the network interface id
+func (n *NetworkInterface) GetID() string {
+	return n.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (n *NetworkInterface) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#NIC:search=%s"
+	return fmt.Sprintf(t, n.Region, n.Region, n.ID)
+}
+
+// NewNetworkInterface returns a new network interface entity
+func NewNetworkInterface(eni *ec2.NetworkInterface, region string, svc ec2iface.EC2API) *NetworkInterface {
+	entity := &NetworkInterface{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if eni == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if eni.NetworkInterfaceId != nil {
+		entity.ID = *eni.NetworkInterfaceId
+	}
+	entity.attached = eni.Attachment != nil && aws.StringValue(eni.Attachment.Status) != ec2.AttachmentStatusDetached
+	entity.requesterManaged = aws.BoolValue(eni.RequesterManaged)
+	hasPublicIP := eni.Association != nil && eni.Association.PublicIp != nil
+	securityGroupCount := int64(len(eni.Groups))
+
+	for _, tag := range eni.TagSet {
+		if tag == nil {
+			continue
+		}
+		if tag.Key != nil && tag.Value != nil && *tag.Key == "Name" {
+			entity.Name = *tag.Value
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(networkInterfaceLabelStatus, eni.Status).
+		AddBoolLabel(networkInterfaceLabelIsAttached, &entity.attached).
+		AddLabel(networkInterfaceLabelInterfaceType, eni.InterfaceType).
+		AddBoolLabel(networkInterfaceLabelRequesterManaged, eni.RequesterManaged).
+		AddBoolLabel(networkInterfaceLabelHasPublicIP, &hasPublicIP).
+		AddLabel(networkInterfaceLabelVpcID, eni.VpcId).
+		AddLabel(networkInterfaceLabelSubnetID, eni.SubnetId).
+		AddLabel(networkInterfaceLabelAvailabilityZone, eni.AvailabilityZone).
+		AddInt64Label(networkInterfaceLabelSecurityGroupCount, &securityGroupCount)
+	if eni.Attachment != nil {
+		entity.AddLabel(networkInterfaceLabelInstanceID, eni.Attachment.InstanceId)
+	}
+
+	return entity
+}
+
+// Delete deletes the network interface if it is detached and not managed by another service
+func (n *NetworkInterface) Delete() error {
+	if n.attached {
+		return errors.Errorf("network interface %s is attached", n.ID)
+	}
+	if n.requesterManaged {
+		return errors.Errorf("network interface %s is managed by another AWS service", n.ID)
+	}
+
+	log.Warnf("Deleting network interface %s", n.ID)
+	input := &ec2.DeleteNetworkInterfaceInput{NetworkInterfaceId: &n.ID}
+	_, err := n.svc.DeleteNetworkInterfaceWithContext(context.Background(), input)
+	return errors.Wrapf(err, "could not delete network interface %s", n.ID)
+}
+
+// EvalNetworkInterface walks through all network interfaces
+func (c *Client) EvalNetworkInterface(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		input := &ec2.DescribeNetworkInterfacesInput{}
+		err := client.EC2.DescribeNetworkInterfacesPagesWithContext(ctx, input, func(output *ec2.DescribeNetworkInterfacesOutput, lastPage bool) bool {
+			for _, eni := range output.NetworkInterfaces {
+				n := NewNetworkInterface(eni, region, client.EC2)
+				if p.Match(n) {
+					violation := policy.NewViolation(p, n, p.Expired(n), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getNetworkInterface(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	input := &ec2.DescribeNetworkInterfacesInput{NetworkInterfaceIds: aws.StringSlice([]string{id})}
+	output, err := client.EC2.DescribeNetworkInterfacesWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe network interface %s", id)
+	}
+	for _, eni := range output.NetworkInterfaces {
+		return NewNetworkInterface(eni, region, client.EC2), nil
+	}
+	return nil, errors.Errorf("network interface %s not found in %s", id, region)
+}
@@ -3,13 +3,16 @@ package aws
 import (
 	"context"
 	"fmt"
+	"sort"
+	"strings"
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	cziAws "github.com/chanzuckerberg/go-misc/aws"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	"github.com/chanzuckerberg/reaper/pkg/util"
 	"github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -18,11 +21,52 @@ const (
 	publicIngress = "public_ingress"
 )
 
+// ec2_security_group specific labels
+const (
+	securityGroupLabelPublicPorts            TypeEntityLabel = "public_ports"
+	securityGroupLabelPublicAllPorts         TypeEntityLabel = "public_all_ports"
+	securityGroupLabelPublicIngressIPv6      TypeEntityLabel = "public_ingress_ipv6"
+	securityGroupLabelEgressUnrestricted     TypeEntityLabel = "egress_unrestricted"
+	securityGroupLabelAttachedENICount       TypeEntityLabel = "attached_eni_count"
+	securityGroupLabelIsDefault              TypeEntityLabel = "is_default"
+	securityGroupLabelIngressPrefixListCount TypeEntityLabel = "ingress_prefix_list_count"
+	securityGroupLabelIngressGroupCount      TypeEntityLabel = "ingress_group_count"
+)
+
+// securityGroupPublicPortLabelPrefix prefixes the labels set for each publicly reachable port, e.g.
+// public_port_22. Label selectors can't match a single port in public_ports.
+const securityGroupPublicPortLabelPrefix = "public_port_"
+
+// securityGroupSensitivePorts are the ports we set a public_port_ label for when they fall in a
+// public port range, ports opened on their own always get one
+var securityGroupSensitivePorts = []int64{
+	22,    // ssh
+	23,    // telnet
+	445,   // smb
+	1433,  // sql server
+	2375,  // docker
+	3306,  // mysql
+	3389,  // rdp
+	5432,  // postgres
+	6379,  // redis
+	9200,  // elasticsearch
+	11211, // memcached
+	27017, // mongodb
+}
+
+// the ip permission protocol allowing all traffic
+const securityGroupAllProtocols = "-1"
+
 // EC2SG is an evaluation entity representing an ec2 security group
 type EC2SG struct {
 	Entity
-	ID   string
-	Name string
+	ID               string
+	Name             string
+	isDefault        bool
+	attachedENICount int64
+	// publicIngress holds the parts of the ingress rules open to public ranges
+	publicIngress []*ec2.IpPermission
+	svc           ec2iface.EC2API
 }
 
 // GetID returns the security group id
@@ -30,10 +74,12 @@ func (e *EC2SG) GetID() string {
 	return e.ID
 }
 
-// NewEC2SG returns a new ec2 security group
-func NewEC2SG(sg *ec2.SecurityGroup, region string) *EC2SG {
+// NewEC2SG returns a new ec2 security group. attachedENICount is how many network interfaces use it.
+func NewEC2SG(sg *ec2.SecurityGroup, region string, attachedENICount int64, svc ec2iface.EC2API) *EC2SG {
 	entity := &EC2SG{
-		Entity: NewEntity(),
+		Entity:           NewEntity(),
+		attachedENICount: attachedENICount,
+		svc:              svc,
 	}
 	if sg == nil {
 		log.Debug("nil sg")
@@ -55,64 +101,236 @@ func NewEC2SG(sg *ec2.SecurityGroup, region string) *EC2SG {
 		}
 		entity.AddTag(tag.Key, tag.Value)
 	}
-	entity.AddLabel(vpcID, sg.VpcId)
+	entity.isDefault = aws.StringValue(sg.GroupName) == "default"
+	entity.
+		AddLabel(vpcID, sg.VpcId).
+		AddBoolLabel(securityGroupLabelIsDefault, &entity.isDefault).
+		AddInt64Label(securityGroupLabelAttachedENICount, &attachedENICount)
 
+	var publicPorts []string
+	var publicAllPorts, publicIPv4, publicIPv6 bool
+	var prefixListCount, groupCount int64
 	for _, permission := range sg.IpPermissions {
+		if permission == nil {
+			continue
+		}
+		prefixListCount += int64(len(permission.PrefixListIds))
+		groupCount += int64(len(permission.UserIdGroupPairs))
+
+		public := &ec2.IpPermission{
+			IpProtocol: permission.IpProtocol,
+			FromPort:   permission.FromPort,
+			ToPort:     permission.ToPort,
+		}
 		for _, rang := range permission.IpRanges {
-			if rang.CidrIp != nil && util.ContainsPublicIps(*rang.CidrIp) {
-				entity.AddLabel(publicIngress, aws.String("true"))
+			if rang.CidrIp != nil && entity.isPublic(*rang.CidrIp) {
+				publicIPv4 = true
+				public.IpRanges = append(public.IpRanges, rang)
+			}
+		}
+		for _, rang := range permission.Ipv6Ranges {
+			if rang.CidrIpv6 != nil && entity.isPublic(*rang.CidrIpv6) {
+				publicIPv6 = true
+				public.Ipv6Ranges = append(public.Ipv6Ranges, rang)
+			}
+		}
+		if len(public.IpRanges) == 0 && len(public.Ipv6Ranges) == 0 {
+			continue
+		}
+		entity.publicIngress = append(entity.publicIngress, public)
+
+		ports, allPorts := ipPermissionPorts(permission)
+		if !containsString(publicPorts, ports) {
+			publicPorts = append(publicPorts, ports)
+		}
+		publicAllPorts = publicAllPorts || allPorts
+		if !allPorts && !ipPermissionHasPorts(permission) {
+			continue
+		}
+		from, to := aws.Int64Value(permission.FromPort), aws.Int64Value(permission.ToPort)
+		for _, port := range securityGroupSensitivePorts {
+			if allPorts || (from <= port && port <= to) {
+				entity.addPublicPort(port)
 			}
 		}
+		if !allPorts && from == to {
+			entity.addPublicPort(from)
+		}
+	}
+	if len(publicPorts) > 0 {
+		sort.Strings(publicPorts)
+		entity.AddLabel(securityGroupLabelPublicPorts, aws.String(strings.Join(publicPorts, ",")))
 	}
 
+	egressUnrestricted := false
+	for _, permission := range sg.IpPermissionsEgress {
+		if permission == nil || aws.StringValue(permission.IpProtocol) != securityGroupAllProtocols {
+			continue
+		}
+		for _, rang := range permission.IpRanges {
+			egressUnrestricted = egressUnrestricted || aws.StringValue(rang.CidrIp) == "0.0.0.0/0"
+		}
+		for _, rang := range permission.Ipv6Ranges {
+			egressUnrestricted = egressUnrestricted || aws.StringValue(rang.CidrIpv6) == "::/0"
+		}
+	}
+
+	entity.
+		AddBoolLabel(publicIngress, &publicIPv4).
+		AddBoolLabel(securityGroupLabelPublicIngressIPv6, &publicIPv6).
+		AddBoolLabel(securityGroupLabelPublicAllPorts, &publicAllPorts).
+		AddBoolLabel(securityGroupLabelEgressUnrestricted, &egressUnrestricted).
+		AddInt64Label(securityGroupLabelIngressPrefixListCount, &prefixListCount).
+		AddInt64Label(securityGroupLabelIngressGroupCount, &groupCount)
+
 	return entity
 }
 
+// isPublic returns true if a rule's CIDR contains any public address
+func (e *EC2SG) isPublic(cidr string) bool {
+	public, err := util.ContainsPublicIps(cidr)
+	if err != nil {
+		log.Warnf("Ignoring the rule for %s in security group %s: %s", cidr, e.ID, err)
+		return false
+	}
+	return public
+}
+
+// addPublicPort sets the label for a port open to a public range
+func (e *EC2SG) addPublicPort(port int64) {
+	e.AddLabel(TypeEntityLabel(fmt.Sprintf("%s%d", securityGroupPublicPortLabelPrefix, port)), aws.String("true"))
+}
+
+// ipPermissionPorts describes the ports a rule opens, e.g. 22, 8000-8080 or all, and whether
+// those are all the ports
+func ipPermissionPorts(permission *ec2.IpPermission) (string, bool) {
+	protocol := aws.StringValue(permission.IpProtocol)
+	if protocol == securityGroupAllProtocols {
+		return "all", true
+	}
+	if !ipPermissionHasPorts(permission) {
+		return protocol, false
+	}
+	from, to := aws.Int64Value(permission.FromPort), aws.Int64Value(permission.ToPort)
+	if from <= 0 && to >= 65535 {
+		return "all", true
+	}
+	if from == to {
+		return fmt.Sprintf("%d", from), false
+	}
+	return fmt.Sprintf("%d-%d", from, to), false
+}
+
+// ipPermissionHasPorts returns false for rules whose protocol has no ports, such as icmp, which uses
+// from and to for the icmp type and code instead
+func ipPermissionHasPorts(permission *ec2.IpPermission) bool {
+	switch aws.StringValue(permission.IpProtocol) {
+	case "tcp", "6", "udp", "17", "132":
+		return permission.FromPort != nil
+	default:
+		return false
+	}
+}
+
 // GetConsoleURL will return a url to the AWS console for this security group
 func (e *EC2SG) GetConsoleURL() string {
 	t := "https://%s.console.aws.amazon.com/ec2/v2/home?region=%s#SecurityGroups:groupId=%s"
 	return fmt.Sprintf(t, e.Region, e.Region, e.ID)
 }
 
-// Delete deletes
+// Delete deletes the security group if no network interface uses it
 func (e *EC2SG) Delete() error {
-	log.Warnf("Would delete security group %s", e.ID)
-	return nil
+	return e.Remediate(policy.Remediation{Action: policy.ActionDelete})
+}
+
+// Remediate revokes the security group's public ingress rules or deletes it. Default groups and
+// groups network interfaces still use are not deleted.
+func (e *EC2SG) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	switch remediation.Action {
+	case policy.ActionRevokePublicIngress:
+		if len(e.publicIngress) == 0 {
+			return nil
+		}
+		log.Warnf("Revoking %d public ingress rules of security group %s", len(e.publicIngress), e.ID)
+		input := &ec2.RevokeSecurityGroupIngressInput{
+			GroupId:       &e.ID,
+			IpPermissions: e.publicIngress,
+		}
+		_, err := e.svc.RevokeSecurityGroupIngressWithContext(ctx, input)
+		return errors.Wrapf(err, "could not revoke the public ingress rules of security group %s", e.ID)
+	case policy.ActionDelete:
+		if e.isDefault {
+			return errors.Errorf("security group %s is the default group of its vpc", e.ID)
+		}
+		if e.attachedENICount > 0 {
+			return errors.Errorf("security group %s is used by %d network interfaces", e.ID, e.attachedENICount)
+		}
+		log.Warnf("Deleting security group %s", e.ID)
+		_, err := e.svc.DeleteSecurityGroupWithContext(ctx, &ec2.DeleteSecurityGroupInput{GroupId: &e.ID})
+		return errors.Wrapf(err, "could not delete security group %s", e.ID)
+	default:
+		return errors.Errorf("security group %s does not support the %s action", e.ID, remediation.Action)
+	}
+}
+
+// countSecurityGroupENIs counts the network interfaces using each security group
+func countSecurityGroupENIs(ctx context.Context, svc ec2iface.EC2API, input *ec2.DescribeNetworkInterfacesInput) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := svc.DescribeNetworkInterfacesPagesWithContext(ctx, input, func(output *ec2.DescribeNetworkInterfacesOutput, lastPage bool) bool {
+		for _, eni := range output.NetworkInterfaces {
+			for _, group := range eni.Groups {
+				counts[aws.StringValue(group.GroupId)]++
+			}
+		}
+		return true
+	})
+	return counts, errors.Wrap(err, "could not describe network interfaces")
 }
 
 // EvalEC2SG walks through all ec2 instances
 func (c *Client) EvalEC2SG(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
 	var errs error
 	ctx := context.Background()
-	err := c.WalkAccountsAndRegions(accounts, regions, func(client *cziAws.Client, account *policy.Account, region string) {
-		var nextToken *string
-		// Limiting to 1000 iteration guarantees that we don't get an infinite loop, even if we have
-		// a mistake below. Small tradeoff is that if there are greater than 1000*pagesize security
-		// groups we won't scan them all.
-		for i := 1; i <= 1000; i++ {
-			log.Debugf("nextToken: %#v", nextToken)
-			input := &ec2.DescribeSecurityGroupsInput{NextToken: nextToken}
-
-			output, err := client.EC2.Svc.DescribeSecurityGroupsWithContext(ctx, input)
-
-			if err != nil {
-				errs = multierror.Append(errs, err)
-			} else {
-				for _, sg := range output.SecurityGroups {
-					s := NewEC2SG(sg, region)
-					if p.Match(s) {
-						violation := policy.NewViolation(p, s, false, account)
-						f(violation)
-					}
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		eniCounts, err := countSecurityGroupENIs(ctx, client.EC2, &ec2.DescribeNetworkInterfacesInput{})
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		input := &ec2.DescribeSecurityGroupsInput{}
+		err = client.EC2.DescribeSecurityGroupsPagesWithContext(ctx, input, func(output *ec2.DescribeSecurityGroupsOutput, lastPage bool) bool {
+			for _, sg := range output.SecurityGroups {
+				s := NewEC2SG(sg, region, eniCounts[aws.StringValue(sg.GroupId)], client.EC2)
+				if p.Match(s) {
+					violation := policy.NewViolation(p, s, p.Expired(s), account)
+					f(violation)
 				}
 			}
-			if output.NextToken == nil {
-				break
-			}
-			nextToken = output.NextToken
-		}
+			return true
+		})
+		errs = multierror.Append(errs, err)
 	})
 	errs = multierror.Append(errs, err)
 
 	return errs
 }
+
+func (c *Client) getEC2SG(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	input := &ec2.DescribeSecurityGroupsInput{GroupIds: []*string{aws.String(id)}}
+	output, err := client.EC2.DescribeSecurityGroupsWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe security group %s", id)
+	}
+	eniCounts, err := countSecurityGroupENIs(ctx, client.EC2, &ec2.DescribeNetworkInterfacesInput{
+		Filters: []*ec2.Filter{{Name: aws.String("group-id"), Values: aws.StringSlice([]string{id})}},
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, sg := range output.SecurityGroups {
+		return NewEC2SG(sg, region, eniCounts[id], client.EC2), nil
+	}
+	return nil, errors.Errorf("security group %s not found in %s", id, region)
+}
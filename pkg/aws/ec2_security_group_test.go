@@ -0,0 +1,156 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func tcpPermission(from, to int64, cidrs ...string) *ec2.IpPermission {
+	permission := &ec2.IpPermission{
+		IpProtocol: aws.String("tcp"),
+		FromPort:   aws.Int64(from),
+		ToPort:     aws.Int64(to),
+	}
+	for _, cidr := range cidrs {
+		permission.IpRanges = append(permission.IpRanges, &ec2.IpRange{CidrIp: aws.String(cidr)})
+	}
+	return permission
+}
+
+func TestNewEC2SG(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name    string
+		sg      *ec2.SecurityGroup
+		labels  map[string]string
+		unset   []string
+		revoked int
+	}{
+		{
+			name: "ssh and rdp open to the world",
+			sg: &ec2.SecurityGroup{
+				GroupName: aws.String("bastion"),
+				IpPermissions: []*ec2.IpPermission{
+					tcpPermission(22, 22, "0.0.0.0/0", "10.0.0.0/8"),
+					tcpPermission(3389, 3389, "0.0.0.0/0"),
+				},
+			},
+			labels: map[string]string{
+				"public_ingress":   "true",
+				"public_ports":     "22,3389",
+				"public_port_22":   "true",
+				"public_port_3389": "true",
+			},
+			unset:   []string{"public_all_ports", "public_ingress_ipv6", "is_default"},
+			revoked: 2,
+		},
+		{
+			name: "all traffic over ipv6",
+			sg: &ec2.SecurityGroup{
+				GroupName: aws.String("default"),
+				IpPermissions: []*ec2.IpPermission{{
+					IpProtocol: aws.String("-1"),
+					Ipv6Ranges: []*ec2.Ipv6Range{{CidrIpv6: aws.String("::/0")}},
+				}},
+				IpPermissionsEgress: []*ec2.IpPermission{{
+					IpProtocol: aws.String("-1"),
+					IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+				}},
+			},
+			labels: map[string]string{
+				"public_ingress_ipv6": "true",
+				"public_ports":        "all",
+				"public_all_ports":    "true",
+				"public_port_22":      "true",
+				"egress_unrestricted": "true",
+				"is_default":          "true",
+			},
+			unset:   []string{"public_ingress"},
+			revoked: 1,
+		},
+		{
+			name: "port range and icmp",
+			sg: &ec2.SecurityGroup{
+				IpPermissions: []*ec2.IpPermission{
+					tcpPermission(3000, 3400, "0.0.0.0/0"),
+					{
+						IpProtocol: aws.String("icmp"),
+						FromPort:   aws.Int64(8),
+						ToPort:     aws.Int64(0),
+						IpRanges:   []*ec2.IpRange{{CidrIp: aws.String("0.0.0.0/0")}},
+					},
+				},
+			},
+			labels: map[string]string{
+				"public_ports":     "3000-3400,icmp",
+				"public_port_3306": "true",
+				"public_port_3389": "true",
+			},
+			unset:   []string{"public_port_8", "public_port_3000", "public_all_ports"},
+			revoked: 2,
+		},
+		{
+			name: "private and group sources",
+			sg: &ec2.SecurityGroup{
+				IpPermissions: []*ec2.IpPermission{
+					tcpPermission(22, 22, "172.16.0.0/12", "100.64.0.0/10"),
+					{
+						IpProtocol: aws.String("tcp"),
+						FromPort:   aws.Int64(22),
+						ToPort:     aws.Int64(22),
+						Ipv6Ranges: []*ec2.Ipv6Range{{CidrIpv6: aws.String("fd00::/8")}},
+					},
+					{
+						IpProtocol:       aws.String("tcp"),
+						FromPort:         aws.Int64(443),
+						ToPort:           aws.Int64(443),
+						UserIdGroupPairs: []*ec2.UserIdGroupPair{{GroupId: aws.String("sg-1")}},
+						PrefixListIds:    []*ec2.PrefixListId{{PrefixListId: aws.String("pl-1")}},
+					},
+				},
+			},
+			labels: map[string]string{
+				"ingress_group_count":       "1",
+				"ingress_prefix_list_count": "1",
+				"attached_eni_count":        "2",
+			},
+			unset: []string{"public_ingress", "public_ingress_ipv6", "public_ports", "public_port_22", "egress_unrestricted"},
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeEC2{}
+		sg := reaperAws.NewEC2SG(test.sg, "us-west-2", 2, svc)
+		labels := sg.GetLabels()
+		for label, value := range test.labels {
+			a.Equal(value, labels[label], "%s: %s", test.name, label)
+		}
+		for _, label := range test.unset {
+			a.NotContains(labels, label, test.name)
+		}
+
+		a.NoError(sg.Remediate(policy.Remediation{Action: policy.ActionRevokePublicIngress}), test.name)
+		a.Len(svc.revoked, test.revoked, test.name)
+		for _, permission := range svc.revoked {
+			for _, rang := range permission.IpRanges {
+				a.NotEqual("10.0.0.0/8", aws.StringValue(rang.CidrIp), test.name)
+			}
+		}
+	}
+}
+
+func TestEC2SGDeleteRefusesUsedGroups(t *testing.T) {
+	a := assert.New(t)
+
+	used := reaperAws.NewEC2SG(&ec2.SecurityGroup{GroupId: aws.String("sg-1"), GroupName: aws.String("web")}, "us-west-2", 1, &fakeEC2{})
+	a.Error(used.Remediate(policy.Remediation{Action: policy.ActionDelete}))
+
+	defaultGroup := reaperAws.NewEC2SG(&ec2.SecurityGroup{GroupId: aws.String("sg-2"), GroupName: aws.String("default")}, "us-west-2", 0, &fakeEC2{})
+	a.Error(defaultGroup.Remediate(policy.Remediation{Action: policy.ActionDelete}))
+}
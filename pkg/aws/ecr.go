@@ -0,0 +1,185 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecr/ecriface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// ecr_repository specific labels
+const (
+	ecrLabelImageCount         TypeEntityLabel = "image_count"
+	ecrLabelLastPush           TypeEntityLabel = "last_push"
+	ecrLabelDaysSinceLastPush  TypeEntityLabel = "days_since_last_push"
+	ecrLabelHasLifecyclePolicy TypeEntityLabel = "has_lifecycle_policy"
+	ecrLabelScanOnPush         TypeEntityLabel = "scan_on_push"
+	ecrLabelTagMutability      TypeEntityLabel = "tag_mutability"
+)
+
+// ECRRepository is an evaluation entity representing an ecr repository
+type ECRRepository struct {
+	Entity
+}
+
+// GetID returns the repository name
+func (e *ECRRepository) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *ECRRepository) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ecr/repositories/%s/?region=%s"
+	return fmt.Sprintf(t, e.Region, e.ID, e.Region)
+}
+
+// ecrRepositoryDetails are looked up separately from the repository description
+type ecrRepositoryDetails struct {
+	tags               []*ecr.Tag
+	imageCount         int64
+	lastPush           *time.Time
+	hasLifecyclePolicy bool
+}
+
+// NewECRRepository returns a new ecr repository entity
+func NewECRRepository(repository *ecr.Repository, details *ecrRepositoryDetails, region string) *ECRRepository {
+	entity := &ECRRepository{
+		Entity: NewEntity(),
+	}
+	if repository == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if repository.RepositoryName != nil {
+		entity.ID = *repository.RepositoryName
+		entity.Name = *repository.RepositoryName
+	}
+
+	for _, tag := range details.tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(labelARN, repository.RepositoryArn).
+		AddInt64Label(ecrLabelImageCount, &details.imageCount).
+		AddBoolLabel(ecrLabelHasLifecyclePolicy, &details.hasLifecyclePolicy).
+		AddLabel(ecrLabelTagMutability, repository.ImageTagMutability).
+		AddCreatedAt(repository.CreatedAt)
+	if repository.ImageScanningConfiguration != nil {
+		entity.AddBoolLabel(ecrLabelScanOnPush, repository.ImageScanningConfiguration.ScanOnPush)
+	}
+	if details.lastPush != nil {
+		days := int64(math.Floor(time.Since(*details.lastPush).Hours() / 24))
+		entity.
+			AddLabel(ecrLabelLastPush, aws.String(details.lastPush.UTC().Format("2006-01-02"))).
+			AddInt64Label(ecrLabelDaysSinceLastPush, &days)
+	}
+
+	return entity
+}
+
+func getECRRepositoryDetails(ctx context.Context, svc ecriface.ECRAPI, repository *ecr.Repository) (*ecrRepositoryDetails, error) {
+	details := &ecrRepositoryDetails{}
+	name := aws.StringValue(repository.RepositoryName)
+
+	tags, err := svc.ListTagsForResourceWithContext(ctx, &ecr.ListTagsForResourceInput{ResourceArn: repository.RepositoryArn})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list tags of ecr repository %s", name)
+	}
+	details.tags = tags.Tags
+
+	_, err = svc.GetLifecyclePolicyWithContext(ctx, &ecr.GetLifecyclePolicyInput{RepositoryName: repository.RepositoryName, RegistryId: repository.RegistryId})
+	if err == nil {
+		details.hasLifecyclePolicy = true
+	} else if aerr, ok := err.(awserr.Error); !ok || aerr.Code() != ecr.ErrCodeLifecyclePolicyNotFoundException {
+		return nil, errors.Wrapf(err, "could not get lifecycle policy of ecr repository %s", name)
+	}
+	return details, nil
+}
+
+// ecrImageLabels are the labels getECRImageDetails looks up
+var ecrImageLabels = []string{string(ecrLabelImageCount), string(ecrLabelLastPush), string(ecrLabelDaysSinceLastPush)}
+
+// getECRImageDetails adds the image count and last push of the repository to its details. It
+// pages through every image, so evaluations only look them up for repositories that might match.
+func getECRImageDetails(ctx context.Context, svc ecriface.ECRAPI, repository *ecr.Repository, details *ecrRepositoryDetails) error {
+	input := &ecr.DescribeImagesInput{RepositoryName: repository.RepositoryName, RegistryId: repository.RegistryId}
+	err := svc.DescribeImagesPagesWithContext(ctx, input, func(output *ecr.DescribeImagesOutput, lastPage bool) bool {
+		for _, image := range output.ImageDetails {
+			details.imageCount++
+			if image.ImagePushedAt != nil && (details.lastPush == nil || image.ImagePushedAt.After(*details.lastPush)) {
+				details.lastPush = image.ImagePushedAt
+			}
+		}
+		return true
+	})
+	return errors.Wrapf(err, "could not describe images in ecr repository %s", aws.StringValue(repository.RepositoryName))
+}
+
+// EvalECRRepository walks through all ecr repositories
+func (c *Client) EvalECRRepository(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := ecr.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		err := svc.DescribeRepositoriesPagesWithContext(ctx, &ecr.DescribeRepositoriesInput{}, func(output *ecr.DescribeRepositoriesOutput, lastPage bool) bool {
+			for _, repository := range output.Repositories {
+				details, err := getECRRepositoryDetails(ctx, svc, repository)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if !p.MightMatch(NewECRRepository(repository, details, region), ecrImageLabels, false) {
+					continue
+				}
+				err = getECRImageDetails(ctx, svc, repository, details)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				e := NewECRRepository(repository, details, region)
+				if p.Match(e) {
+					violation := policy.NewViolation(p, e, p.Expired(e), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getECRRepository(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := ecr.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	output, err := svc.DescribeRepositoriesWithContext(ctx, &ecr.DescribeRepositoriesInput{RepositoryNames: aws.StringSlice([]string{id})})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe ecr repository %s", id)
+	}
+	if len(output.Repositories) == 0 {
+		return nil, errors.Errorf("ecr repository %s not found in %s", id, region)
+	}
+	repository := output.Repositories[0]
+	details, err := getECRRepositoryDetails(ctx, svc, repository)
+	if err != nil {
+		return nil, err
+	}
+	err = getECRImageDetails(ctx, svc, repository, details)
+	if err != nil {
+		return nil, err
+	}
+	return NewECRRepository(repository, details, region), nil
+}
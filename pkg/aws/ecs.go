@@ -0,0 +1,281 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/ecs/ecsiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// ecs_cluster and ecs_service specific labels
+const (
+	ecsLabelStatus                 TypeEntityLabel = "status"
+	ecsLabelRunningTaskCount       TypeEntityLabel = "running_task_count"
+	ecsLabelPendingTaskCount       TypeEntityLabel = "pending_task_count"
+	ecsLabelActiveServiceCount     TypeEntityLabel = "active_service_count"
+	ecsLabelContainerInstanceCount TypeEntityLabel = "container_instance_count"
+	ecsLabelCluster                TypeEntityLabel = "cluster"
+	ecsLabelLaunchType             TypeEntityLabel = "launch_type"
+	ecsLabelDesiredCount           TypeEntityLabel = "desired_count"
+)
+
+// DescribeClusters and DescribeServices take at most this many names at a time
+const (
+	ecsDescribeClustersBatch = 100
+	ecsDescribeServicesBatch = 10
+)
+
+// ECSCluster is an evaluation entity representing an ecs cluster
+type ECSCluster struct {
+	Entity
+}
+
+// GetID returns the cluster name
+func (e *ECSCluster) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *ECSCluster) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ecs/home?region=%s#/clusters/%s/services"
+	return fmt.Sprintf(t, e.Region, e.Region, e.ID)
+}
+
+// NewECSCluster returns a new ecs cluster entity
+func NewECSCluster(cluster *ecs.Cluster, region string) *ECSCluster {
+	entity := &ECSCluster{
+		Entity: NewEntity(),
+	}
+	if cluster == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if cluster.ClusterName != nil {
+		entity.ID = *cluster.ClusterName
+		entity.Name = *cluster.ClusterName
+	}
+
+	for _, tag := range cluster.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(labelARN, cluster.ClusterArn).
+		AddLabel(ecsLabelStatus, cluster.Status).
+		AddInt64Label(ecsLabelRunningTaskCount, cluster.RunningTasksCount).
+		AddInt64Label(ecsLabelPendingTaskCount, cluster.PendingTasksCount).
+		AddInt64Label(ecsLabelActiveServiceCount, cluster.ActiveServicesCount).
+		AddInt64Label(ecsLabelContainerInstanceCount, cluster.RegisteredContainerInstancesCount)
+
+	return entity
+}
+
+// ECSService is an evaluation entity representing an ecs service
+type ECSService struct {
+	Entity
+	cluster string
+}
+
+// GetID returns the cluster and service name as cluster/service
+func (e *ECSService) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *ECSService) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ecs/home?region=%s#/clusters/%s/services/%s/details"
+	return fmt.Sprintf(t, e.Region, e.Region, e.cluster, e.Name)
+}
+
+// NewECSService returns a new ecs service entity
+func NewECSService(service *ecs.Service, cluster string, region string) *ECSService {
+	entity := &ECSService{
+		Entity:  NewEntity(),
+		cluster: cluster,
+	}
+	if service == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if service.ServiceName != nil {
+		entity.ID = fmt.Sprintf("%s/%s", cluster, *service.ServiceName)
+		entity.Name = *service.ServiceName
+	}
+
+	for _, tag := range service.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(labelARN, service.ServiceArn).
+		AddLabel(ecsLabelCluster, &cluster).
+		AddLabel(ecsLabelStatus, service.Status).
+		AddLabel(ecsLabelLaunchType, service.LaunchType).
+		AddInt64Label(ecsLabelDesiredCount, service.DesiredCount).
+		AddInt64Label(ecsLabelRunningTaskCount, service.RunningCount).
+		AddInt64Label(ecsLabelPendingTaskCount, service.PendingCount).
+		AddCreatedAt(service.CreatedAt)
+
+	return entity
+}
+
+// describeECSClusters describes clusters, all of them if names is empty
+func describeECSClusters(ctx context.Context, svc ecsiface.ECSAPI, names []*string) ([]*ecs.Cluster, error) {
+	if len(names) == 0 {
+		err := svc.ListClustersPagesWithContext(ctx, &ecs.ListClustersInput{}, func(output *ecs.ListClustersOutput, lastPage bool) bool {
+			names = append(names, output.ClusterArns...)
+			return true
+		})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not list ecs clusters")
+		}
+	}
+
+	var clusters []*ecs.Cluster
+	for i := 0; i < len(names); i += ecsDescribeClustersBatch {
+		end := i + ecsDescribeClustersBatch
+		if end > len(names) {
+			end = len(names)
+		}
+		input := &ecs.DescribeClustersInput{
+			Clusters: names[i:end],
+			Include:  aws.StringSlice([]string{ecs.ClusterFieldTags}),
+		}
+		output, err := svc.DescribeClustersWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.Wrap(err, "could not describe ecs clusters")
+		}
+		clusters = append(clusters, output.Clusters...)
+	}
+	return clusters, nil
+}
+
+// describeECSServices describes the services in cluster, all of them if names is empty
+func describeECSServices(ctx context.Context, svc ecsiface.ECSAPI, cluster string, names []*string) ([]*ecs.Service, error) {
+	if len(names) == 0 {
+		input := &ecs.ListServicesInput{Cluster: &cluster}
+		err := svc.ListServicesPagesWithContext(ctx, input, func(output *ecs.ListServicesOutput, lastPage bool) bool {
+			names = append(names, output.ServiceArns...)
+			return true
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not list services in ecs cluster %s", cluster)
+		}
+	}
+
+	var services []*ecs.Service
+	for i := 0; i < len(names); i += ecsDescribeServicesBatch {
+		end := i + ecsDescribeServicesBatch
+		if end > len(names) {
+			end = len(names)
+		}
+		input := &ecs.DescribeServicesInput{
+			Cluster:  &cluster,
+			Services: names[i:end],
+			Include:  aws.StringSlice([]string{ecs.ServiceFieldTags}),
+		}
+		output, err := svc.DescribeServicesWithContext(ctx, input)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not describe services in ecs cluster %s", cluster)
+		}
+		services = append(services, output.Services...)
+	}
+	return services, nil
+}
+
+// EvalECSCluster walks through all ecs clusters
+func (c *Client) EvalECSCluster(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := ecs.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		clusters, err := describeECSClusters(ctx, svc, nil)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		for _, cluster := range clusters {
+			e := NewECSCluster(cluster, region)
+			if p.Match(e) {
+				violation := policy.NewViolation(p, e, p.Expired(e), account)
+				f(violation)
+			}
+		}
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+// EvalECSService walks through all services in all ecs clusters
+func (c *Client) EvalECSService(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := ecs.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		clusters, err := describeECSClusters(ctx, svc, nil)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
+		for _, cluster := range clusters {
+			name := aws.StringValue(cluster.ClusterName)
+			services, err := describeECSServices(ctx, svc, name, nil)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			for _, service := range services {
+				e := NewECSService(service, name, region)
+				if p.Match(e) {
+					violation := policy.NewViolation(p, e, p.Expired(e), account)
+					f(violation)
+				}
+			}
+		}
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getECSCluster(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := ecs.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	clusters, err := describeECSClusters(ctx, svc, aws.StringSlice([]string{id}))
+	if err != nil {
+		return nil, err
+	}
+	for _, cluster := range clusters {
+		return NewECSCluster(cluster, region), nil
+	}
+	return nil, errors.Errorf("ecs cluster %s not found in %s", id, region)
+}
+
+// getECSService fetches a service identified as cluster/service
+func (c *Client) getECSService(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("ecs services are identified as cluster/service, not %s", id)
+	}
+	svc := ecs.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	services, err := describeECSServices(ctx, svc, parts[0], aws.StringSlice([]string{parts[1]}))
+	if err != nil {
+		return nil, err
+	}
+	for _, service := range services {
+		return NewECSService(service, parts[0], region), nil
+	}
+	return nil, errors.Errorf("ecs service %s not found in %s", id, region)
+}
@@ -0,0 +1,276 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/eks/eksiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// eks_cluster and eks_nodegroup specific labels
+const (
+	eksLabelVersion              TypeEntityLabel = "version"
+	eksLabelVersionEndOfLife     TypeEntityLabel = "version_end_of_life"
+	eksLabelStatus               TypeEntityLabel = "status"
+	eksLabelEndpointPublicAccess TypeEntityLabel = "endpoint_public_access"
+	eksLabelNodegroupCount       TypeEntityLabel = "nodegroup_count"
+	eksLabelCluster              TypeEntityLabel = "cluster"
+	eksLabelDesiredSize          TypeEntityLabel = "desired_size"
+	eksLabelAMIType              TypeEntityLabel = "ami_type"
+)
+
+// eksVersionEndOfLife maps from a kubernetes version to the date EKS ended standard support
+// for it, see https://docs.aws.amazon.com/eks/latest/userguide/kubernetes-versions.html
+var eksVersionEndOfLife = mustParseEndOfLife(map[string]string{
+	"1.10": "2019-07-22",
+	"1.11": "2019-11-04",
+	"1.12": "2020-05-11",
+	"1.13": "2020-06-30",
+	"1.14": "2020-12-08",
+	"1.15": "2021-05-03",
+	"1.16": "2021-09-27",
+	"1.17": "2021-11-02",
+	"1.18": "2022-03-31",
+	"1.19": "2022-08-01",
+	"1.20": "2022-11-01",
+	"1.21": "2023-02-15",
+	"1.22": "2023-06-04",
+	"1.23": "2023-10-11",
+	"1.24": "2024-01-31",
+	"1.25": "2024-05-01",
+	"1.26": "2024-06-11",
+	"1.27": "2024-07-24",
+	"1.28": "2024-11-26",
+	"1.29": "2025-03-23",
+	"1.30": "2025-07-23",
+	"1.31": "2025-11-26",
+	"1.32": "2026-03-23",
+	"1.33": "2026-07-29",
+})
+
+// EKSVersionEndOfLife returns true if EKS ended standard support for the kubernetes version before now
+func EKSVersionEndOfLife(version string, now time.Time) bool {
+	return eksVersionEndOfLife.ended(version, now)
+}
+
+// EKSCluster is an evaluation entity representing an eks cluster
+type EKSCluster struct {
+	Entity
+}
+
+// GetID returns the cluster name
+func (e *EKSCluster) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *EKSCluster) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/eks/home?region=%s#/clusters/%s"
+	return fmt.Sprintf(t, e.Region, e.Region, e.ID)
+}
+
+// NewEKSCluster returns a new eks cluster entity
+func NewEKSCluster(cluster *eks.Cluster, nodegroupCount int64, region string) *EKSCluster {
+	entity := &EKSCluster{
+		Entity: NewEntity(),
+	}
+	if cluster == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if cluster.Name != nil {
+		entity.ID = *cluster.Name
+		entity.Name = *cluster.Name
+	}
+
+	for key, value := range cluster.Tags {
+		entity.AddTag(aws.String(key), value)
+	}
+	endOfLife := EKSVersionEndOfLife(aws.StringValue(cluster.Version), time.Now())
+	entity.
+		AddLabel(labelARN, cluster.Arn).
+		AddLabel(eksLabelVersion, cluster.Version).
+		AddBoolLabel(eksLabelVersionEndOfLife, &endOfLife).
+		AddLabel(eksLabelStatus, cluster.Status).
+		AddInt64Label(eksLabelNodegroupCount, &nodegroupCount).
+		AddCreatedAt(cluster.CreatedAt)
+	if cluster.ResourcesVpcConfig != nil {
+		entity.AddBoolLabel(eksLabelEndpointPublicAccess, cluster.ResourcesVpcConfig.EndpointPublicAccess)
+	}
+
+	return entity
+}
+
+// EKSNodegroup is an evaluation entity representing an eks managed nodegroup
+type EKSNodegroup struct {
+	Entity
+	cluster string
+}
+
+// GetID returns the cluster and nodegroup name as cluster/nodegroup
+func (e *EKSNodegroup) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *EKSNodegroup) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/eks/home?region=%s#/clusters/%s/nodegroups/%s"
+	return fmt.Sprintf(t, e.Region, e.Region, e.cluster, e.Name)
+}
+
+// NewEKSNodegroup returns a new eks nodegroup entity
+func NewEKSNodegroup(nodegroup *eks.Nodegroup, region string) *EKSNodegroup {
+	entity := &EKSNodegroup{
+		Entity: NewEntity(),
+	}
+	if nodegroup == nil {
+		return entity
+	}
+
+	entity.Region = region
+	entity.cluster = aws.StringValue(nodegroup.ClusterName)
+	if nodegroup.NodegroupName != nil {
+		entity.ID = fmt.Sprintf("%s/%s", entity.cluster, *nodegroup.NodegroupName)
+		entity.Name = *nodegroup.NodegroupName
+	}
+
+	for key, value := range nodegroup.Tags {
+		entity.AddTag(aws.String(key), value)
+	}
+	endOfLife := EKSVersionEndOfLife(aws.StringValue(nodegroup.Version), time.Now())
+	entity.
+		AddLabel(labelARN, nodegroup.NodegroupArn).
+		AddLabel(eksLabelCluster, nodegroup.ClusterName).
+		AddLabel(eksLabelVersion, nodegroup.Version).
+		AddBoolLabel(eksLabelVersionEndOfLife, &endOfLife).
+		AddLabel(eksLabelStatus, nodegroup.Status).
+		AddLabel(eksLabelAMIType, nodegroup.AmiType).
+		AddCreatedAt(nodegroup.CreatedAt)
+	if nodegroup.ScalingConfig != nil {
+		entity.AddInt64Label(eksLabelDesiredSize, nodegroup.ScalingConfig.DesiredSize)
+	}
+
+	return entity
+}
+
+func listEKSNodegroups(ctx context.Context, svc eksiface.EKSAPI, cluster *string) ([]*string, error) {
+	var nodegroups []*string
+	input := &eks.ListNodegroupsInput{ClusterName: cluster}
+	err := svc.ListNodegroupsPagesWithContext(ctx, input, func(output *eks.ListNodegroupsOutput, lastPage bool) bool {
+		nodegroups = append(nodegroups, output.Nodegroups...)
+		return true
+	})
+	return nodegroups, errors.Wrapf(err, "could not list nodegroups of eks cluster %s", aws.StringValue(cluster))
+}
+
+// walkEKSClusters calls f with every eks cluster and the names of its nodegroups
+func walkEKSClusters(ctx context.Context, svc eksiface.EKSAPI, f func(cluster *eks.Cluster, nodegroups []*string)) error {
+	var names []*string
+	err := svc.ListClustersPagesWithContext(ctx, &eks.ListClustersInput{}, func(output *eks.ListClustersOutput, lastPage bool) bool {
+		names = append(names, output.Clusters...)
+		return true
+	})
+	if err != nil {
+		return errors.Wrap(err, "could not list eks clusters")
+	}
+
+	var errs *multierror.Error
+	for _, name := range names {
+		output, err := svc.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: name})
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not describe eks cluster %s", aws.StringValue(name)))
+			continue
+		}
+		nodegroups, err := listEKSNodegroups(ctx, svc, name)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			continue
+		}
+		f(output.Cluster, nodegroups)
+	}
+	return errs.ErrorOrNil()
+}
+
+// EvalEKSCluster walks through all eks clusters
+func (c *Client) EvalEKSCluster(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := eks.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		err := walkEKSClusters(ctx, svc, func(cluster *eks.Cluster, nodegroups []*string) {
+			e := NewEKSCluster(cluster, int64(len(nodegroups)), region)
+			if p.Match(e) {
+				violation := policy.NewViolation(p, e, p.Expired(e), account)
+				f(violation)
+			}
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+// EvalEKSNodegroup walks through all managed nodegroups in all eks clusters
+func (c *Client) EvalEKSNodegroup(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := eks.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		err := walkEKSClusters(ctx, svc, func(cluster *eks.Cluster, nodegroups []*string) {
+			for _, name := range nodegroups {
+				input := &eks.DescribeNodegroupInput{ClusterName: cluster.Name, NodegroupName: name}
+				output, err := svc.DescribeNodegroupWithContext(ctx, input)
+				if err != nil {
+					errs = multierror.Append(errs, errors.Wrapf(err, "could not describe nodegroup %s", aws.StringValue(name)))
+					continue
+				}
+				e := NewEKSNodegroup(output.Nodegroup, region)
+				if p.Match(e) {
+					violation := policy.NewViolation(p, e, p.Expired(e), account)
+					f(violation)
+				}
+			}
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getEKSCluster(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := eks.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	output, err := svc.DescribeClusterWithContext(ctx, &eks.DescribeClusterInput{Name: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe eks cluster %s", id)
+	}
+	nodegroups, err := listEKSNodegroups(ctx, svc, &id)
+	if err != nil {
+		return nil, err
+	}
+	return NewEKSCluster(output.Cluster, int64(len(nodegroups)), region), nil
+}
+
+// getEKSNodegroup fetches a nodegroup identified as cluster/nodegroup
+func (c *Client) getEKSNodegroup(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	parts := strings.SplitN(id, "/", 2)
+	if len(parts) != 2 {
+		return nil, errors.Errorf("eks nodegroups are identified as cluster/nodegroup, not %s", id)
+	}
+	svc := eks.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	input := &eks.DescribeNodegroupInput{ClusterName: &parts[0], NodegroupName: &parts[1]}
+	output, err := svc.DescribeNodegroupWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe nodegroup %s", id)
+	}
+	return NewEKSNodegroup(output.Nodegroup, region), nil
+}
@@ -0,0 +1,71 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/eks"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestEKSVersionEndOfLife(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.True(reaperAws.EKSVersionEndOfLife("1.14", now))
+	a.False(reaperAws.EKSVersionEndOfLife("1.21", now))
+	a.False(reaperAws.EKSVersionEndOfLife("2.0", now))
+}
+
+func TestNewEKSCluster(t *testing.T) {
+	a := assert.New(t)
+	created := time.Date(2020, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	cluster := reaperAws.NewEKSCluster(&eks.Cluster{
+		Name:               aws.String("main"),
+		Arn:                aws.String("arn:aws:eks:us-west-2:123:cluster/main"),
+		Version:            aws.String("1.14"),
+		Status:             aws.String(eks.ClusterStatusActive),
+		CreatedAt:          &created,
+		Tags:               map[string]*string{"team": aws.String("infra")},
+		ResourcesVpcConfig: &eks.VpcConfigResponse{EndpointPublicAccess: aws.Bool(true)},
+	}, 2, "us-west-2")
+	a.Equal("main", cluster.GetID())
+	a.Equal("infra", cluster.GetTags()["team"])
+	a.Equal(created, *cluster.GetCreatedAt())
+	labels := cluster.GetLabels()
+	a.Equal("1.14", labels["version"])
+	a.Equal("true", labels["version_end_of_life"])
+	a.Equal(eks.ClusterStatusActive, labels["status"])
+	a.Equal("true", labels["endpoint_public_access"])
+	a.Equal("2", labels["nodegroup_count"])
+
+	cluster = reaperAws.NewEKSCluster(&eks.Cluster{Name: aws.String("new"), Version: aws.String("9.99")}, 0, "us-west-2")
+	labels = cluster.GetLabels()
+	a.Equal("0", labels["nodegroup_count"])
+	a.NotContains(labels, "version_end_of_life")
+	a.NotContains(labels, "endpoint_public_access")
+}
+
+func TestNewEKSNodegroup(t *testing.T) {
+	a := assert.New(t)
+
+	nodegroup := reaperAws.NewEKSNodegroup(&eks.Nodegroup{
+		ClusterName:   aws.String("main"),
+		NodegroupName: aws.String("workers"),
+		Version:       aws.String("1.14"),
+		Status:        aws.String(eks.NodegroupStatusActive),
+		AmiType:       aws.String(eks.AMITypesAl2X8664),
+		ScalingConfig: &eks.NodegroupScalingConfig{DesiredSize: aws.Int64(3)},
+	}, "us-west-2")
+	a.Equal("main/workers", nodegroup.GetID())
+	a.Equal("workers", nodegroup.GetName())
+	labels := nodegroup.GetLabels()
+	a.Equal("main", labels["cluster"])
+	a.Equal("true", labels["version_end_of_life"])
+	a.Equal(eks.AMITypesAl2X8664, labels["ami_type"])
+	a.Equal("3", labels["desired_size"])
+	a.Contains(nodegroup.GetConsoleURL(), "/clusters/main/nodegroups/workers")
+}
@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// load balancer labels, shared by elb_classic, alb and nlb
+const (
+	loadBalancerLabelScheme                 TypeEntityLabel = "scheme"
+	loadBalancerLabelIsInternetFacing       TypeEntityLabel = "is_internet_facing"
+	loadBalancerLabelVpcID                  TypeEntityLabel = "vpc_id"
+	loadBalancerLabelListenerCount          TypeEntityLabel = "listener_count"
+	loadBalancerLabelRegisteredTargetCount  TypeEntityLabel = "registered_target_count"
+	loadBalancerLabelHealthyTargetCount     TypeEntityLabel = "healthy_target_count"
+	loadBalancerLabelPeakHealthyTargetCount TypeEntityLabel = "peak_healthy_target_count"
+	loadBalancerLabelAccessLogging          TypeEntityLabel = "access_logging"
+)
+
+const loadBalancerSchemeInternetFacing = "internet-facing"
+
+// ELBClassic is an evaluation entity representing a classic load balancer
+type ELBClassic struct {
+	Entity
+	svc elbiface.ELBAPI
+}
+
+// GetID returns the load balancer name
+func (e *ELBClassic) GetID() string {
+	return e.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (e *ELBClassic) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#LoadBalancers:search=%s"
+	return fmt.Sprintf(t, e.Region, e.Region, e.ID)
+}
+
+// elbClassicDetails are looked up separately from the load balancer description
+type elbClassicDetails struct {
+	tags                   []*elb.Tag
+	accessLogging          bool
+	healthyTargetCount     int64
+	peakHealthyTargetCount float64
+}
+
+// NewELBClassic returns a new classic load balancer entity
+func NewELBClassic(lb *elb.LoadBalancerDescription, details *elbClassicDetails, region string, svc elbiface.ELBAPI) *ELBClassic {
+	entity := &ELBClassic{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if lb == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if lb.LoadBalancerName != nil {
+		entity.ID = *lb.LoadBalancerName
+		entity.Name = *lb.LoadBalancerName
+	}
+
+	for _, tag := range details.tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	internetFacing := aws.StringValue(lb.Scheme) == loadBalancerSchemeInternetFacing
+	listenerCount := int64(len(lb.ListenerDescriptions))
+	registeredTargetCount := int64(len(lb.Instances))
+	entity.
+		AddLabel(loadBalancerLabelScheme, lb.Scheme).
+		AddBoolLabel(loadBalancerLabelIsInternetFacing, &internetFacing).
+		AddLabel(loadBalancerLabelVpcID, lb.VPCId).
+		AddInt64Label(loadBalancerLabelListenerCount, &listenerCount).
+		AddInt64Label(loadBalancerLabelRegisteredTargetCount, &registeredTargetCount).
+		AddInt64Label(loadBalancerLabelHealthyTargetCount, &details.healthyTargetCount).
+		AddLabel(loadBalancerLabelPeakHealthyTargetCount, formatMetric(details.peakHealthyTargetCount)).
+		AddBoolLabel(loadBalancerLabelAccessLogging, &details.accessLogging).
+		AddCreatedAt(lb.CreatedTime)
+
+	return entity
+}
+
+// Delete deletes the load balancer
+func (e *ELBClassic) Delete() error {
+	log.Warnf("Deleting classic load balancer %s", e.ID)
+	_, err := e.svc.DeleteLoadBalancerWithContext(context.Background(), &elb.DeleteLoadBalancerInput{LoadBalancerName: &e.ID})
+	return errors.Wrapf(err, "could not delete classic load balancer %s", e.ID)
+}
+
+func getELBClassicDetails(ctx context.Context, svc elbiface.ELBAPI, cw cloudwatchiface.CloudWatchAPI, lb *elb.LoadBalancerDescription, lookback time.Duration) (*elbClassicDetails, error) {
+	details := &elbClassicDetails{}
+	name := lb.LoadBalancerName
+
+	tags, err := svc.DescribeTagsWithContext(ctx, &elb.DescribeTagsInput{LoadBalancerNames: []*string{name}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe tags of %s", aws.StringValue(name))
+	}
+	for _, description := range tags.TagDescriptions {
+		details.tags = append(details.tags, description.Tags...)
+	}
+
+	attributes, err := svc.DescribeLoadBalancerAttributesWithContext(ctx, &elb.DescribeLoadBalancerAttributesInput{LoadBalancerName: name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe attributes of %s", aws.StringValue(name))
+	}
+	if attributes.LoadBalancerAttributes != nil && attributes.LoadBalancerAttributes.AccessLog != nil {
+		details.accessLogging = aws.BoolValue(attributes.LoadBalancerAttributes.AccessLog.Enabled)
+	}
+
+	health, err := svc.DescribeInstanceHealthWithContext(ctx, &elb.DescribeInstanceHealthInput{LoadBalancerName: name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe instance health of %s", aws.StringValue(name))
+	}
+	for _, state := range health.InstanceStates {
+		if aws.StringValue(state.State) == "InService" {
+			details.healthyTargetCount++
+		}
+	}
+
+	details.peakHealthyTargetCount, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/ELB",
+		Metric:     "HealthyHostCount",
+		Dimensions: map[string]string{"LoadBalancerName": aws.StringValue(name)},
+		Statistic:  cloudwatch.StatisticMaximum,
+	}, lookback)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get healthy host count of %s", aws.StringValue(name))
+	}
+	return details, nil
+}
+
+// DescribeELBClassic looks up the tags, attributes and instance health of a classic load balancer,
+// and its peak healthy target count over the lookback period
+func DescribeELBClassic(ctx context.Context, svc elbiface.ELBAPI, cw cloudwatchiface.CloudWatchAPI, lb *elb.LoadBalancerDescription, region string, lookback time.Duration) (*ELBClassic, error) {
+	details, err := getELBClassicDetails(ctx, svc, cw, lb, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewELBClassic(lb, details, region, svc), nil
+}
+
+// EvalELBClassic walks through all classic load balancers
+func (c *Client) EvalELBClassic(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+		svc := elb.New(sess, conf)
+		cw := cloudwatch.New(sess, conf)
+		input := &elb.DescribeLoadBalancersInput{}
+		err := svc.DescribeLoadBalancersPagesWithContext(ctx, input, func(output *elb.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range output.LoadBalancerDescriptions {
+				e, err := DescribeELBClassic(ctx, svc, cw, lb, region, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if p.Match(e) {
+					violation := policy.NewViolation(p, e, p.Expired(e), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getELBClassic(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+	svc := elb.New(sess, conf)
+	input := &elb.DescribeLoadBalancersInput{LoadBalancerNames: aws.StringSlice([]string{id})}
+	output, err := svc.DescribeLoadBalancersWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe classic load balancer %s", id)
+	}
+	for _, lb := range output.LoadBalancerDescriptions {
+		return DescribeELBClassic(ctx, svc, cloudwatch.New(sess, conf), lb, region, lookback)
+	}
+	return nil, errors.Errorf("classic load balancer %s not found in %s", id, region)
+}
@@ -0,0 +1,75 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/elb"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeELBClassic(t *testing.T) {
+	a := assert.New(t)
+	lookback := 7 * 24 * time.Hour
+	lb := &elb.LoadBalancerDescription{
+		LoadBalancerName:     aws.String("legacy"),
+		Scheme:               aws.String("internet-facing"),
+		VPCId:                aws.String("vpc-1"),
+		ListenerDescriptions: []*elb.ListenerDescription{{}},
+		Instances:            []*elb.Instance{{InstanceId: aws.String("i-1")}, {InstanceId: aws.String("i-2")}},
+	}
+
+	svc := &fakeELB{
+		tags:          []*elb.Tag{{Key: aws.String("owner"), Value: aws.String("web@example.com")}},
+		accessLogging: true,
+		instanceStates: []*elb.InstanceState{
+			{InstanceId: aws.String("i-1"), State: aws.String("InService")},
+			{InstanceId: aws.String("i-2"), State: aws.String("OutOfService")},
+		},
+	}
+	cw := &fakeCloudWatch{datapoints: map[string][]*cloudwatch.Datapoint{
+		"HealthyHostCount": {{Maximum: aws.Float64(2)}},
+	}}
+	e, err := reaperAws.DescribeELBClassic(context.Background(), svc, cw, lb, "us-west-2", lookback)
+	a.NoError(err)
+	a.Equal("legacy", e.GetID())
+	a.Equal("web@example.com", e.GetTags()["owner"])
+	labels := e.GetLabels()
+	a.Equal("internet-facing", labels["scheme"])
+	a.Equal("true", labels["is_internet_facing"])
+	a.Equal("vpc-1", labels["vpc_id"])
+	a.Equal("1", labels["listener_count"])
+	a.Equal("2", labels["registered_target_count"])
+	a.Equal("1", labels["healthy_target_count"])
+	a.Equal("2", labels["peak_healthy_target_count"])
+	a.Equal("true", labels["access_logging"])
+	a.Len(cw.queries, 1)
+	a.Equal("AWS/ELB", aws.StringValue(cw.queries[0].Namespace))
+	a.Equal(lookback, cw.queries[0].EndTime.Sub(*cw.queries[0].StartTime))
+
+	// no instances and nothing healthy over the lookback period
+	lb.Scheme = aws.String("internal")
+	lb.Instances = nil
+	e, err = reaperAws.DescribeELBClassic(context.Background(), &fakeELB{}, &fakeCloudWatch{}, lb, "us-west-2", lookback)
+	a.NoError(err)
+	labels = e.GetLabels()
+	a.Equal("internal", labels["scheme"])
+	a.NotContains(labels, "is_internet_facing")
+	a.NotContains(labels, "access_logging")
+	a.Equal("0", labels["registered_target_count"])
+	a.Equal("0", labels["healthy_target_count"])
+	a.Equal("0", labels["peak_healthy_target_count"])
+}
+
+func TestELBClassicDelete(t *testing.T) {
+	a := assert.New(t)
+	svc := &fakeELB{}
+	e, err := reaperAws.DescribeELBClassic(context.Background(), svc, &fakeCloudWatch{}, &elb.LoadBalancerDescription{LoadBalancerName: aws.String("legacy")}, "us-west-2", time.Hour)
+	a.NoError(err)
+	a.NoError(e.Delete())
+	a.Equal([]string{"delete legacy"}, svc.calls)
+}
@@ -0,0 +1,263 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// alb and nlb specific labels
+const (
+	loadBalancerLabelState              TypeEntityLabel = "state"
+	loadBalancerLabelTargetGroupCount   TypeEntityLabel = "target_group_count"
+	loadBalancerLabelDeletionProtection TypeEntityLabel = "deletion_protection"
+)
+
+// elbv2MetricNamespaces maps from the load balancer type to its CloudWatch namespace
+var elbv2MetricNamespaces = map[string]string{
+	elbv2.LoadBalancerTypeEnumApplication: "AWS/ApplicationELB",
+	elbv2.LoadBalancerTypeEnumNetwork:     "AWS/NetworkELB",
+}
+
+// LoadBalancerV2 is an evaluation entity representing an application or network load balancer
+type LoadBalancerV2 struct {
+	Entity
+	arn                string
+	deletionProtection bool
+	svc                elbv2iface.ELBV2API
+}
+
+// GetID returns the load balancer name
+func (l *LoadBalancerV2) GetID() string {
+	return l.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (l *LoadBalancerV2) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/ec2/v2/home?&region=%s#LoadBalancers:search=%s"
+	return fmt.Sprintf(t, l.Region, l.Region, l.ID)
+}
+
+// loadBalancerV2Details are looked up separately from the load balancer description
+type loadBalancerV2Details struct {
+	tags                   []*elbv2.Tag
+	accessLogging          bool
+	deletionProtection     bool
+	listenerCount          int64
+	targetGroupCount       int64
+	registeredTargetCount  int64
+	healthyTargetCount     int64
+	peakHealthyTargetCount float64
+}
+
+// NewLoadBalancerV2 returns a new application or network load balancer entity
+func NewLoadBalancerV2(lb *elbv2.LoadBalancer, details *loadBalancerV2Details, region string, svc elbv2iface.ELBV2API) *LoadBalancerV2 {
+	entity := &LoadBalancerV2{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if lb == nil {
+		return entity
+	}
+
+	entity.Region = region
+	entity.arn = aws.StringValue(lb.LoadBalancerArn)
+	if lb.LoadBalancerName != nil {
+		entity.ID = *lb.LoadBalancerName
+		entity.Name = *lb.LoadBalancerName
+	}
+	entity.deletionProtection = details.deletionProtection
+
+	for _, tag := range details.tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	internetFacing := aws.StringValue(lb.Scheme) == loadBalancerSchemeInternetFacing
+	entity.
+		AddLabel(labelARN, lb.LoadBalancerArn).
+		AddLabel(loadBalancerLabelScheme, lb.Scheme).
+		AddBoolLabel(loadBalancerLabelIsInternetFacing, &internetFacing).
+		AddLabel(loadBalancerLabelVpcID, lb.VpcId).
+		AddInt64Label(loadBalancerLabelListenerCount, &details.listenerCount).
+		AddInt64Label(loadBalancerLabelTargetGroupCount, &details.targetGroupCount).
+		AddInt64Label(loadBalancerLabelRegisteredTargetCount, &details.registeredTargetCount).
+		AddInt64Label(loadBalancerLabelHealthyTargetCount, &details.healthyTargetCount).
+		AddLabel(loadBalancerLabelPeakHealthyTargetCount, formatMetric(details.peakHealthyTargetCount)).
+		AddBoolLabel(loadBalancerLabelAccessLogging, &details.accessLogging).
+		AddBoolLabel(loadBalancerLabelDeletionProtection, &details.deletionProtection).
+		AddCreatedAt(lb.CreatedTime)
+	if lb.State != nil {
+		entity.AddLabel(loadBalancerLabelState, lb.State.Code)
+	}
+
+	return entity
+}
+
+// Delete deletes the load balancer. Its target groups are left in place.
+func (l *LoadBalancerV2) Delete() error {
+	if l.deletionProtection {
+		return errors.Errorf("load balancer %s has deletion protection enabled", l.ID)
+	}
+	log.Warnf("Deleting load balancer %s", l.ID)
+	_, err := l.svc.DeleteLoadBalancerWithContext(context.Background(), &elbv2.DeleteLoadBalancerInput{LoadBalancerArn: &l.arn})
+	return errors.Wrapf(err, "could not delete load balancer %s", l.ID)
+}
+
+// elbv2MetricDimension returns the CloudWatch dimension for a load balancer or target group arn,
+// which is the part after the resource type, e.g. targetgroup/name/1234
+func elbv2MetricDimension(arn string) string {
+	i := strings.Index(arn, ":loadbalancer/")
+	if i >= 0 {
+		return arn[i+len(":loadbalancer/"):]
+	}
+	i = strings.Index(arn, ":targetgroup/")
+	if i >= 0 {
+		return arn[i+1:]
+	}
+	return arn
+}
+
+func getLoadBalancerV2Details(ctx context.Context, svc elbv2iface.ELBV2API, cw cloudwatchiface.CloudWatchAPI, lb *elbv2.LoadBalancer, lookback time.Duration) (*loadBalancerV2Details, error) {
+	details := &loadBalancerV2Details{}
+	arn := lb.LoadBalancerArn
+	name := aws.StringValue(lb.LoadBalancerName)
+
+	tags, err := svc.DescribeTagsWithContext(ctx, &elbv2.DescribeTagsInput{ResourceArns: []*string{arn}})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe tags of %s", name)
+	}
+	for _, description := range tags.TagDescriptions {
+		details.tags = append(details.tags, description.Tags...)
+	}
+
+	attributes, err := svc.DescribeLoadBalancerAttributesWithContext(ctx, &elbv2.DescribeLoadBalancerAttributesInput{LoadBalancerArn: arn})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe attributes of %s", name)
+	}
+	for _, attribute := range attributes.Attributes {
+		switch aws.StringValue(attribute.Key) {
+		case "access_logs.s3.enabled":
+			details.accessLogging = aws.StringValue(attribute.Value) == "true"
+		case "deletion_protection.enabled":
+			details.deletionProtection = aws.StringValue(attribute.Value) == "true"
+		}
+	}
+
+	err = svc.DescribeListenersPagesWithContext(ctx, &elbv2.DescribeListenersInput{LoadBalancerArn: arn}, func(output *elbv2.DescribeListenersOutput, lastPage bool) bool {
+		details.listenerCount += int64(len(output.Listeners))
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe listeners of %s", name)
+	}
+
+	var targetGroups []*elbv2.TargetGroup
+	err = svc.DescribeTargetGroupsPagesWithContext(ctx, &elbv2.DescribeTargetGroupsInput{LoadBalancerArn: arn}, func(output *elbv2.DescribeTargetGroupsOutput, lastPage bool) bool {
+		targetGroups = append(targetGroups, output.TargetGroups...)
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe target groups of %s", name)
+	}
+	details.targetGroupCount = int64(len(targetGroups))
+
+	for _, targetGroup := range targetGroups {
+		health, err := svc.DescribeTargetHealthWithContext(ctx, &elbv2.DescribeTargetHealthInput{TargetGroupArn: targetGroup.TargetGroupArn})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not describe target health of %s", aws.StringValue(targetGroup.TargetGroupName))
+		}
+		for _, description := range health.TargetHealthDescriptions {
+			details.registeredTargetCount++
+			if description.TargetHealth != nil && aws.StringValue(description.TargetHealth.State) == elbv2.TargetHealthStateEnumHealthy {
+				details.healthyTargetCount++
+			}
+		}
+
+		// the peak across target groups is approximated by adding up each target group's peak
+		peak, err := getMetric(ctx, cw, metricQuery{
+			Namespace: elbv2MetricNamespaces[aws.StringValue(lb.Type)],
+			Metric:    "HealthyHostCount",
+			Dimensions: map[string]string{
+				"LoadBalancer": elbv2MetricDimension(aws.StringValue(arn)),
+				"TargetGroup":  elbv2MetricDimension(aws.StringValue(targetGroup.TargetGroupArn)),
+			},
+			Statistic: cloudwatch.StatisticMaximum,
+		}, lookback)
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get healthy host count of %s", name)
+		}
+		details.peakHealthyTargetCount += peak
+	}
+	return details, nil
+}
+
+// DescribeLoadBalancerV2 looks up the tags, attributes, listeners and targets of a load balancer,
+// and its peak healthy target count over the lookback period
+func DescribeLoadBalancerV2(ctx context.Context, svc elbv2iface.ELBV2API, cw cloudwatchiface.CloudWatchAPI, lb *elbv2.LoadBalancer, region string, lookback time.Duration) (*LoadBalancerV2, error) {
+	details, err := getLoadBalancerV2Details(ctx, svc, cw, lb, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewLoadBalancerV2(lb, details, region, svc), nil
+}
+
+// EvalLoadBalancerV2 walks through all load balancers of lbType, one of
+// elbv2.LoadBalancerTypeEnumApplication or elbv2.LoadBalancerTypeEnumNetwork
+func (c *Client) EvalLoadBalancerV2(accounts []*policy.Account, p policy.Policy, regions []string, lbType string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+		svc := elbv2.New(sess, conf)
+		cw := cloudwatch.New(sess, conf)
+		input := &elbv2.DescribeLoadBalancersInput{}
+		err := svc.DescribeLoadBalancersPagesWithContext(ctx, input, func(output *elbv2.DescribeLoadBalancersOutput, lastPage bool) bool {
+			for _, lb := range output.LoadBalancers {
+				if aws.StringValue(lb.Type) != lbType {
+					continue
+				}
+				l, err := DescribeLoadBalancerV2(ctx, svc, cw, lb, region, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if p.Match(l) {
+					violation := policy.NewViolation(p, l, p.Expired(l), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getLoadBalancerV2(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+	svc := elbv2.New(sess, conf)
+	input := &elbv2.DescribeLoadBalancersInput{Names: aws.StringSlice([]string{id})}
+	output, err := svc.DescribeLoadBalancersWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe load balancer %s", id)
+	}
+	for _, lb := range output.LoadBalancers {
+		return DescribeLoadBalancerV2(ctx, svc, cloudwatch.New(sess, conf), lb, region, lookback)
+	}
+	return nil, errors.Errorf("load balancer %s not found in %s", id, region)
+}
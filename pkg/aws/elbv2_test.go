@@ -0,0 +1,158 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+const (
+	testLoadBalancerARN = "arn:aws:elasticloadbalancing:us-west-2:123456789012:loadbalancer/app/web/1234"
+	testTargetGroupA    = "arn:aws:elasticloadbalancing:us-west-2:123456789012:targetgroup/a/1"
+	testTargetGroupB    = "arn:aws:elasticloadbalancing:us-west-2:123456789012:targetgroup/b/2"
+)
+
+func testLoadBalancerV2(scheme string) *elbv2.LoadBalancer {
+	return &elbv2.LoadBalancer{
+		LoadBalancerArn:  aws.String(testLoadBalancerARN),
+		LoadBalancerName: aws.String("web"),
+		Type:             aws.String(elbv2.LoadBalancerTypeEnumApplication),
+		Scheme:           aws.String(scheme),
+		VpcId:            aws.String("vpc-1"),
+		State:            &elbv2.LoadBalancerState{Code: aws.String(elbv2.LoadBalancerStateEnumActive)},
+	}
+}
+
+// targetHealth returns the health of a target in each of states
+func targetHealth(states ...string) []*elbv2.TargetHealthDescription {
+	var res []*elbv2.TargetHealthDescription
+	for _, state := range states {
+		res = append(res, &elbv2.TargetHealthDescription{TargetHealth: &elbv2.TargetHealth{State: aws.String(state)}})
+	}
+	return res
+}
+
+func TestDescribeLoadBalancerV2(t *testing.T) {
+	a := assert.New(t)
+	lookback := 7 * 24 * time.Hour
+
+	svc := &fakeELBV2{
+		tags: []*elbv2.Tag{{Key: aws.String("owner"), Value: aws.String("web@example.com")}},
+		attributes: map[string]string{
+			"access_logs.s3.enabled":      "true",
+			"deletion_protection.enabled": "false",
+		},
+		listeners: []*elbv2.Listener{{}, {}},
+		targetGroups: []*elbv2.TargetGroup{
+			{TargetGroupArn: aws.String(testTargetGroupA), TargetGroupName: aws.String("a")},
+			{TargetGroupArn: aws.String(testTargetGroupB), TargetGroupName: aws.String("b")},
+		},
+		targetHealth: map[string][]*elbv2.TargetHealthDescription{
+			testTargetGroupA: targetHealth(elbv2.TargetHealthStateEnumHealthy, elbv2.TargetHealthStateEnumUnhealthy),
+			testTargetGroupB: targetHealth(elbv2.TargetHealthStateEnumHealthy),
+		},
+	}
+	cw := &fakeCloudWatch{datapoints: map[string][]*cloudwatch.Datapoint{
+		"HealthyHostCount": {{Maximum: aws.Float64(1)}, {Maximum: aws.Float64(3)}},
+	}}
+	lb, err := reaperAws.DescribeLoadBalancerV2(context.Background(), svc, cw, testLoadBalancerV2("internet-facing"), "us-west-2", lookback)
+	a.NoError(err)
+	a.Equal("web", lb.GetID())
+	a.Equal("web@example.com", lb.GetTags()["owner"])
+	labels := lb.GetLabels()
+	a.Equal("internet-facing", labels["scheme"])
+	a.Equal("true", labels["is_internet_facing"])
+	a.Equal("vpc-1", labels["vpc_id"])
+	a.Equal("active", labels["state"])
+	a.Equal("2", labels["listener_count"])
+	a.Equal("2", labels["target_group_count"])
+	a.Equal("3", labels["registered_target_count"])
+	a.Equal("2", labels["healthy_target_count"])
+	// the peaks of both target groups added up
+	a.Equal("6", labels["peak_healthy_target_count"])
+	a.Equal("true", labels["access_logging"])
+	a.NotContains(labels, "deletion_protection")
+
+	// one HealthyHostCount query per target group, over the lookback period
+	a.Len(cw.queries, 2)
+	for i, targetGroup := range []string{"targetgroup/a/1", "targetgroup/b/2"} {
+		q := cw.queries[i]
+		a.Equal("AWS/ApplicationELB", aws.StringValue(q.Namespace))
+		a.Equal("HealthyHostCount", aws.StringValue(q.MetricName))
+		a.Equal(lookback, q.EndTime.Sub(*q.StartTime))
+		dimensions := map[string]string{}
+		for _, d := range q.Dimensions {
+			dimensions[aws.StringValue(d.Name)] = aws.StringValue(d.Value)
+		}
+		a.Equal(map[string]string{"LoadBalancer": "app/web/1234", "TargetGroup": targetGroup}, dimensions)
+	}
+}
+
+func TestDescribeLoadBalancerV2WithoutTargets(t *testing.T) {
+	a := assert.New(t)
+	lookback := 14 * 24 * time.Hour
+
+	// targets were healthy earlier in the lookback period, none are now
+	svc := &fakeELBV2{
+		targetGroups: []*elbv2.TargetGroup{{TargetGroupArn: aws.String(testTargetGroupA), TargetGroupName: aws.String("a")}},
+		targetHealth: map[string][]*elbv2.TargetHealthDescription{
+			testTargetGroupA: targetHealth(elbv2.TargetHealthStateEnumUnhealthy),
+		},
+	}
+	cw := &fakeCloudWatch{datapoints: map[string][]*cloudwatch.Datapoint{
+		"HealthyHostCount": {{Maximum: aws.Float64(2)}},
+	}}
+	lb, err := reaperAws.DescribeLoadBalancerV2(context.Background(), svc, cw, testLoadBalancerV2("internal"), "us-west-2", lookback)
+	a.NoError(err)
+	labels := lb.GetLabels()
+	a.Equal("internal", labels["scheme"])
+	a.NotContains(labels, "is_internet_facing")
+	a.NotContains(labels, "access_logging")
+	a.Equal("1", labels["registered_target_count"])
+	a.Equal("0", labels["healthy_target_count"])
+	a.Equal("2", labels["peak_healthy_target_count"])
+	a.Len(cw.queries, 1)
+	a.Equal(lookback, cw.queries[0].EndTime.Sub(*cw.queries[0].StartTime))
+
+	// without target groups nothing was healthy over the lookback period
+	svc = &fakeELBV2{}
+	cw = &fakeCloudWatch{}
+	lb, err = reaperAws.DescribeLoadBalancerV2(context.Background(), svc, cw, testLoadBalancerV2("internal"), "us-west-2", lookback)
+	a.NoError(err)
+	labels = lb.GetLabels()
+	a.Equal("0", labels["target_group_count"])
+	a.Equal("0", labels["registered_target_count"])
+	a.Equal("0", labels["healthy_target_count"])
+	a.Equal("0", labels["peak_healthy_target_count"])
+	a.Empty(cw.queries)
+}
+
+func TestLoadBalancerV2Delete(t *testing.T) {
+	a := assert.New(t)
+
+	for _, protected := range []bool{false, true} {
+		svc := &fakeELBV2{attributes: map[string]string{"deletion_protection.enabled": "false"}}
+		if protected {
+			svc.attributes["deletion_protection.enabled"] = "true"
+		}
+		lb, err := reaperAws.DescribeLoadBalancerV2(context.Background(), svc, &fakeCloudWatch{}, testLoadBalancerV2("internal"), "us-west-2", time.Hour)
+		a.NoError(err)
+
+		err = lb.Delete()
+		if protected {
+			a.Equal("true", lb.GetLabels()["deletion_protection"])
+			a.Error(err)
+			a.Contains(err.Error(), "load balancer web has deletion protection enabled")
+			a.Empty(svc.calls)
+		} else {
+			a.NoError(err)
+			a.Equal([]string{"delete " + testLoadBalancerARN}, svc.calls)
+		}
+	}
+}
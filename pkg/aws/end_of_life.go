@@ -0,0 +1,29 @@
+package aws
+
+import (
+	"time"
+)
+
+// endOfLife maps from a version, such as a lambda runtime or kubernetes version, to the day AWS
+// ended support for it
+type endOfLife map[string]time.Time
+
+// mustParseEndOfLife parses the dates of each version, formatted as 2006-01-02
+func mustParseEndOfLife(dates map[string]string) endOfLife {
+	e := endOfLife{}
+	for version, date := range dates {
+		t, err := time.Parse("2006-01-02", date)
+		if err != nil {
+			panic(err)
+		}
+		e[version] = t
+	}
+	return e
+}
+
+// ended returns true if support for the version ended before now. Versions we have no date for
+// are still supported.
+func (e endOfLife) ended(version string, now time.Time) bool {
+	date, ok := e[version]
+	return ok && now.After(date)
+}
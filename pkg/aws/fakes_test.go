@@ -0,0 +1,530 @@
+package aws_test
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/aws/request"
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/cloudformation/cloudformationiface"
+	"github.com/aws/aws-sdk-go/service/cloudtrail"
+	"github.com/aws/aws-sdk-go/service/cloudtrail/cloudtrailiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
+	"github.com/aws/aws-sdk-go/service/elb"
+	"github.com/aws/aws-sdk-go/service/elb/elbiface"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/elbv2/elbv2iface"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+)
+
+// recorder records the calls a fake service is asked to make, in order, e.g. "delete vol-1".
+// Fakes only record calls that change something.
+type recorder struct {
+	calls []string
+}
+
+func (r *recorder) record(call string, id *string) {
+	r.calls = append(r.calls, call+" "+aws.StringValue(id))
+}
+
+// fakeCloudFormation records the stacks it is asked to delete, and describes every stack with
+// status and statusReason
+type fakeCloudFormation struct {
+	cloudformationiface.CloudFormationAPI
+	recorder
+	status       string
+	statusReason string
+}
+
+func (f *fakeCloudFormation) DeleteStackWithContext(ctx context.Context, input *cloudformation.DeleteStackInput, opts ...request.Option) (*cloudformation.DeleteStackOutput, error) {
+	f.record("delete", input.StackName)
+	return &cloudformation.DeleteStackOutput{}, nil
+}
+
+func (f *fakeCloudFormation) DescribeStacksWithContext(ctx context.Context, input *cloudformation.DescribeStacksInput, opts ...request.Option) (*cloudformation.DescribeStacksOutput, error) {
+	return &cloudformation.DescribeStacksOutput{Stacks: []*cloudformation.Stack{{
+		StackId:           input.StackName,
+		StackStatus:       aws.String(f.status),
+		StackStatusReason: aws.String(f.statusReason),
+	}}}, nil
+}
+
+// fakeCloudTrail serves the events set on it that refer to the looked up resource, or fails
+// every lookup with err
+type fakeCloudTrail struct {
+	cloudtrailiface.CloudTrailAPI
+	events []*cloudtrail.Event
+	err    error
+}
+
+func (f *fakeCloudTrail) LookupEventsPagesWithContext(ctx context.Context, input *cloudtrail.LookupEventsInput, fn func(*cloudtrail.LookupEventsOutput, bool) bool, opts ...request.Option) error {
+	if f.err != nil {
+		return f.err
+	}
+	output := &cloudtrail.LookupEventsOutput{}
+	for _, event := range f.events {
+		for _, resource := range event.Resources {
+			if aws.StringValue(resource.ResourceName) == aws.StringValue(input.LookupAttributes[0].AttributeValue) {
+				output.Events = append(output.Events, event)
+				break
+			}
+		}
+	}
+	fn(output, true)
+	return nil
+}
+
+// fakeCloudWatch serves the datapoints set on it by metric name. ListMetrics returns a metric
+// for each name that has datapoints. It keeps the statistics it was asked for in queries.
+type fakeCloudWatch struct {
+	cloudwatchiface.CloudWatchAPI
+	datapoints map[string][]*cloudwatch.Datapoint
+	queries    []*cloudwatch.GetMetricStatisticsInput
+}
+
+func (f *fakeCloudWatch) GetMetricStatisticsWithContext(ctx context.Context, input *cloudwatch.GetMetricStatisticsInput, opts ...request.Option) (*cloudwatch.GetMetricStatisticsOutput, error) {
+	f.queries = append(f.queries, input)
+	return &cloudwatch.GetMetricStatisticsOutput{Datapoints: f.datapoints[aws.StringValue(input.MetricName)]}, nil
+}
+
+func (f *fakeCloudWatch) ListMetricsPagesWithContext(ctx context.Context, input *cloudwatch.ListMetricsInput, fn func(*cloudwatch.ListMetricsOutput, bool) bool, opts ...request.Option) error {
+	output := &cloudwatch.ListMetricsOutput{}
+	if len(f.datapoints[aws.StringValue(input.MetricName)]) > 0 {
+		output.Metrics = append(output.Metrics, &cloudwatch.Metric{MetricName: input.MetricName})
+	}
+	fn(output, true)
+	return nil
+}
+
+// fakeCloudWatchLogs records the retention periods it is asked to set, e.g. "retention 90 /app/web"
+type fakeCloudWatchLogs struct {
+	cloudwatchlogsiface.CloudWatchLogsAPI
+	recorder
+}
+
+func (f *fakeCloudWatchLogs) PutRetentionPolicyWithContext(ctx context.Context, input *cloudwatchlogs.PutRetentionPolicyInput, opts ...request.Option) (*cloudwatchlogs.PutRetentionPolicyOutput, error) {
+	f.record(fmt.Sprintf("retention %d", aws.Int64Value(input.RetentionInDays)), input.LogGroupName)
+	return &cloudwatchlogs.PutRetentionPolicyOutput{}, nil
+}
+
+// fakeEC2 serves the ec2 resources set on it and records the calls made to change them.
+// Snapshots it creates complete right away.
+type fakeEC2 struct {
+	ec2iface.EC2API
+	recorder
+	enis             []*ec2.NetworkInterface
+	internetGateways []*ec2.InternetGateway
+	subnets          []*ec2.Subnet
+	routeTables      []*ec2.RouteTable
+	networkACLs      []*ec2.NetworkAcl
+	securityGroups   []*ec2.SecurityGroup
+	snapshots        []*ec2.Snapshot
+	instances        []*ec2.Instance
+	revoked          []*ec2.IpPermission
+	// vpc dependencies that block deleting it
+	vpcEndpoints               []*ec2.VpcEndpoint
+	vpnGateways                []*ec2.VpnGateway
+	egressOnlyInternetGateways []*ec2.EgressOnlyInternetGateway
+	peeringConnections         []*ec2.VpcPeeringConnection
+	securityGroupReferences    []*ec2.SecurityGroupReference
+}
+
+func (f *fakeEC2) DescribeVpcEndpointsPagesWithContext(ctx context.Context, input *ec2.DescribeVpcEndpointsInput, fn func(*ec2.DescribeVpcEndpointsOutput, bool) bool, opts ...request.Option) error {
+	fn(&ec2.DescribeVpcEndpointsOutput{VpcEndpoints: f.vpcEndpoints}, true)
+	return nil
+}
+
+func (f *fakeEC2) DescribeVpnGatewaysWithContext(ctx context.Context, input *ec2.DescribeVpnGatewaysInput, opts ...request.Option) (*ec2.DescribeVpnGatewaysOutput, error) {
+	return &ec2.DescribeVpnGatewaysOutput{VpnGateways: f.vpnGateways}, nil
+}
+
+func (f *fakeEC2) DescribeEgressOnlyInternetGatewaysPagesWithContext(ctx context.Context, input *ec2.DescribeEgressOnlyInternetGatewaysInput, fn func(*ec2.DescribeEgressOnlyInternetGatewaysOutput, bool) bool, opts ...request.Option) error {
+	fn(&ec2.DescribeEgressOnlyInternetGatewaysOutput{EgressOnlyInternetGateways: f.egressOnlyInternetGateways}, true)
+	return nil
+}
+
+func (f *fakeEC2) DescribeVpcPeeringConnectionsPagesWithContext(ctx context.Context, input *ec2.DescribeVpcPeeringConnectionsInput, fn func(*ec2.DescribeVpcPeeringConnectionsOutput, bool) bool, opts ...request.Option) error {
+	fn(&ec2.DescribeVpcPeeringConnectionsOutput{VpcPeeringConnections: f.peeringConnections}, true)
+	return nil
+}
+
+func (f *fakeEC2) DescribeSecurityGroupReferencesWithContext(ctx context.Context, input *ec2.DescribeSecurityGroupReferencesInput, opts ...request.Option) (*ec2.DescribeSecurityGroupReferencesOutput, error) {
+	return &ec2.DescribeSecurityGroupReferencesOutput{SecurityGroupReferenceSet: f.securityGroupReferences}, nil
+}
+
+func (f *fakeEC2) DescribeNetworkInterfacesPagesWithContext(ctx context.Context, input *ec2.DescribeNetworkInterfacesInput, fn func(*ec2.DescribeNetworkInterfacesOutput, bool) bool, opts ...request.Option) error {
+	fn(&ec2.DescribeNetworkInterfacesOutput{NetworkInterfaces: f.enis}, true)
+	return nil
+}
+
+func (f *fakeEC2) DescribeInternetGatewaysWithContext(ctx context.Context, input *ec2.DescribeInternetGatewaysInput, opts ...request.Option) (*ec2.DescribeInternetGatewaysOutput, error) {
+	return &ec2.DescribeInternetGatewaysOutput{InternetGateways: f.internetGateways}, nil
+}
+
+func (f *fakeEC2) DetachInternetGatewayWithContext(ctx context.Context, input *ec2.DetachInternetGatewayInput, opts ...request.Option) (*ec2.DetachInternetGatewayOutput, error) {
+	f.record("detach", input.InternetGatewayId)
+	return &ec2.DetachInternetGatewayOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteInternetGatewayWithContext(ctx context.Context, input *ec2.DeleteInternetGatewayInput, opts ...request.Option) (*ec2.DeleteInternetGatewayOutput, error) {
+	f.record("delete", input.InternetGatewayId)
+	return &ec2.DeleteInternetGatewayOutput{}, nil
+}
+
+func (f *fakeEC2) DescribeSubnetsWithContext(ctx context.Context, input *ec2.DescribeSubnetsInput, opts ...request.Option) (*ec2.DescribeSubnetsOutput, error) {
+	return &ec2.DescribeSubnetsOutput{Subnets: f.subnets}, nil
+}
+
+func (f *fakeEC2) DeleteSubnetWithContext(ctx context.Context, input *ec2.DeleteSubnetInput, opts ...request.Option) (*ec2.DeleteSubnetOutput, error) {
+	f.record("delete", input.SubnetId)
+	return &ec2.DeleteSubnetOutput{}, nil
+}
+
+// DescribeRouteTablesWithContext ignores the input's filters, tests only set the route tables they
+// expect the filters to match
+func (f *fakeEC2) DescribeRouteTablesWithContext(ctx context.Context, input *ec2.DescribeRouteTablesInput, opts ...request.Option) (*ec2.DescribeRouteTablesOutput, error) {
+	return &ec2.DescribeRouteTablesOutput{RouteTables: f.routeTables}, nil
+}
+
+func (f *fakeEC2) DeleteRouteTableWithContext(ctx context.Context, input *ec2.DeleteRouteTableInput, opts ...request.Option) (*ec2.DeleteRouteTableOutput, error) {
+	f.record("delete", input.RouteTableId)
+	return &ec2.DeleteRouteTableOutput{}, nil
+}
+
+func (f *fakeEC2) DescribeNetworkAclsWithContext(ctx context.Context, input *ec2.DescribeNetworkAclsInput, opts ...request.Option) (*ec2.DescribeNetworkAclsOutput, error) {
+	return &ec2.DescribeNetworkAclsOutput{NetworkAcls: f.networkACLs}, nil
+}
+
+func (f *fakeEC2) DescribeSecurityGroupsWithContext(ctx context.Context, input *ec2.DescribeSecurityGroupsInput, opts ...request.Option) (*ec2.DescribeSecurityGroupsOutput, error) {
+	return &ec2.DescribeSecurityGroupsOutput{SecurityGroups: f.securityGroups}, nil
+}
+
+func (f *fakeEC2) DeleteSecurityGroupWithContext(ctx context.Context, input *ec2.DeleteSecurityGroupInput, opts ...request.Option) (*ec2.DeleteSecurityGroupOutput, error) {
+	f.record("delete", input.GroupId)
+	return &ec2.DeleteSecurityGroupOutput{}, nil
+}
+
+func (f *fakeEC2) RevokeSecurityGroupIngressWithContext(ctx context.Context, input *ec2.RevokeSecurityGroupIngressInput, opts ...request.Option) (*ec2.RevokeSecurityGroupIngressOutput, error) {
+	f.revoked = append(f.revoked, input.IpPermissions...)
+	return &ec2.RevokeSecurityGroupIngressOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteNatGatewayWithContext(ctx context.Context, input *ec2.DeleteNatGatewayInput, opts ...request.Option) (*ec2.DeleteNatGatewayOutput, error) {
+	f.record("delete", input.NatGatewayId)
+	return &ec2.DeleteNatGatewayOutput{}, nil
+}
+
+func (f *fakeEC2) ReleaseAddressWithContext(ctx context.Context, input *ec2.ReleaseAddressInput, opts ...request.Option) (*ec2.ReleaseAddressOutput, error) {
+	if input.AllocationId != nil {
+		f.record("release", input.AllocationId)
+	} else {
+		f.record("release", input.PublicIp)
+	}
+	return &ec2.ReleaseAddressOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteVpcWithContext(ctx context.Context, input *ec2.DeleteVpcInput, opts ...request.Option) (*ec2.DeleteVpcOutput, error) {
+	f.record("delete", input.VpcId)
+	return &ec2.DeleteVpcOutput{}, nil
+}
+
+func (f *fakeEC2) CreateSnapshotWithContext(ctx context.Context, input *ec2.CreateSnapshotInput, opts ...request.Option) (*ec2.Snapshot, error) {
+	f.record("snapshot", input.VolumeId)
+	snapshot := &ec2.Snapshot{SnapshotId: aws.String("snap-1"), VolumeId: input.VolumeId, State: aws.String(ec2.SnapshotStateCompleted)}
+	f.snapshots = append(f.snapshots, snapshot)
+	return snapshot, nil
+}
+
+func (f *fakeEC2) DescribeSnapshotsWithContext(ctx context.Context, input *ec2.DescribeSnapshotsInput, opts ...request.Option) (*ec2.DescribeSnapshotsOutput, error) {
+	return &ec2.DescribeSnapshotsOutput{Snapshots: f.snapshots}, nil
+}
+
+func (f *fakeEC2) DeleteSnapshotWithContext(ctx context.Context, input *ec2.DeleteSnapshotInput, opts ...request.Option) (*ec2.DeleteSnapshotOutput, error) {
+	f.record("delete", input.SnapshotId)
+	return &ec2.DeleteSnapshotOutput{}, nil
+}
+
+// DescribeInstancesPagesWithContext ignores the input's filters, tests only set the instances they
+// expect the filters to match
+func (f *fakeEC2) DescribeInstancesPagesWithContext(ctx context.Context, input *ec2.DescribeInstancesInput, fn func(*ec2.DescribeInstancesOutput, bool) bool, opts ...request.Option) error {
+	fn(&ec2.DescribeInstancesOutput{Reservations: []*ec2.Reservation{{Instances: f.instances}}}, true)
+	return nil
+}
+
+func (f *fakeEC2) DeregisterImageWithContext(ctx context.Context, input *ec2.DeregisterImageInput, opts ...request.Option) (*ec2.DeregisterImageOutput, error) {
+	f.record("deregister", input.ImageId)
+	return &ec2.DeregisterImageOutput{}, nil
+}
+
+func (f *fakeEC2) CreateTagsWithContext(ctx context.Context, input *ec2.CreateTagsInput, opts ...request.Option) (*ec2.CreateTagsOutput, error) {
+	f.record("tag", input.Resources[0])
+	return &ec2.CreateTagsOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteTagsWithContext(ctx context.Context, input *ec2.DeleteTagsInput, opts ...request.Option) (*ec2.DeleteTagsOutput, error) {
+	f.record("untag", input.Resources[0])
+	return &ec2.DeleteTagsOutput{}, nil
+}
+
+func (f *fakeEC2) DeleteVolumeWithContext(ctx context.Context, input *ec2.DeleteVolumeInput, opts ...request.Option) (*ec2.DeleteVolumeOutput, error) {
+	f.record("delete", input.VolumeId)
+	return &ec2.DeleteVolumeOutput{}, nil
+}
+
+// fakeELB serves the classic load balancer details set on it and records the load balancers it
+// deletes
+type fakeELB struct {
+	elbiface.ELBAPI
+	recorder
+	tags           []*elb.Tag
+	accessLogging  bool
+	instanceStates []*elb.InstanceState
+}
+
+func (f *fakeELB) DescribeTagsWithContext(ctx context.Context, input *elb.DescribeTagsInput, opts ...request.Option) (*elb.DescribeTagsOutput, error) {
+	return &elb.DescribeTagsOutput{TagDescriptions: []*elb.TagDescription{{LoadBalancerName: input.LoadBalancerNames[0], Tags: f.tags}}}, nil
+}
+
+func (f *fakeELB) DescribeLoadBalancerAttributesWithContext(ctx context.Context, input *elb.DescribeLoadBalancerAttributesInput, opts ...request.Option) (*elb.DescribeLoadBalancerAttributesOutput, error) {
+	return &elb.DescribeLoadBalancerAttributesOutput{LoadBalancerAttributes: &elb.LoadBalancerAttributes{
+		AccessLog: &elb.AccessLog{Enabled: aws.Bool(f.accessLogging)},
+	}}, nil
+}
+
+func (f *fakeELB) DescribeInstanceHealthWithContext(ctx context.Context, input *elb.DescribeInstanceHealthInput, opts ...request.Option) (*elb.DescribeInstanceHealthOutput, error) {
+	return &elb.DescribeInstanceHealthOutput{InstanceStates: f.instanceStates}, nil
+}
+
+func (f *fakeELB) DeleteLoadBalancerWithContext(ctx context.Context, input *elb.DeleteLoadBalancerInput, opts ...request.Option) (*elb.DeleteLoadBalancerOutput, error) {
+	f.record("delete", input.LoadBalancerName)
+	return &elb.DeleteLoadBalancerOutput{}, nil
+}
+
+// fakeELBV2 serves the load balancer details set on it, the target health by target group arn,
+// and records the load balancers it deletes
+type fakeELBV2 struct {
+	elbv2iface.ELBV2API
+	recorder
+	tags         []*elbv2.Tag
+	attributes   map[string]string
+	listeners    []*elbv2.Listener
+	targetGroups []*elbv2.TargetGroup
+	targetHealth map[string][]*elbv2.TargetHealthDescription
+}
+
+func (f *fakeELBV2) DescribeTagsWithContext(ctx context.Context, input *elbv2.DescribeTagsInput, opts ...request.Option) (*elbv2.DescribeTagsOutput, error) {
+	return &elbv2.DescribeTagsOutput{TagDescriptions: []*elbv2.TagDescription{{ResourceArn: input.ResourceArns[0], Tags: f.tags}}}, nil
+}
+
+func (f *fakeELBV2) DescribeLoadBalancerAttributesWithContext(ctx context.Context, input *elbv2.DescribeLoadBalancerAttributesInput, opts ...request.Option) (*elbv2.DescribeLoadBalancerAttributesOutput, error) {
+	output := &elbv2.DescribeLoadBalancerAttributesOutput{}
+	for key, value := range f.attributes {
+		output.Attributes = append(output.Attributes, &elbv2.LoadBalancerAttribute{Key: aws.String(key), Value: aws.String(value)})
+	}
+	return output, nil
+}
+
+func (f *fakeELBV2) DescribeListenersPagesWithContext(ctx context.Context, input *elbv2.DescribeListenersInput, fn func(*elbv2.DescribeListenersOutput, bool) bool, opts ...request.Option) error {
+	fn(&elbv2.DescribeListenersOutput{Listeners: f.listeners}, true)
+	return nil
+}
+
+func (f *fakeELBV2) DescribeTargetGroupsPagesWithContext(ctx context.Context, input *elbv2.DescribeTargetGroupsInput, fn func(*elbv2.DescribeTargetGroupsOutput, bool) bool, opts ...request.Option) error {
+	fn(&elbv2.DescribeTargetGroupsOutput{TargetGroups: f.targetGroups}, true)
+	return nil
+}
+
+func (f *fakeELBV2) DescribeTargetHealthWithContext(ctx context.Context, input *elbv2.DescribeTargetHealthInput, opts ...request.Option) (*elbv2.DescribeTargetHealthOutput, error) {
+	return &elbv2.DescribeTargetHealthOutput{TargetHealthDescriptions: f.targetHealth[aws.StringValue(input.TargetGroupArn)]}, nil
+}
+
+func (f *fakeELBV2) DeleteLoadBalancerWithContext(ctx context.Context, input *elbv2.DeleteLoadBalancerInput, opts ...request.Option) (*elbv2.DeleteLoadBalancerOutput, error) {
+	f.record("delete", input.LoadBalancerArn)
+	return &elbv2.DeleteLoadBalancerOutput{}, nil
+}
+
+// fakeIAM records the calls access key remediation makes
+type fakeIAM struct {
+	iamiface.IAMAPI
+	recorder
+	// updateErr and tagErr fail UpdateAccessKey and TagUser, which are then not recorded
+	updateErr error
+	tagErr    error
+}
+
+func (f *fakeIAM) UpdateAccessKeyWithContext(ctx context.Context, input *iam.UpdateAccessKeyInput, opts ...request.Option) (*iam.UpdateAccessKeyOutput, error) {
+	if f.updateErr != nil {
+		return nil, f.updateErr
+	}
+	f.record("update", input.Status)
+	return &iam.UpdateAccessKeyOutput{}, nil
+}
+
+func (f *fakeIAM) DeleteAccessKeyWithContext(ctx context.Context, input *iam.DeleteAccessKeyInput, opts ...request.Option) (*iam.DeleteAccessKeyOutput, error) {
+	f.record("delete", input.AccessKeyId)
+	return &iam.DeleteAccessKeyOutput{}, nil
+}
+
+func (f *fakeIAM) TagUserWithContext(ctx context.Context, input *iam.TagUserInput, opts ...request.Option) (*iam.TagUserOutput, error) {
+	if f.tagErr != nil {
+		return nil, f.tagErr
+	}
+	f.record("tag", input.Tags[0].Key)
+	return &iam.TagUserOutput{}, nil
+}
+
+func (f *fakeIAM) UntagUserWithContext(ctx context.Context, input *iam.UntagUserInput, opts ...request.Option) (*iam.UntagUserOutput, error) {
+	f.record("untag", input.TagKeys[0])
+	return &iam.UntagUserOutput{}, nil
+}
+
+// fakeKMS records the calls kms key remediation makes
+type fakeKMS struct {
+	kmsiface.KMSAPI
+	recorder
+}
+
+func (f *fakeKMS) TagResourceWithContext(ctx context.Context, input *kms.TagResourceInput, opts ...request.Option) (*kms.TagResourceOutput, error) {
+	f.record("tag", input.Tags[0].TagValue)
+	return &kms.TagResourceOutput{}, nil
+}
+
+func (f *fakeKMS) ScheduleKeyDeletionWithContext(ctx context.Context, input *kms.ScheduleKeyDeletionInput, opts ...request.Option) (*kms.ScheduleKeyDeletionOutput, error) {
+	window := time.Duration(aws.Int64Value(input.PendingWindowInDays)) * 24 * time.Hour
+	f.record("schedule", aws.String(window.String()))
+	return &kms.ScheduleKeyDeletionOutput{}, nil
+}
+
+// fakeRDS serves the db instances set on it and records the calls rds remediation makes
+type fakeRDS struct {
+	rdsiface.RDSAPI
+	recorder
+	instances []*rds.DBInstance
+}
+
+func (f *fakeRDS) DescribeDBInstancesPagesWithContext(ctx context.Context, input *rds.DescribeDBInstancesInput, fn func(*rds.DescribeDBInstancesOutput, bool) bool, opts ...request.Option) error {
+	fn(&rds.DescribeDBInstancesOutput{DBInstances: f.instances}, true)
+	return nil
+}
+
+func (f *fakeRDS) StopDBInstanceWithContext(ctx context.Context, input *rds.StopDBInstanceInput, opts ...request.Option) (*rds.StopDBInstanceOutput, error) {
+	f.record("stop", input.DBInstanceIdentifier)
+	return &rds.StopDBInstanceOutput{}, nil
+}
+
+func (f *fakeRDS) DeleteDBInstanceWithContext(ctx context.Context, input *rds.DeleteDBInstanceInput, opts ...request.Option) (*rds.DeleteDBInstanceOutput, error) {
+	f.record("delete", input.DBInstanceIdentifier)
+	return &rds.DeleteDBInstanceOutput{}, nil
+}
+
+func (f *fakeRDS) StopDBClusterWithContext(ctx context.Context, input *rds.StopDBClusterInput, opts ...request.Option) (*rds.StopDBClusterOutput, error) {
+	f.record("stop", input.DBClusterIdentifier)
+	return &rds.StopDBClusterOutput{}, nil
+}
+
+func (f *fakeRDS) DeleteDBClusterWithContext(ctx context.Context, input *rds.DeleteDBClusterInput, opts ...request.Option) (*rds.DeleteDBClusterOutput, error) {
+	f.record("delete", input.DBClusterIdentifier)
+	return &rds.DeleteDBClusterOutput{}, nil
+}
+
+// fakeS3 serves the bucket settings set on it, and answers for the rest as S3 does for settings a
+// bucket doesn't have. errs fails calls by operation name, e.g. GetBucketAcl. It records the
+// object versions and buckets it deletes.
+type fakeS3 struct {
+	s3iface.S3API
+	recorder
+	grants            []*s3.Grant
+	publicAccessBlock *s3.PublicAccessBlockConfiguration
+	versions          []*s3.ObjectVersion
+	deleteMarkers     []*s3.DeleteMarkerEntry
+	versionsTruncated bool
+	errs              map[string]error
+}
+
+func (f *fakeS3) GetBucketAclWithContext(ctx context.Context, input *s3.GetBucketAclInput, opts ...request.Option) (*s3.GetBucketAclOutput, error) {
+	return &s3.GetBucketAclOutput{Grants: f.grants}, f.errs["GetBucketAcl"]
+}
+
+func (f *fakeS3) GetBucketPolicyStatusWithContext(ctx context.Context, input *s3.GetBucketPolicyStatusInput, opts ...request.Option) (*s3.GetBucketPolicyStatusOutput, error) {
+	return &s3.GetBucketPolicyStatusOutput{}, f.notConfigured("GetBucketPolicyStatus", "NoSuchBucketPolicy")
+}
+
+func (f *fakeS3) GetPublicAccessBlockWithContext(ctx context.Context, input *s3.GetPublicAccessBlockInput, opts ...request.Option) (*s3.GetPublicAccessBlockOutput, error) {
+	if f.publicAccessBlock != nil {
+		return &s3.GetPublicAccessBlockOutput{PublicAccessBlockConfiguration: f.publicAccessBlock}, f.errs["GetPublicAccessBlock"]
+	}
+	return &s3.GetPublicAccessBlockOutput{}, f.notConfigured("GetPublicAccessBlock", "NoSuchPublicAccessBlockConfiguration")
+}
+
+func (f *fakeS3) GetBucketEncryptionWithContext(ctx context.Context, input *s3.GetBucketEncryptionInput, opts ...request.Option) (*s3.GetBucketEncryptionOutput, error) {
+	return &s3.GetBucketEncryptionOutput{}, f.notConfigured("GetBucketEncryption", "ServerSideEncryptionConfigurationNotFoundError")
+}
+
+func (f *fakeS3) GetBucketVersioningWithContext(ctx context.Context, input *s3.GetBucketVersioningInput, opts ...request.Option) (*s3.GetBucketVersioningOutput, error) {
+	return &s3.GetBucketVersioningOutput{}, f.errs["GetBucketVersioning"]
+}
+
+func (f *fakeS3) GetBucketLoggingWithContext(ctx context.Context, input *s3.GetBucketLoggingInput, opts ...request.Option) (*s3.GetBucketLoggingOutput, error) {
+	return &s3.GetBucketLoggingOutput{}, f.errs["GetBucketLogging"]
+}
+
+func (f *fakeS3) GetBucketOwnershipControlsWithContext(ctx context.Context, input *s3.GetBucketOwnershipControlsInput, opts ...request.Option) (*s3.GetBucketOwnershipControlsOutput, error) {
+	return &s3.GetBucketOwnershipControlsOutput{}, f.notConfigured("GetBucketOwnershipControls", "OwnershipControlsNotFoundError")
+}
+
+func (f *fakeS3) GetBucketLifecycleConfigurationWithContext(ctx context.Context, input *s3.GetBucketLifecycleConfigurationInput, opts ...request.Option) (*s3.GetBucketLifecycleConfigurationOutput, error) {
+	return &s3.GetBucketLifecycleConfigurationOutput{}, f.notConfigured("GetBucketLifecycleConfiguration", "NoSuchLifecycleConfiguration")
+}
+
+func (f *fakeS3) GetBucketReplicationWithContext(ctx context.Context, input *s3.GetBucketReplicationInput, opts ...request.Option) (*s3.GetBucketReplicationOutput, error) {
+	return &s3.GetBucketReplicationOutput{}, f.notConfigured("GetBucketReplication", "ReplicationConfigurationNotFoundError")
+}
+
+func (f *fakeS3) ListObjectVersionsWithContext(ctx context.Context, input *s3.ListObjectVersionsInput, opts ...request.Option) (*s3.ListObjectVersionsOutput, error) {
+	return &s3.ListObjectVersionsOutput{
+		Versions:      f.versions,
+		DeleteMarkers: f.deleteMarkers,
+		IsTruncated:   aws.Bool(f.versionsTruncated),
+	}, f.errs["ListObjectVersions"]
+}
+
+func (f *fakeS3) ListObjectVersionsPagesWithContext(ctx context.Context, input *s3.ListObjectVersionsInput, fn func(*s3.ListObjectVersionsOutput, bool) bool, opts ...request.Option) error {
+	fn(&s3.ListObjectVersionsOutput{Versions: f.versions}, true)
+	return nil
+}
+
+func (f *fakeS3) DeleteObjectsWithContext(ctx context.Context, input *s3.DeleteObjectsInput, opts ...request.Option) (*s3.DeleteObjectsOutput, error) {
+	for _, object := range input.Delete.Objects {
+		f.record("delete-object", object.Key)
+	}
+	return &s3.DeleteObjectsOutput{}, nil
+}
+
+func (f *fakeS3) DeleteBucketWithContext(ctx context.Context, input *s3.DeleteBucketInput, opts ...request.Option) (*s3.DeleteBucketOutput, error) {
+	f.record("delete-bucket", input.Bucket)
+	return &s3.DeleteBucketOutput{}, nil
+}
+
+// notConfigured fails op with its set error, or otherwise with the code S3 returns for a setting
+// the bucket doesn't have
+func (f *fakeS3) notConfigured(op string, code string) error {
+	if err := f.errs[op]; err != nil {
+		return err
+	}
+	return awserr.New(code, "not configured", nil)
+}
@@ -3,21 +3,73 @@ package aws
 import (
 	"context"
 	"fmt"
+	"strings"
+	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-// IAMUser is an evaluation entity representing an ec2 instance
+// iam_user specific labels
+const (
+	iamUserLabelHasMFA                   TypeEntityLabel = "has_mfa"
+	iamUserLabelHasPassword              TypeEntityLabel = "has_password"
+	iamUserLabelConsoleWithoutMFA        TypeEntityLabel = "console_access_without_mfa"
+	iamUserLabelPasswordLastUsed         TypeEntityLabel = "password_last_used"
+	iamUserLabelPasswordAgeDays          TypeEntityLabel = "password_age_days"
+	iamUserLabelActiveAccessKeyCount     TypeEntityLabel = "active_access_key_count"
+	iamUserLabelAccessKeyLastUsed        TypeEntityLabel = "access_key_last_used"
+	iamUserLabelAccessKeyLastUsedService TypeEntityLabel = "access_key_last_used_service"
+	iamUserLabelAttachedPolicyCount      TypeEntityLabel = "attached_policy_count"
+	iamUserLabelInlinePolicyCount        TypeEntityLabel = "inline_policy_count"
+	iamUserLabelHasAdministratorAccess   TypeEntityLabel = "has_administrator_access"
+	iamUserLabelGroupCount               TypeEntityLabel = "group_count"
+	iamUserLabelNotInCredentialReport    TypeEntityLabel = "not_in_credential_report"
+)
+
+// iamUserGroupLabelPrefix prefixes the group names we set a label for, e.g. group/admins
+const iamUserGroupLabelPrefix = "group/"
+
+// label names are at most this long
+const labelNameMaxLength = 63
+
+// iamUserGroupLabel returns the label set for membership of a group. Label names are limited to
+// 63 alphanumerics, '-', '_' and '.', starting and ending with an alphanumeric, while group names
+// can also contain +=,@ and be up to 128 characters. Other characters become '_' and the name is
+// cut to fit, so e.g. ops+oncall gets group/ops_oncall. It returns false when nothing of the name
+// can be used.
+func iamUserGroupLabel(group string) (TypeEntityLabel, bool) {
+	isAlphanumeric := func(r rune) bool {
+		return (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') || (r >= '0' && r <= '9')
+	}
+	name := strings.Map(func(r rune) rune {
+		if isAlphanumeric(r) || r == '-' || r == '_' || r == '.' {
+			return r
+		}
+		return '_'
+	}, group)
+	trim := func(r rune) bool { return !isAlphanumeric(r) }
+	name = strings.TrimFunc(name, trim)
+	if len(name) > labelNameMaxLength {
+		name = strings.TrimRightFunc(name[:labelNameMaxLength], trim)
+	}
+	if name == "" {
+		return "", false
+	}
+	return TypeEntityLabel(iamUserGroupLabelPrefix + name), true
+}
+
+// IAMUser is an evaluation entity representing an iam user
 type IAMUser struct {
 	Entity
-	ID   string
-	Name string
 }
 
-// GetID returns the ec2_instance id
+// GetID returns the user name
 func (u *IAMUser) GetID() string {
 	return u.ID
 }
@@ -27,11 +79,9 @@ func (u *IAMUser) GetOwner() string {
 	return u.ID
 }
 
-// NewIAMUser returns a new ec2 instance entity
-// I don't like that I have to pass accountId and roleName all the way down here.
-func (c *Client) NewIAMUser(user *iam.User, accountID int64, roleName string, externalID string) *IAMUser {
-	ctx := context.Background()
-	t := "true"
+// NewIAMUser returns a new iam user entity. credentials is the user's row of the credential
+// report, mapping from column names to values.
+func NewIAMUser(user *iam.UserDetail, credentials map[string]string, now time.Time) *IAMUser {
 	entity := &IAMUser{
 		Entity: NewEntity(),
 	}
@@ -39,24 +89,80 @@ func (c *Client) NewIAMUser(user *iam.User, accountID int64, roleName string, ex
 		return entity
 	}
 
-	if user.UserName != nil {
-		entity.ID = *user.UserName
+	entity.ID = aws.StringValue(user.UserName)
+	entity.Name = entity.ID
+
+	for _, tag := range user.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
 	}
 
-	client := c.Get(accountID, roleName, externalID, DefaultRegion)
-	_, e := client.IAM.GetAnMFASerial(ctx, user.UserName)
+	attachedPolicyCount := int64(len(user.AttachedManagedPolicies))
+	inlinePolicyCount := int64(len(user.UserPolicyList))
+	groupCount := int64(len(user.GroupList))
+	hasAdministratorAccess := false
+	for _, p := range user.AttachedManagedPolicies {
+		if aws.StringValue(p.PolicyArn) == iamAdministratorAccessARN {
+			hasAdministratorAccess = true
+		}
+	}
+	entity.
+		AddLabel(labelARN, user.Arn).
+		AddInt64Label(iamUserLabelAttachedPolicyCount, &attachedPolicyCount).
+		AddInt64Label(iamUserLabelInlinePolicyCount, &inlinePolicyCount).
+		AddBoolLabel(iamUserLabelHasAdministratorAccess, &hasAdministratorAccess).
+		AddInt64Label(iamUserLabelGroupCount, &groupCount).
+		AddCreatedAt(user.CreateDate)
+	for _, group := range user.GroupList {
+		if label, ok := iamUserGroupLabel(aws.StringValue(group)); ok {
+			entity.AddLabel(label, aws.String("true"))
+		}
+	}
 
-	if !(e != nil && e.Error() == "No MFA serial Configured") {
-		entity.AddLabel("has_mfa", &t)
+	// the report is generated every 4 hours, newer users aren't in it yet
+	if credentials == nil {
+		missing := true
+		entity.AddBoolLabel(iamUserLabelNotInCredentialReport, &missing)
+		return entity
 	}
 
-	login, _ := client.IAM.GetLoginProfile(ctx, *user.UserName)
-	// if e != nil {
-	// 	return errors.Wrap(e, "error fetching login profile")
-	// }
+	hasMFA := credentials["mfa_active"] == "true"
+	hasPassword := credentials["password_enabled"] == "true"
+	consoleWithoutMFA := hasPassword && !hasMFA
+	entity.
+		AddBoolLabel(iamUserLabelHasMFA, &hasMFA).
+		AddBoolLabel(iamUserLabelHasPassword, &hasPassword).
+		AddBoolLabel(iamUserLabelConsoleWithoutMFA, &consoleWithoutMFA)
+	if lastUsed := credentialReportTime(credentials, "password_last_used"); lastUsed != nil {
+		entity.AddLabel(iamUserLabelPasswordLastUsed, aws.String(lastUsed.UTC().Format("2006-01-02")))
+	}
+	if changed := credentialReportTime(credentials, "password_last_changed"); hasPassword && changed != nil {
+		ageDays := int64(now.Sub(*changed).Hours() / 24)
+		entity.AddInt64Label(iamUserLabelPasswordAgeDays, &ageDays)
+	}
 
-	if login != nil {
-		entity.AddLabel("has_password", &t)
+	// the report has columns for both of the access keys a user can have
+	var activeAccessKeyCount int64
+	var accessKeyLastUsed *time.Time
+	var accessKeyLastUsedService string
+	for _, key := range []string{"access_key_1", "access_key_2"} {
+		if credentials[key+"_active"] != "true" {
+			continue
+		}
+		activeAccessKeyCount++
+		lastUsed := credentialReportTime(credentials, key+"_last_used_date")
+		if lastUsed != nil && (accessKeyLastUsed == nil || lastUsed.After(*accessKeyLastUsed)) {
+			accessKeyLastUsed = lastUsed
+			accessKeyLastUsedService = credentials[key+"_last_used_service"]
+		}
+	}
+	entity.AddInt64Label(iamUserLabelActiveAccessKeyCount, &activeAccessKeyCount)
+	if accessKeyLastUsed != nil {
+		entity.
+			AddLabel(iamUserLabelAccessKeyLastUsed, aws.String(accessKeyLastUsed.UTC().Format("2006-01-02"))).
+			AddLabel(iamUserLabelAccessKeyLastUsedService, &accessKeyLastUsedService)
 	}
 
 	return entity
@@ -68,7 +174,18 @@ func (u *IAMUser) GetConsoleURL() string {
 	return fmt.Sprintf(t, u.ID)
 }
 
-// EvalIAMUser walks through all ec2 instances
+// listIAMUserDetails lists the users in an account along with their groups and policies
+func listIAMUserDetails(ctx context.Context, svc iamiface.IAMAPI) ([]*iam.UserDetail, error) {
+	var users []*iam.UserDetail
+	input := &iam.GetAccountAuthorizationDetailsInput{Filter: aws.StringSlice([]string{iam.EntityTypeUser})}
+	err := svc.GetAccountAuthorizationDetailsPagesWithContext(ctx, input, func(output *iam.GetAccountAuthorizationDetailsOutput, lastPage bool) bool {
+		users = append(users, output.UserDetailList...)
+		return true
+	})
+	return users, errors.Wrap(err, "could not list iam users")
+}
+
+// EvalIAMUser walks through all iam users
 func (c *Client) EvalIAMUser(accounts []*policy.Account, p policy.Policy, regions []string) ([]policy.Violation, error) {
 	var violations []policy.Violation
 	var errs error
@@ -77,15 +194,80 @@ func (c *Client) EvalIAMUser(accounts []*policy.Account, p policy.Policy, region
 		log.Infof("Walking iam users for %s", account.Name)
 		region := DefaultRegion
 		client := c.Get(account.ID, account.Role, account.ExternalID, region)
-		err := client.IAM.ListAllUsers(ctx, func(user *iam.User) {
-			i := c.NewIAMUser(user, account.ID, account.Role, account.ExternalID)
+		report, err := getCredentialReport(ctx, client.IAM)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate iam users in %s", account.Name))
+			continue
+		}
+		users, err := listIAMUserDetails(ctx, client.IAM)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate iam users in %s", account.Name))
+			continue
+		}
+		for _, user := range users {
+			i := NewIAMUser(user, report[aws.StringValue(user.UserName)], time.Now())
 			if p.Match(i) {
-				violation := policy.NewViolation(p, i, false, account)
+				violation := policy.NewViolation(p, i, p.Expired(i), account)
 				violations = append(violations, violation)
 			}
-		})
-		errs = multierror.Append(errs, err)
-
+		}
 	}
 	return violations, errs
 }
+
+// describeIAMUser looks up a single user along with their groups and policies, in the shape
+// GetAccountAuthorizationDetails returns them
+func describeIAMUser(ctx context.Context, svc iamiface.IAMAPI, name string) (*iam.UserDetail, error) {
+	output, err := svc.GetUserWithContext(ctx, &iam.GetUserInput{UserName: &name})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get iam user %s", name)
+	}
+	user := &iam.UserDetail{
+		UserName:   output.User.UserName,
+		UserId:     output.User.UserId,
+		Arn:        output.User.Arn,
+		Path:       output.User.Path,
+		CreateDate: output.User.CreateDate,
+		Tags:       output.User.Tags,
+	}
+
+	err = svc.ListGroupsForUserPagesWithContext(ctx, &iam.ListGroupsForUserInput{UserName: &name}, func(output *iam.ListGroupsForUserOutput, lastPage bool) bool {
+		for _, group := range output.Groups {
+			user.GroupList = append(user.GroupList, group.GroupName)
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list groups of iam user %s", name)
+	}
+	err = svc.ListAttachedUserPoliciesPagesWithContext(ctx, &iam.ListAttachedUserPoliciesInput{UserName: &name}, func(output *iam.ListAttachedUserPoliciesOutput, lastPage bool) bool {
+		user.AttachedManagedPolicies = append(user.AttachedManagedPolicies, output.AttachedPolicies...)
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list attached policies of iam user %s", name)
+	}
+	err = svc.ListUserPoliciesPagesWithContext(ctx, &iam.ListUserPoliciesInput{UserName: &name}, func(output *iam.ListUserPoliciesOutput, lastPage bool) bool {
+		for _, policyName := range output.PolicyNames {
+			user.UserPolicyList = append(user.UserPolicyList, &iam.PolicyDetail{PolicyName: policyName})
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list inline policies of iam user %s", name)
+	}
+	return user, nil
+}
+
+func (c *Client) getIAMUser(ctx context.Context, account *policy.Account, name string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+	user, err := describeIAMUser(ctx, client.IAM, name)
+	if err != nil {
+		return nil, err
+	}
+	report, err := getCredentialReport(ctx, client.IAM)
+	if err != nil {
+		return nil, err
+	}
+	return NewIAMUser(user, report[name], time.Now()), nil
+}
@@ -5,20 +5,45 @@ import (
 	"fmt"
 	"time"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
-// IAMAccessKey is an evaluation entity representing an ec2 instance
+// iam_access_key specific labels
+const (
+	iamAccessKeyLabelStatus          TypeEntityLabel = "status"
+	iamAccessKeyLabelUsername        TypeEntityLabel = "username"
+	iamAccessKeyLabelAge             TypeEntityLabel = "age"
+	iamAccessKeyLabelAgeDays         TypeEntityLabel = "age_days"
+	iamAccessKeyLabelLastUsedAt      TypeEntityLabel = "last_used_at"
+	iamAccessKeyLabelLastUsedService TypeEntityLabel = "last_used_service"
+	iamAccessKeyLabelLastUsedRegion  TypeEntityLabel = "last_used_region"
+	iamAccessKeyLabelNeverUsed       TypeEntityLabel = "never_used"
+	iamAccessKeyLabelUnusedDays      TypeEntityLabel = "unused_days"
+	iamAccessKeyLabelDeactivatedAt   TypeEntityLabel = "deactivated_at"
+)
+
+// iamAccessKeyDeactivatedTag prefixes the user tag recording when reaper deactivated one of the
+// user's access keys, access keys can't be tagged themselves
+const iamAccessKeyDeactivatedTag = "reaper:deactivated:"
+
+// IAMAccessKey is an evaluation entity representing an iam access key
 type IAMAccessKey struct {
 	Entity
-	ID       string
 	UserName string
+	status   string
+	// deactivatedAt is when reaper deactivated the key, nil if it didn't or the key was
+	// activated again since
+	deactivatedAt *time.Time
+	svc           iamiface.IAMAPI
 }
 
-// GetID returns the ec2_instance id
+// GetID returns the access key id
 func (u *IAMAccessKey) GetID() string {
 	return u.ID
 }
@@ -34,28 +59,164 @@ func (u *IAMAccessKey) GetConsoleURL() string {
 	return fmt.Sprintf(t, u.UserName)
 }
 
-// NewIAMAccessKey returns a new ec2 instance entity
-func (c *Client) NewIAMAccessKey(ctx context.Context, key *iam.AccessKeyMetadata) *IAMAccessKey {
+// NewIAMAccessKey returns a new iam access key entity. userTags are the tags of the key's user.
+func NewIAMAccessKey(key *iam.AccessKeyMetadata, lastUsed *iam.AccessKeyLastUsed, userTags []*iam.Tag, now time.Time, svc iamiface.IAMAPI) *IAMAccessKey {
 	entity := &IAMAccessKey{
 		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if key == nil {
+		return entity
 	}
 
-	entity.ID = *key.AccessKeyId
-	entity.UserName = *key.UserName
+	entity.ID = aws.StringValue(key.AccessKeyId)
+	entity.UserName = aws.StringValue(key.UserName)
+	entity.status = aws.StringValue(key.Status)
 
-	entity.AddLabel("status", key.Status)
-	entity.AddLabel("username", key.UserName)
+	entity.
+		AddLabel(iamAccessKeyLabelStatus, key.Status).
+		AddLabel(iamAccessKeyLabelUsername, key.UserName)
 
 	if key.CreateDate != nil {
 		entity.AddCreatedAt(key.CreateDate)
-		age := int64(time.Since(*key.CreateDate).Seconds())
-		entity.AddInt64Label("age", &age)
-		log.Debugf("user: %s age: %d", *key.UserName, age)
+		age := int64(now.Sub(*key.CreateDate).Seconds())
+		ageDays := int64(now.Sub(*key.CreateDate).Hours() / 24)
+		entity.
+			AddInt64Label(iamAccessKeyLabelAge, &age).
+			AddInt64Label(iamAccessKeyLabelAgeDays, &ageDays)
+	}
+
+	// keys that were never used count as unused since they were created
+	unusedSince := key.CreateDate
+	if lastUsed != nil && lastUsed.LastUsedDate != nil {
+		unusedSince = lastUsed.LastUsedDate
+		entity.
+			AddLabel(iamAccessKeyLabelLastUsedAt, aws.String(lastUsed.LastUsedDate.UTC().Format("2006-01-02"))).
+			AddLabel(iamAccessKeyLabelLastUsedService, lastUsed.ServiceName).
+			AddLabel(iamAccessKeyLabelLastUsedRegion, lastUsed.Region)
+	} else {
+		neverUsed := true
+		entity.AddBoolLabel(iamAccessKeyLabelNeverUsed, &neverUsed)
+	}
+	if unusedSince != nil {
+		unusedDays := int64(now.Sub(*unusedSince).Hours() / 24)
+		entity.AddInt64Label(iamAccessKeyLabelUnusedDays, &unusedDays)
+	}
+
+	for _, tag := range userTags {
+		// the tag of a key that is active again is stale, deactivate overwrites it
+		if tag == nil || aws.StringValue(tag.Key) != iamAccessKeyDeactivatedTag+entity.ID || entity.status != iam.StatusTypeInactive {
+			continue
+		}
+		deactivatedAt, err := time.Parse(time.RFC3339, aws.StringValue(tag.Value))
+		if err != nil {
+			log.Warnf("could not parse the deactivation time %s of access key %s", aws.StringValue(tag.Value), entity.ID)
+			continue
+		}
+		// a key used since reaper deactivated it was activated again, and deactivated outside of
+		// reaper afterwards
+		if unusedSince != nil && unusedSince.After(deactivatedAt) {
+			continue
+		}
+		entity.deactivatedAt = &deactivatedAt
+		entity.AddLabel(iamAccessKeyLabelDeactivatedAt, aws.String(deactivatedAt.UTC().Format("2006-01-02")))
 	}
 
 	return entity
 }
 
+// Remediate deactivates or deletes the access key. Keys are deleted in two phases: they have to be
+// deactivated first, and are only deleted once the grace period has passed since.
+func (u *IAMAccessKey) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	switch remediation.Action {
+	case policy.ActionDeactivate:
+		if u.status == iam.StatusTypeInactive {
+			return nil
+		}
+		// tag first, a deactivated key without the tag would wait out a fresh grace period
+		err := u.tagDeactivated(ctx, time.Now())
+		if err != nil {
+			return err
+		}
+		log.Warnf("Deactivating access key %s of %s", u.ID, u.UserName)
+		_, err = u.svc.UpdateAccessKeyWithContext(ctx, &iam.UpdateAccessKeyInput{
+			AccessKeyId: &u.ID,
+			UserName:    &u.UserName,
+			Status:      aws.String(iam.StatusTypeInactive),
+		})
+		if err != nil {
+			var errs error
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not deactivate access key %s", u.ID))
+			return multierror.Append(errs, u.untagDeactivated(ctx))
+		}
+		return nil
+	case policy.ActionDelete:
+		if u.status != iam.StatusTypeInactive {
+			return errors.Errorf("access key %s is active, deactivate it first", u.ID)
+		}
+		// keys deactivated outside of reaper start their grace period now
+		if u.deactivatedAt == nil {
+			log.Warnf("Access key %s was not deactivated by reaper, it will be deleted after the grace period", u.ID)
+			return u.tagDeactivated(ctx, time.Now())
+		}
+		gracePeriod := remediation.Delete.GracePeriodOrDefault()
+		if time.Since(*u.deactivatedAt) < gracePeriod {
+			log.Infof("Access key %s was deactivated %s, waiting until its grace period of %s has passed", u.ID, u.deactivatedAt.Format(time.RFC3339), gracePeriod)
+			return nil
+		}
+		log.Warnf("Deleting access key %s of %s", u.ID, u.UserName)
+		_, err := u.svc.DeleteAccessKeyWithContext(ctx, &iam.DeleteAccessKeyInput{
+			AccessKeyId: &u.ID,
+			UserName:    &u.UserName,
+		})
+		if err != nil {
+			return errors.Wrapf(err, "could not delete access key %s", u.ID)
+		}
+		return u.untagDeactivated(ctx)
+	default:
+		return errors.Errorf("access key %s does not support the %s action", u.ID, remediation.Action)
+	}
+}
+
+// tagDeactivated records on the key's user when the key was deactivated
+func (u *IAMAccessKey) tagDeactivated(ctx context.Context, at time.Time) error {
+	_, err := u.svc.TagUserWithContext(ctx, &iam.TagUserInput{
+		UserName: &u.UserName,
+		Tags: []*iam.Tag{{
+			Key:   aws.String(iamAccessKeyDeactivatedTag + u.ID),
+			Value: aws.String(at.UTC().Format(time.RFC3339)),
+		}},
+	})
+	return errors.Wrapf(err, "could not record the deactivation of access key %s on %s", u.ID, u.UserName)
+}
+
+// untagDeactivated removes the record of the key's deactivation from its user
+func (u *IAMAccessKey) untagDeactivated(ctx context.Context) error {
+	_, err := u.svc.UntagUserWithContext(ctx, &iam.UntagUserInput{
+		UserName: &u.UserName,
+		TagKeys:  aws.StringSlice([]string{iamAccessKeyDeactivatedTag + u.ID}),
+	})
+	return errors.Wrapf(err, "could not remove the deactivation tag of access key %s from %s", u.ID, u.UserName)
+}
+
+// getIAMAccessKeys looks up the access keys of a user and when each was last used
+func getIAMAccessKeys(ctx context.Context, svc iamiface.IAMAPI, user *iam.UserDetail, now time.Time) ([]*IAMAccessKey, error) {
+	output, err := svc.ListAccessKeysWithContext(ctx, &iam.ListAccessKeysInput{UserName: user.UserName})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list access keys of %s", aws.StringValue(user.UserName))
+	}
+	var keys []*IAMAccessKey
+	for _, keyMetadata := range output.AccessKeyMetadata {
+		lastUsed, err := svc.GetAccessKeyLastUsedWithContext(ctx, &iam.GetAccessKeyLastUsedInput{AccessKeyId: keyMetadata.AccessKeyId})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not get when access key %s was last used", aws.StringValue(keyMetadata.AccessKeyId))
+		}
+		keys = append(keys, NewIAMAccessKey(keyMetadata, lastUsed.AccessKeyLastUsed, user.Tags, now, svc))
+	}
+	return keys, nil
+}
+
 // EvalIAMAccessKey walks through all IAM users' access keys
 func (c *Client) EvalIAMAccessKey(accounts []*policy.Account, p policy.Policy) ([]policy.Violation, error) {
 	var violations []policy.Violation
@@ -65,26 +226,51 @@ func (c *Client) EvalIAMAccessKey(accounts []*policy.Account, p policy.Policy) (
 		log.Infof("Walking iam access key for %s", account.Name)
 		region := DefaultRegion
 		client := c.Get(account.ID, account.Role, account.ExternalID, region)
-		err := client.IAM.ListAllUsers(ctx, func(user *iam.User) {
-			input := &iam.ListAccessKeysInput{
-				UserName: user.UserName,
-			}
-			output, err := client.IAM.Svc.ListAccessKeysWithContext(ctx, input)
+		users, err := listIAMUserDetails(ctx, client.IAM)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate access keys in %s", account.Name))
+			continue
+		}
+		for _, user := range users {
+			keys, err := getIAMAccessKeys(ctx, client.IAM, user, time.Now())
 			if err != nil {
 				errs = multierror.Append(errs, err)
-				return
+				continue
 			}
-			for _, keyMetadata := range output.AccessKeyMetadata {
-				key := c.NewIAMAccessKey(ctx, keyMetadata)
+			for _, key := range keys {
 				if p.Match(key) {
-					violation := policy.NewViolation(p, key, false, account)
+					violation := policy.NewViolation(p, key, p.Expired(key), account)
 					violations = append(violations, violation)
 				}
 			}
-
-		})
-		errs = multierror.Append(errs, err)
-
+		}
 	}
 	return violations, errs
 }
+
+func (c *Client) getIAMAccessKey(ctx context.Context, account *policy.Account, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+	lastUsed, err := client.IAM.GetAccessKeyLastUsedWithContext(ctx, &iam.GetAccessKeyLastUsedInput{AccessKeyId: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not find the user for access key %s", id)
+	}
+	users, err := listIAMUserDetails(ctx, client.IAM)
+	if err != nil {
+		return nil, err
+	}
+	for _, user := range users {
+		if aws.StringValue(user.UserName) != aws.StringValue(lastUsed.UserName) {
+			continue
+		}
+		keys, err := getIAMAccessKeys(ctx, client.IAM, user, time.Now())
+		if err != nil {
+			return nil, err
+		}
+		for _, key := range keys {
+			if key.ID == id {
+				return key, nil
+			}
+		}
+	}
+	return nil, errors.Errorf("access key %s not found", id)
+}
@@ -0,0 +1,101 @@
+package aws_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIAMAccessKeyLabels(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	key := &iam.AccessKeyMetadata{
+		AccessKeyId: aws.String("AKIA123"),
+		UserName:    aws.String("alice"),
+		Status:      aws.String(iam.StatusTypeActive),
+		CreateDate:  aws.Time(now.AddDate(0, 0, -200)),
+	}
+
+	labels := reaperAws.NewIAMAccessKey(key, &iam.AccessKeyLastUsed{Region: aws.String("N/A"), ServiceName: aws.String("N/A")}, nil, now, nil).GetLabels()
+	a.Equal("true", labels["never_used"])
+	a.Equal("200", labels["unused_days"])
+	a.Equal("200", labels["age_days"])
+
+	lastUsed := &iam.AccessKeyLastUsed{
+		LastUsedDate: aws.Time(now.AddDate(0, 0, -10)),
+		Region:       aws.String("us-west-2"),
+		ServiceName:  aws.String("s3"),
+	}
+	tags := []*iam.Tag{{Key: aws.String("reaper:deactivated:AKIA123"), Value: aws.String("2020-05-25T00:00:00Z")}}
+	key.Status = aws.String(iam.StatusTypeInactive)
+	labels = reaperAws.NewIAMAccessKey(key, lastUsed, tags, now, nil).GetLabels()
+	a.Equal("", labels["never_used"])
+	a.Equal("10", labels["unused_days"])
+	a.Equal("2020-05-22", labels["last_used_at"])
+	a.Equal("s3", labels["last_used_service"])
+	a.Equal("us-west-2", labels["last_used_region"])
+	a.Equal("2020-05-25", labels["deactivated_at"])
+
+	// the key was activated again since reaper deactivated it
+	key.Status = aws.String(iam.StatusTypeActive)
+	labels = reaperAws.NewIAMAccessKey(key, lastUsed, tags, now, nil).GetLabels()
+	a.NotContains(labels, "deactivated_at")
+
+	// the key was used after reaper deactivated it, and deactivated again outside of reaper
+	key.Status = aws.String(iam.StatusTypeInactive)
+	lastUsed.LastUsedDate = aws.Time(now.AddDate(0, 0, -2))
+	labels = reaperAws.NewIAMAccessKey(key, lastUsed, tags, now, nil).GetLabels()
+	a.NotContains(labels, "deactivated_at")
+}
+
+func TestIAMAccessKeyRemediate(t *testing.T) {
+	now := time.Now()
+	deactivatedAt := func(d time.Duration) []*iam.Tag {
+		return []*iam.Tag{{Key: aws.String("reaper:deactivated:AKIA123"), Value: aws.String(now.Add(-d).UTC().Format(time.RFC3339))}}
+	}
+	week := 7 * 24 * time.Hour
+	tests := []struct {
+		name   string
+		status string
+		tags   []*iam.Tag
+		action string
+		svc    fakeIAM
+		calls  []string
+		err    string
+	}{
+		{"deactivate", iam.StatusTypeActive, nil, policy.ActionDeactivate, fakeIAM{}, []string{"tag reaper:deactivated:AKIA123", "update Inactive"}, ""},
+		{"deactivate when the user has too many tags", iam.StatusTypeActive, nil, policy.ActionDeactivate, fakeIAM{tagErr: errors.New("LimitExceeded")}, nil, "could not record the deactivation of access key AKIA123 on alice: LimitExceeded"},
+		{"deactivate fails", iam.StatusTypeActive, nil, policy.ActionDeactivate, fakeIAM{updateErr: errors.New("AccessDenied")}, []string{"tag reaper:deactivated:AKIA123", "untag reaper:deactivated:AKIA123"}, "could not deactivate access key AKIA123: AccessDenied"},
+		{"deactivate key reactivated since", iam.StatusTypeActive, deactivatedAt(3 * week), policy.ActionDeactivate, fakeIAM{}, []string{"tag reaper:deactivated:AKIA123", "update Inactive"}, ""},
+		{"deactivate inactive key", iam.StatusTypeInactive, nil, policy.ActionDeactivate, fakeIAM{}, nil, ""},
+		{"delete active key", iam.StatusTypeActive, nil, policy.ActionDelete, fakeIAM{}, nil, "access key AKIA123 is active, deactivate it first"},
+		{"delete key deactivated outside reaper", iam.StatusTypeInactive, nil, policy.ActionDelete, fakeIAM{}, []string{"tag reaper:deactivated:AKIA123"}, ""},
+		{"delete within grace period", iam.StatusTypeInactive, deactivatedAt(week), policy.ActionDelete, fakeIAM{}, nil, ""},
+		{"delete after grace period", iam.StatusTypeInactive, deactivatedAt(3 * week), policy.ActionDelete, fakeIAM{}, []string{"delete AKIA123", "untag reaper:deactivated:AKIA123"}, ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			svc := &test.svc
+			key := &iam.AccessKeyMetadata{
+				AccessKeyId: aws.String("AKIA123"),
+				UserName:    aws.String("alice"),
+				Status:      aws.String(test.status),
+			}
+			err := reaperAws.NewIAMAccessKey(key, nil, test.tags, now, svc).Remediate(policy.Remediation{Action: test.action})
+			if test.err != "" {
+				a.Error(err)
+				a.Contains(err.Error(), test.err)
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(test.calls, svc.calls)
+		})
+	}
+}
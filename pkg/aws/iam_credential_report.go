@@ -0,0 +1,80 @@
+package aws
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/pkg/errors"
+)
+
+// how often and for how long we poll a credential report AWS is generating
+const (
+	credentialReportPollInterval = 2 * time.Second
+	credentialReportTimeout      = 5 * time.Minute
+)
+
+// getCredentialReport generates the credential report of an account and returns its rows keyed by
+// user name. Each row maps from a column of the report, such as password_last_used, to its value.
+// AWS only regenerates the report when the last one is over 4 hours old.
+func getCredentialReport(ctx context.Context, svc iamiface.IAMAPI) (map[string]map[string]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, credentialReportTimeout)
+	defer cancel()
+	for {
+		output, err := svc.GenerateCredentialReportWithContext(ctx, &iam.GenerateCredentialReportInput{})
+		if err != nil {
+			return nil, errors.Wrap(err, "could not generate credential report")
+		}
+		if aws.StringValue(output.State) == iam.ReportStateTypeComplete {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil, errors.New("gave up waiting for the credential report")
+		case <-time.After(credentialReportPollInterval):
+		}
+	}
+
+	output, err := svc.GetCredentialReportWithContext(ctx, &iam.GetCredentialReportInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not get credential report")
+	}
+	return parseCredentialReport(output.Content)
+}
+
+// parseCredentialReport parses the csv content of a credential report
+func parseCredentialReport(content []byte) (map[string]map[string]string, error) {
+	records, err := csv.NewReader(bytes.NewReader(content)).ReadAll()
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse credential report")
+	}
+	if len(records) == 0 {
+		return nil, errors.New("empty credential report")
+	}
+	header := records[0]
+	rows := map[string]map[string]string{}
+	for _, record := range records[1:] {
+		row := map[string]string{}
+		for i, column := range header {
+			if i < len(record) {
+				row[column] = record[i]
+			}
+		}
+		rows[row["user"]] = row
+	}
+	return rows, nil
+}
+
+// credentialReportTime parses a timestamp column of the credential report. Columns without a
+// timestamp hold N/A, no_information or not_supported.
+func credentialReportTime(row map[string]string, column string) *time.Time {
+	t, err := time.Parse(time.RFC3339, row[column])
+	if err != nil {
+		return nil
+	}
+	return &t
+}
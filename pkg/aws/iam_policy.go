@@ -0,0 +1,147 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// iam_policy specific labels
+const (
+	iamPolicyLabelPath                          TypeEntityLabel = "path"
+	iamPolicyLabelAttachmentCount               TypeEntityLabel = "attachment_count"
+	iamPolicyLabelIsAttached                    TypeEntityLabel = "is_attached"
+	iamPolicyLabelPermissionsBoundaryUsageCount TypeEntityLabel = "permissions_boundary_usage_count"
+	iamPolicyLabelVersion                       TypeEntityLabel = "version"
+	iamPolicyLabelAllowsAdmin                   TypeEntityLabel = "allows_admin"
+)
+
+// IAMPolicy is an evaluation entity representing a customer managed iam policy
+type IAMPolicy struct {
+	Entity
+}
+
+// GetID returns the policy name
+func (p *IAMPolicy) GetID() string {
+	return p.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (p *IAMPolicy) GetConsoleURL() string {
+	t := "https://console.aws.amazon.com/iam/home#/policies/%s$serviceLevelSummary"
+	return fmt.Sprintf(t, url.PathEscape(p.GetLabelOr(labelARN, "")))
+}
+
+// NewIAMPolicy returns a new iam policy entity, document is the policy's default version
+func NewIAMPolicy(p *iam.Policy, document *string) *IAMPolicy {
+	entity := &IAMPolicy{
+		Entity: NewEntity(),
+	}
+	if p == nil {
+		return entity
+	}
+
+	entity.ID = aws.StringValue(p.PolicyName)
+	entity.Name = entity.ID
+
+	attached := aws.Int64Value(p.AttachmentCount) > 0
+	entity.
+		AddLabel(labelARN, p.Arn).
+		AddLabel(iamPolicyLabelPath, p.Path).
+		AddInt64Label(iamPolicyLabelAttachmentCount, p.AttachmentCount).
+		AddBoolLabel(iamPolicyLabelIsAttached, &attached).
+		AddInt64Label(iamPolicyLabelPermissionsBoundaryUsageCount, p.PermissionsBoundaryUsageCount).
+		AddLabel(iamPolicyLabelVersion, p.DefaultVersionId).
+		AddCreatedAt(p.CreateDate)
+
+	if document != nil {
+		d, err := parseIAMPolicyDocument(*document)
+		if err != nil {
+			log.Warnf("could not parse iam policy %s: %s", entity.ID, err)
+			return entity
+		}
+		allowsAdmin := d.allowsAdmin()
+		entity.AddBoolLabel(iamPolicyLabelAllowsAdmin, &allowsAdmin)
+	}
+
+	return entity
+}
+
+// getIAMPolicyDocument fetches the document of the policy's default version
+func getIAMPolicyDocument(ctx context.Context, svc iamiface.IAMAPI, p *iam.Policy) (*string, error) {
+	output, err := svc.GetPolicyVersionWithContext(ctx, &iam.GetPolicyVersionInput{
+		PolicyArn: p.Arn,
+		VersionId: p.DefaultVersionId,
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get the default version of iam policy %s", aws.StringValue(p.PolicyName))
+	}
+	return output.PolicyVersion.Document, nil
+}
+
+// listIAMPolicies lists the customer managed policies in an account
+func listIAMPolicies(ctx context.Context, svc iamiface.IAMAPI) ([]*iam.Policy, error) {
+	var policies []*iam.Policy
+	input := &iam.ListPoliciesInput{Scope: aws.String(iam.PolicyScopeTypeLocal)}
+	err := svc.ListPoliciesPagesWithContext(ctx, input, func(output *iam.ListPoliciesOutput, lastPage bool) bool {
+		policies = append(policies, output.Policies...)
+		return true
+	})
+	return policies, errors.Wrap(err, "could not list iam policies")
+}
+
+// EvalIAMPolicy walks through all customer managed iam policies
+func (c *Client) EvalIAMPolicy(accounts []*policy.Account, p policy.Policy, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	for _, account := range accounts {
+		log.Infof("Walking iam policies for %s", account.Name)
+		client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+		policies, err := listIAMPolicies(ctx, client.IAM)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate iam policies in %s", account.Name))
+			continue
+		}
+		for _, iamPolicy := range policies {
+			document, err := getIAMPolicyDocument(ctx, client.IAM, iamPolicy)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			i := NewIAMPolicy(iamPolicy, document)
+			if p.Match(i) {
+				violation := policy.NewViolation(p, i, p.Expired(i), account)
+				f(violation)
+			}
+		}
+	}
+	return errs
+}
+
+func (c *Client) getIAMPolicy(ctx context.Context, account *policy.Account, name string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+	// policy arns include the path, so we look the policy up by name
+	policies, err := listIAMPolicies(ctx, client.IAM)
+	if err != nil {
+		return nil, err
+	}
+	for _, iamPolicy := range policies {
+		if aws.StringValue(iamPolicy.PolicyName) != name {
+			continue
+		}
+		document, err := getIAMPolicyDocument(ctx, client.IAM, iamPolicy)
+		if err != nil {
+			return nil, err
+		}
+		return NewIAMPolicy(iamPolicy, document), nil
+	}
+	return nil, errors.Errorf("iam policy %s not found", name)
+}
@@ -0,0 +1,141 @@
+package aws
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/pkg/errors"
+)
+
+// iamStringOrSlice is a policy element that can be a single string or a list of them
+type iamStringOrSlice []string
+
+// UnmarshalJSON accepts both forms
+func (s *iamStringOrSlice) UnmarshalJSON(data []byte) error {
+	var single string
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = iamStringOrSlice{single}
+		return nil
+	}
+	var list []string
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// iamPrincipal maps from a principal type such as AWS or Service to the principals of that type
+type iamPrincipal map[string]iamStringOrSlice
+
+// UnmarshalJSON accepts the "*" shorthand for everybody
+func (p *iamPrincipal) UnmarshalJSON(data []byte) error {
+	var wildcard string
+	if err := json.Unmarshal(data, &wildcard); err == nil {
+		*p = iamPrincipal{"AWS": {wildcard}}
+		return nil
+	}
+	var principals map[string]iamStringOrSlice
+	if err := json.Unmarshal(data, &principals); err != nil {
+		return err
+	}
+	*p = principals
+	return nil
+}
+
+type iamStatement struct {
+	Effect    string
+	Principal iamPrincipal
+	Action    iamStringOrSlice
+	Resource  iamStringOrSlice
+}
+
+// iamStatements can be a single statement or a list of them
+type iamStatements []iamStatement
+
+// UnmarshalJSON accepts both forms
+func (s *iamStatements) UnmarshalJSON(data []byte) error {
+	var single iamStatement
+	if err := json.Unmarshal(data, &single); err == nil {
+		*s = iamStatements{single}
+		return nil
+	}
+	var list []iamStatement
+	if err := json.Unmarshal(data, &list); err != nil {
+		return err
+	}
+	*s = list
+	return nil
+}
+
+// iamPolicyDocument is the subset of an IAM policy document we look at
+type iamPolicyDocument struct {
+	Statement iamStatements
+}
+
+// parseIAMPolicyDocument parses a policy document as returned by the IAM API, which url encodes them
+func parseIAMPolicyDocument(document string) (*iamPolicyDocument, error) {
+	decoded, err := url.QueryUnescape(document)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not decode policy document")
+	}
+	d := &iamPolicyDocument{}
+	err = json.Unmarshal([]byte(decoded), d)
+	return d, errors.Wrap(err, "could not parse policy document")
+}
+
+// allowsAdmin returns true if the document allows every action on every resource
+func (d *iamPolicyDocument) allowsAdmin() bool {
+	for _, s := range d.Statement {
+		if s.Effect == "Allow" && containsString(s.Action, "*") && containsString(s.Resource, "*") {
+			return true
+		}
+	}
+	return false
+}
+
+// iamTrust summarizes who a role's trust policy lets assume it
+type iamTrust struct {
+	// accounts lists the other accounts trusted
+	accounts []string
+	// wildcard is set when anybody is trusted
+	wildcard  bool
+	service   bool
+	federated bool
+}
+
+var accountIDPattern = regexp.MustCompile(`^[0-9]{12}$`)
+
+// trust analyzes the document as the trust policy of a role in accountID
+func (d *iamPolicyDocument) trust(accountID string) iamTrust {
+	t := iamTrust{}
+	for _, s := range d.Statement {
+		if s.Effect != "Allow" {
+			continue
+		}
+		for principalType, principals := range s.Principal {
+			switch principalType {
+			case "Service":
+				t.service = true
+			case "Federated":
+				t.federated = true
+			case "AWS":
+				for _, principal := range principals {
+					account := principal
+					if a, err := arn.Parse(principal); err == nil {
+						account = a.AccountID
+					}
+					switch {
+					case principal == "*":
+						t.wildcard = true
+					case accountIDPattern.MatchString(account) && account != accountID && !containsString(t.accounts, account):
+						t.accounts = append(t.accounts, account)
+					}
+				}
+			}
+		}
+	}
+	return t
+}
@@ -0,0 +1,205 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/iam/iamiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// iam_role specific labels
+const (
+	iamRoleLabelPath                   TypeEntityLabel = "path"
+	iamRoleLabelIsServiceLinked        TypeEntityLabel = "is_service_linked"
+	iamRoleLabelLastUsed               TypeEntityLabel = "last_used"
+	iamRoleLabelLastUsedRegion         TypeEntityLabel = "last_used_region"
+	iamRoleLabelNeverUsed              TypeEntityLabel = "never_used"
+	iamRoleLabelUnusedDays             TypeEntityLabel = "unused_days"
+	iamRoleLabelTrustsOtherAccounts    TypeEntityLabel = "trusts_other_accounts"
+	iamRoleLabelTrustedAccountCount    TypeEntityLabel = "trusted_account_count"
+	iamRoleLabelTrustsWildcard         TypeEntityLabel = "trusts_wildcard_principal"
+	iamRoleLabelTrustsService          TypeEntityLabel = "trusts_service"
+	iamRoleLabelTrustsFederated        TypeEntityLabel = "trusts_federated"
+	iamRoleLabelAttachedPolicyCount    TypeEntityLabel = "attached_policy_count"
+	iamRoleLabelInlinePolicyCount      TypeEntityLabel = "inline_policy_count"
+	iamRoleLabelHasAdministratorAccess TypeEntityLabel = "has_administrator_access"
+	iamRoleLabelHasPermissionsBoundary TypeEntityLabel = "has_permissions_boundary"
+	iamRoleLabelTrustPolicyUnparsable  TypeEntityLabel = "trust_policy_unparsable"
+)
+
+const (
+	iamAdministratorAccessARN = "arn:aws:iam::aws:policy/AdministratorAccess"
+	iamServiceLinkedRolePath  = "/aws-service-role/"
+)
+
+// IAMRole is an evaluation entity representing an iam role
+type IAMRole struct {
+	Entity
+}
+
+// GetID returns the role name
+func (r *IAMRole) GetID() string {
+	return r.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (r *IAMRole) GetConsoleURL() string {
+	t := "https://console.aws.amazon.com/iam/home#/roles/%s"
+	return fmt.Sprintf(t, r.ID)
+}
+
+// NewIAMRole returns a new iam role entity. role has to come from GetRole, ListRoles leaves out
+// RoleLastUsed and the tags. accountID is the 12 digit id of the account the role lives in.
+func NewIAMRole(role *iam.Role, attachedPolicies []*iam.AttachedPolicy, inlinePolicyCount int64, accountID string, now time.Time) *IAMRole {
+	entity := &IAMRole{
+		Entity: NewEntity(),
+	}
+	if role == nil {
+		return entity
+	}
+
+	entity.ID = aws.StringValue(role.RoleName)
+	entity.Name = entity.ID
+
+	for _, tag := range role.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+
+	serviceLinked := strings.HasPrefix(aws.StringValue(role.Path), iamServiceLinkedRolePath)
+	hasPermissionsBoundary := role.PermissionsBoundary != nil
+	attachedPolicyCount := int64(len(attachedPolicies))
+	hasAdministratorAccess := false
+	for _, p := range attachedPolicies {
+		if aws.StringValue(p.PolicyArn) == iamAdministratorAccessARN {
+			hasAdministratorAccess = true
+		}
+	}
+	entity.
+		AddLabel(labelARN, role.Arn).
+		AddLabel(iamRoleLabelPath, role.Path).
+		AddBoolLabel(iamRoleLabelIsServiceLinked, &serviceLinked).
+		AddInt64Label(iamRoleLabelAttachedPolicyCount, &attachedPolicyCount).
+		AddInt64Label(iamRoleLabelInlinePolicyCount, &inlinePolicyCount).
+		AddBoolLabel(iamRoleLabelHasAdministratorAccess, &hasAdministratorAccess).
+		AddBoolLabel(iamRoleLabelHasPermissionsBoundary, &hasPermissionsBoundary).
+		AddCreatedAt(role.CreateDate)
+
+	// roles that were never used count as unused since they were created
+	unusedSince := role.CreateDate
+	if role.RoleLastUsed != nil && role.RoleLastUsed.LastUsedDate != nil {
+		unusedSince = role.RoleLastUsed.LastUsedDate
+		entity.
+			AddLabel(iamRoleLabelLastUsed, aws.String(unusedSince.UTC().Format("2006-01-02"))).
+			AddLabel(iamRoleLabelLastUsedRegion, role.RoleLastUsed.Region)
+	} else {
+		neverUsed := true
+		entity.AddBoolLabel(iamRoleLabelNeverUsed, &neverUsed)
+	}
+	if unusedSince != nil {
+		unusedDays := int64(now.Sub(*unusedSince).Hours() / 24)
+		entity.AddInt64Label(iamRoleLabelUnusedDays, &unusedDays)
+	}
+
+	if role.AssumeRolePolicyDocument != nil {
+		document, err := parseIAMPolicyDocument(*role.AssumeRolePolicyDocument)
+		if err != nil {
+			log.Warnf("could not parse the trust policy of role %s: %s", entity.ID, err)
+			unparsable := true
+			entity.AddBoolLabel(iamRoleLabelTrustPolicyUnparsable, &unparsable)
+			return entity
+		}
+		trust := document.trust(accountID)
+		trustsOtherAccounts := len(trust.accounts) > 0
+		trustedAccountCount := int64(len(trust.accounts))
+		entity.
+			AddBoolLabel(iamRoleLabelTrustsOtherAccounts, &trustsOtherAccounts).
+			AddInt64Label(iamRoleLabelTrustedAccountCount, &trustedAccountCount).
+			AddBoolLabel(iamRoleLabelTrustsWildcard, &trust.wildcard).
+			AddBoolLabel(iamRoleLabelTrustsService, &trust.service).
+			AddBoolLabel(iamRoleLabelTrustsFederated, &trust.federated)
+	}
+
+	return entity
+}
+
+// getIAMRoleDetails fetches a role with its last used data and tags, and counts its policies
+func getIAMRoleDetails(ctx context.Context, svc iamiface.IAMAPI, name *string) (*iam.Role, []*iam.AttachedPolicy, int64, error) {
+	role, err := svc.GetRoleWithContext(ctx, &iam.GetRoleInput{RoleName: name})
+	if err != nil {
+		return nil, nil, 0, errors.Wrapf(err, "could not get iam role %s", aws.StringValue(name))
+	}
+
+	var attachedPolicies []*iam.AttachedPolicy
+	err = svc.ListAttachedRolePoliciesPagesWithContext(ctx, &iam.ListAttachedRolePoliciesInput{RoleName: name}, func(output *iam.ListAttachedRolePoliciesOutput, lastPage bool) bool {
+		attachedPolicies = append(attachedPolicies, output.AttachedPolicies...)
+		return true
+	})
+	if err != nil {
+		return nil, nil, 0, errors.Wrapf(err, "could not list attached policies of iam role %s", aws.StringValue(name))
+	}
+
+	var inlinePolicyCount int64
+	err = svc.ListRolePoliciesPagesWithContext(ctx, &iam.ListRolePoliciesInput{RoleName: name}, func(output *iam.ListRolePoliciesOutput, lastPage bool) bool {
+		inlinePolicyCount += int64(len(output.PolicyNames))
+		return true
+	})
+	if err != nil {
+		return nil, nil, 0, errors.Wrapf(err, "could not list inline policies of iam role %s", aws.StringValue(name))
+	}
+	return role.Role, attachedPolicies, inlinePolicyCount, nil
+}
+
+// EvalIAMRole walks through all iam roles
+func (c *Client) EvalIAMRole(accounts []*policy.Account, p policy.Policy, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	for _, account := range accounts {
+		log.Infof("Walking iam roles for %s", account.Name)
+		client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+		svc := client.IAM
+		var names []*string
+		err := svc.ListRolesPagesWithContext(ctx, &iam.ListRolesInput{}, func(output *iam.ListRolesOutput, lastPage bool) bool {
+			for _, role := range output.Roles {
+				names = append(names, role.RoleName)
+			}
+			return true
+		})
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not list iam roles in %s", account.Name))
+			continue
+		}
+		for _, name := range names {
+			role, attachedPolicies, inlinePolicyCount, err := getIAMRoleDetails(ctx, svc, name)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			r := NewIAMRole(role, attachedPolicies, inlinePolicyCount, fmt.Sprintf("%012d", account.ID), time.Now())
+			if p.Match(r) {
+				violation := policy.NewViolation(p, r, p.Expired(r), account)
+				f(violation)
+			}
+		}
+	}
+	return errs
+}
+
+func (c *Client) getIAMRole(ctx context.Context, account *policy.Account, name string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+	role, attachedPolicies, inlinePolicyCount, err := getIAMRoleDetails(ctx, client.IAM, &name)
+	if err != nil {
+		return nil, err
+	}
+	return NewIAMRole(role, attachedPolicies, inlinePolicyCount, fmt.Sprintf("%012d", account.ID), time.Now()), nil
+}
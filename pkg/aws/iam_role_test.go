@@ -0,0 +1,98 @@
+package aws_test
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewIAMRoleTrust(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		trust  string
+		labels map[string]string
+	}{
+		{
+			"service",
+			`{"Version":"2012-10-17","Statement":[{"Effect":"Allow","Principal":{"Service":"lambda.amazonaws.com"},"Action":"sts:AssumeRole"}]}`,
+			map[string]string{"trusts_service": "true", "trusted_account_count": "0"},
+		},
+		{
+			"same account",
+			`{"Statement":{"Effect":"Allow","Principal":{"AWS":"arn:aws:iam::123456789012:root"},"Action":"sts:AssumeRole"}}`,
+			map[string]string{"trusted_account_count": "0"},
+		},
+		{
+			"other accounts",
+			`{"Statement":[{"Effect":"Allow","Principal":{"AWS":["arn:aws:iam::210987654321:role/ci","111111111111","arn:aws:iam::210987654321:root"]},"Action":"sts:AssumeRole"}]}`,
+			map[string]string{"trusts_other_accounts": "true", "trusted_account_count": "2"},
+		},
+		{
+			"wildcard",
+			`{"Statement":[{"Effect":"Allow","Principal":"*","Action":"sts:AssumeRole"}]}`,
+			map[string]string{"trusts_wildcard_principal": "true", "trusted_account_count": "0"},
+		},
+		{
+			"denied",
+			`{"Statement":[{"Effect":"Deny","Principal":{"AWS":"*"},"Action":"sts:AssumeRole"}]}`,
+			map[string]string{"trusted_account_count": "0"},
+		},
+		{
+			"federated",
+			`{"Statement":[{"Effect":"Allow","Principal":{"Federated":"arn:aws:iam::123456789012:saml-provider/okta"},"Action":"sts:AssumeRoleWithSAML"}]}`,
+			map[string]string{"trusts_federated": "true", "trusted_account_count": "0"},
+		},
+		{
+			"unparsable",
+			`{"Statement":`,
+			map[string]string{"trust_policy_unparsable": "true"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			role := &iam.Role{
+				RoleName:                 aws.String("test"),
+				Path:                     aws.String("/"),
+				CreateDate:               aws.Time(now.AddDate(0, 0, -100)),
+				AssumeRolePolicyDocument: aws.String(url.QueryEscape(test.trust)),
+			}
+			labels := reaperAws.NewIAMRole(role, nil, 0, "123456789012", now).GetLabels()
+			for _, label := range []string{"trusts_service", "trusts_other_accounts", "trusted_account_count", "trusts_wildcard_principal", "trusts_federated", "trust_policy_unparsable"} {
+				a.Equal(test.labels[label], labels[label], label)
+			}
+		})
+	}
+}
+
+func TestNewIAMRoleUsage(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	role := &iam.Role{
+		RoleName:   aws.String("test"),
+		Path:       aws.String("/aws-service-role/"),
+		CreateDate: aws.Time(now.AddDate(0, 0, -100)),
+	}
+	attached := []*iam.AttachedPolicy{{PolicyArn: aws.String("arn:aws:iam::aws:policy/AdministratorAccess")}}
+
+	labels := reaperAws.NewIAMRole(role, attached, 2, "123456789012", now).GetLabels()
+	a.Equal("true", labels["never_used"])
+	a.Equal("100", labels["unused_days"])
+	a.Equal("true", labels["is_service_linked"])
+	a.Equal("true", labels["has_administrator_access"])
+	a.Equal("1", labels["attached_policy_count"])
+	a.Equal("2", labels["inline_policy_count"])
+
+	role.RoleLastUsed = &iam.RoleLastUsed{LastUsedDate: aws.Time(now.AddDate(0, 0, -3)), Region: aws.String("us-west-2")}
+	labels = reaperAws.NewIAMRole(role, nil, 0, "123456789012", now).GetLabels()
+	a.Equal("", labels["never_used"])
+	a.Equal("3", labels["unused_days"])
+	a.Equal("2020-05-29", labels["last_used"])
+	a.Equal("us-west-2", labels["last_used_region"])
+}
@@ -0,0 +1,138 @@
+package aws_test
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/iam"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+	k8sLabels "k8s.io/apimachinery/pkg/labels"
+)
+
+func TestNewIAMUser(t *testing.T) {
+	now := time.Date(2020, 6, 1, 0, 0, 0, 0, time.UTC)
+	user := &iam.UserDetail{
+		UserName:                aws.String("alice"),
+		GroupList:               aws.StringSlice([]string{"admins", "developers"}),
+		AttachedManagedPolicies: []*iam.AttachedPolicy{{PolicyArn: aws.String("arn:aws:iam::aws:policy/AdministratorAccess")}},
+	}
+	tests := []struct {
+		name        string
+		credentials map[string]string
+		labels      map[string]string
+	}{
+		{
+			"console user without mfa",
+			map[string]string{
+				"password_enabled":      "true",
+				"password_last_used":    "2020-05-30T10:00:00+00:00",
+				"password_last_changed": "2020-03-03T00:00:00+00:00",
+				"mfa_active":            "false",
+				"access_key_1_active":   "false",
+				"access_key_2_active":   "false",
+			},
+			map[string]string{
+				"has_password":               "true",
+				"console_access_without_mfa": "true",
+				"password_last_used":         "2020-05-30",
+				"password_age_days":          "90",
+				"active_access_key_count":    "0",
+			},
+		},
+		{
+			"programmatic user",
+			map[string]string{
+				"password_enabled":               "false",
+				"password_last_used":             "N/A",
+				"password_last_changed":          "N/A",
+				"mfa_active":                     "true",
+				"access_key_1_active":            "true",
+				"access_key_1_last_used_date":    "2020-05-01T00:00:00+00:00",
+				"access_key_1_last_used_service": "s3",
+				"access_key_2_active":            "true",
+				"access_key_2_last_used_date":    "2020-05-20T00:00:00+00:00",
+				"access_key_2_last_used_service": "sts",
+			},
+			map[string]string{
+				"has_mfa":                      "true",
+				"active_access_key_count":      "2",
+				"access_key_last_used":         "2020-05-20",
+				"access_key_last_used_service": "sts",
+			},
+		},
+		{
+			"unused access key",
+			map[string]string{
+				"password_enabled":               "false",
+				"mfa_active":                     "false",
+				"access_key_1_active":            "true",
+				"access_key_1_last_used_date":    "N/A",
+				"access_key_1_last_used_service": "N/A",
+			},
+			map[string]string{
+				"active_access_key_count": "1",
+			},
+		},
+		{
+			"not in the report",
+			nil,
+			map[string]string{
+				"not_in_credential_report": "true",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			labels := reaperAws.NewIAMUser(user, test.credentials, now).GetLabels()
+			for _, label := range []string{"has_mfa", "has_password", "console_access_without_mfa", "password_last_used", "password_age_days", "active_access_key_count", "access_key_last_used", "access_key_last_used_service", "not_in_credential_report"} {
+				a.Equal(test.labels[label], labels[label], label)
+			}
+			a.Equal("true", labels["group/admins"])
+			a.Equal("2", labels["group_count"])
+			a.Equal("true", labels["has_administrator_access"])
+		})
+	}
+}
+
+func TestIAMUserGroupLabels(t *testing.T) {
+	tests := []struct {
+		group string
+		label string
+	}{
+		{"admins", "group/admins"},
+		{"ops+oncall@example.com", "group/ops_oncall_example.com"},
+		{"a=b,c", "group/a_b_c"},
+		{"_leading.and.trailing-", "group/leading.and.trailing"},
+		{strings.Repeat("x", 62) + "+y", "group/" + strings.Repeat("x", 62)},
+		{strings.Repeat("y", 128), "group/" + strings.Repeat("y", 63)},
+		{"+=,@", ""},
+	}
+	for _, test := range tests {
+		t.Run(test.group, func(t *testing.T) {
+			a := assert.New(t)
+			user := reaperAws.NewIAMUser(&iam.UserDetail{
+				UserName:  aws.String("alice"),
+				GroupList: aws.StringSlice([]string{test.group}),
+			}, nil, time.Now())
+			labels := user.GetLabels()
+			a.Equal("1", labels["group_count"])
+			var groups []string
+			for label := range labels {
+				if strings.HasPrefix(label, "group/") {
+					groups = append(groups, label)
+				}
+			}
+			if test.label == "" {
+				a.Empty(groups)
+				return
+			}
+			a.Equal([]string{test.label}, groups)
+			_, err := k8sLabels.Parse(test.label)
+			a.NoError(err, "the label should be usable in a label_selector")
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kinesis/kinesisiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// kinesis_stream specific labels
+const (
+	kinesisLabelStatus          TypeEntityLabel = "status"
+	kinesisLabelOpenShardCount  TypeEntityLabel = "open_shard_count"
+	kinesisLabelRetentionHours  TypeEntityLabel = "retention_hours"
+	kinesisLabelIsEncrypted     TypeEntityLabel = "is_encrypted"
+	kinesisLabelIncomingBytes   TypeEntityLabel = "incoming_bytes"
+	kinesisLabelIncomingRecords TypeEntityLabel = "incoming_records"
+)
+
+// KinesisStream is an evaluation entity representing a kinesis data stream
+type KinesisStream struct {
+	Entity
+}
+
+// GetID returns the stream name
+func (k *KinesisStream) GetID() string {
+	return k.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (k *KinesisStream) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/kinesis/home?region=%s#/streams/details/%s"
+	return fmt.Sprintf(t, k.Region, k.Region, k.ID)
+}
+
+// KinesisStreamDetails are looked up separately from the stream description
+type KinesisStreamDetails struct {
+	Tags            []*kinesis.Tag
+	IncomingBytes   float64
+	IncomingRecords float64
+}
+
+// NewKinesisStream returns a new kinesis stream entity
+func NewKinesisStream(stream *kinesis.StreamDescriptionSummary, details *KinesisStreamDetails, region string) *KinesisStream {
+	entity := &KinesisStream{
+		Entity: NewEntity(),
+	}
+	if stream == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if stream.StreamName != nil {
+		entity.ID = *stream.StreamName
+		entity.Name = *stream.StreamName
+	}
+
+	for _, tag := range details.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	encrypted := aws.StringValue(stream.EncryptionType) == kinesis.EncryptionTypeKms
+	entity.
+		AddLabel(labelARN, stream.StreamARN).
+		AddLabel(kinesisLabelStatus, stream.StreamStatus).
+		AddInt64Label(kinesisLabelOpenShardCount, stream.OpenShardCount).
+		AddInt64Label(kinesisLabelRetentionHours, stream.RetentionPeriodHours).
+		AddBoolLabel(kinesisLabelIsEncrypted, &encrypted).
+		AddLabel(kinesisLabelIncomingBytes, formatMetric(details.IncomingBytes)).
+		AddLabel(kinesisLabelIncomingRecords, formatMetric(details.IncomingRecords)).
+		AddCreatedAt(stream.StreamCreationTimestamp)
+
+	return entity
+}
+
+func describeKinesisStream(ctx context.Context, svc kinesisiface.KinesisAPI, name *string) (*kinesis.StreamDescriptionSummary, *KinesisStreamDetails, error) {
+	output, err := svc.DescribeStreamSummaryWithContext(ctx, &kinesis.DescribeStreamSummaryInput{StreamName: name})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not describe kinesis stream %s", aws.StringValue(name))
+	}
+
+	details := &KinesisStreamDetails{}
+	input := &kinesis.ListTagsForStreamInput{StreamName: name}
+	for {
+		tags, err := svc.ListTagsForStreamWithContext(ctx, input)
+		if err != nil {
+			return nil, nil, errors.Wrapf(err, "could not list tags of kinesis stream %s", aws.StringValue(name))
+		}
+		details.Tags = append(details.Tags, tags.Tags...)
+		if !aws.BoolValue(tags.HasMoreTags) || len(tags.Tags) == 0 {
+			break
+		}
+		input.ExclusiveStartTagKey = tags.Tags[len(tags.Tags)-1].Key
+	}
+	return output.StreamDescriptionSummary, details, nil
+}
+
+// kinesisMetricLabels are the labels getKinesisStreamMetrics looks up
+var kinesisMetricLabels = []string{string(kinesisLabelIncomingBytes), string(kinesisLabelIncomingRecords)}
+
+// getKinesisStreamMetrics adds the cloudwatch metrics of the stream to its details
+func getKinesisStreamMetrics(ctx context.Context, cw cloudwatchiface.CloudWatchAPI, name *string, details *KinesisStreamDetails, lookback time.Duration) error {
+	dimensions := map[string]string{"StreamName": aws.StringValue(name)}
+	var err error
+	details.IncomingBytes, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/Kinesis",
+		Metric:     "IncomingBytes",
+		Dimensions: dimensions,
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	if err != nil {
+		return errors.Wrapf(err, "could not get incoming bytes of kinesis stream %s", aws.StringValue(name))
+	}
+	details.IncomingRecords, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/Kinesis",
+		Metric:     "IncomingRecords",
+		Dimensions: dimensions,
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	return errors.Wrapf(err, "could not get incoming records of kinesis stream %s", aws.StringValue(name))
+}
+
+// EvalKinesisStream walks through all kinesis data streams
+func (c *Client) EvalKinesisStream(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+		svc := kinesis.New(sess, conf)
+		cw := cloudwatch.New(sess, conf)
+		err := svc.ListStreamsPagesWithContext(ctx, &kinesis.ListStreamsInput{}, func(output *kinesis.ListStreamsOutput, lastPage bool) bool {
+			for _, name := range output.StreamNames {
+				stream, details, err := describeKinesisStream(ctx, svc, name)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if !p.MightMatch(NewKinesisStream(stream, details, region), kinesisMetricLabels, false) {
+					continue
+				}
+				err = getKinesisStreamMetrics(ctx, cw, name, details, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				k := NewKinesisStream(stream, details, region)
+				if p.Match(k) {
+					violation := policy.NewViolation(p, k, p.Expired(k), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getKinesisStream(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+	stream, details, err := describeKinesisStream(ctx, kinesis.New(sess, conf), &id)
+	if err != nil {
+		return nil, err
+	}
+	err = getKinesisStreamMetrics(ctx, cloudwatch.New(sess, conf), &id, details, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewKinesisStream(stream, details, region), nil
+}
@@ -0,0 +1,45 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKinesisStream(t *testing.T) {
+	a := assert.New(t)
+	created := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+
+	stream := reaperAws.NewKinesisStream(&kinesis.StreamDescriptionSummary{
+		StreamName:              aws.String("clicks"),
+		StreamStatus:            aws.String(kinesis.StreamStatusActive),
+		OpenShardCount:          aws.Int64(2),
+		RetentionPeriodHours:    aws.Int64(24),
+		EncryptionType:          aws.String(kinesis.EncryptionTypeKms),
+		StreamCreationTimestamp: &created,
+	}, &reaperAws.KinesisStreamDetails{
+		Tags:            []*kinesis.Tag{{Key: aws.String("owner"), Value: aws.String("data@example.com")}},
+		IncomingBytes:   1024,
+		IncomingRecords: 8,
+	}, "us-west-2")
+	a.Equal("clicks", stream.GetID())
+	a.Equal("data@example.com", stream.GetTags()["owner"])
+	labels := stream.GetLabels()
+	a.Equal("ACTIVE", labels["status"])
+	a.Equal("2", labels["open_shard_count"])
+	a.Equal("24", labels["retention_hours"])
+	a.Equal("true", labels["is_encrypted"])
+	a.Equal("1024", labels["incoming_bytes"])
+	a.Equal("8", labels["incoming_records"])
+	a.Equal(created, *stream.GetCreatedAt())
+
+	stream = reaperAws.NewKinesisStream(&kinesis.StreamDescriptionSummary{
+		StreamName:     aws.String("clicks"),
+		EncryptionType: aws.String(kinesis.EncryptionTypeNone),
+	}, &reaperAws.KinesisStreamDetails{}, "us-west-2")
+	a.NotContains(stream.GetLabels(), "is_encrypted")
+}
@@ -4,51 +4,105 @@ import (
 	"context"
 	"fmt"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/kms"
-	cziAws "github.com/chanzuckerberg/go-misc/aws"
+	"github.com/aws/aws-sdk-go/service/kms/kmsiface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
 // kms specific labels
 const (
-	labelKMSKeyDescription TypeEntityLabel = "key_description"
-	labelKMSKeyState       TypeEntityLabel = "key_state"
+	labelKMSKeyDescription      TypeEntityLabel = "key_description"
+	labelKMSKeyState            TypeEntityLabel = "key_state"
+	labelKMSKeyManager          TypeEntityLabel = "key_manager"
+	labelKMSKeyUsage            TypeEntityLabel = "key_usage"
+	labelKMSRotationEnabled     TypeEntityLabel = "rotation_enabled"
+	labelKMSPendingDeletionAt   TypeEntityLabel = "pending_deletion_date"
+	labelKMSMultiRegion         TypeEntityLabel = "multi_region"
+	labelKMSHasAlias            TypeEntityLabel = "has_alias"
+	labelKMSAliasCount          TypeEntityLabel = "alias_count"
+	labelKMSRotationUnsupported TypeEntityLabel = "rotation_unsupported"
 )
 
+// kmsKeyDeletionReasonTag is the tag recording why reaper scheduled a key's deletion
+const kmsKeyDeletionReasonTag = "reaper:deletion-reason"
+
+// the waiting periods ScheduleKeyDeletion accepts, in days
+const (
+	KMSMinPendingWindowDays = 7
+	KMSMaxPendingWindowDays = 30
+)
+
+// KmsKey is an evaluation entity representing a kms key
 type KmsKey struct {
 	Entity
-	keyID string
+	keyID      string
+	keyManager string
+	keyState   string
+	svc        kmsiface.KMSAPI
+}
+
+// KMSKeyDetails are what we know about a key beyond its metadata
+type KMSKeyDetails struct {
+	Tags []*kms.Tag
+	// RotationEnabled is nil for keys that can't rotate and keys pending deletion
+	RotationEnabled *bool
+	AliasCount      int64
 }
 
-// Delete deletes this kms key
+// Delete schedules the deletion of this kms key with the default waiting period
 func (k *KmsKey) Delete() error {
-	log.Warnf("Would delete KMS key %s", k.keyID)
-	return nil
+	return k.Remediate(policy.Remediation{Action: policy.ActionDelete})
 }
 
+// GetID returns the kms key id
 func (k *KmsKey) GetID() string {
 	return fmt.Sprintf("kms: %s", k.keyID)
 }
+
+// GetConsoleURL will return a URL for this resource in the AWS console
 func (k *KmsKey) GetConsoleURL() string {
 	s := "https://console.aws.amazon.com/iam/home?region=%s#/encryptionKeys/%s/%s"
 	return fmt.Sprintf(s, k.Region, k.Region, k.ID)
 }
 
-func NewKMSKey(keyMetadata *kms.KeyMetadata, tags []*kms.Tag, region string) *KmsKey {
+// NewKMSKey returns a new kms key entity
+func NewKMSKey(keyMetadata *kms.KeyMetadata, details KMSKeyDetails, region string, svc kmsiface.KMSAPI) *KmsKey {
 	entity := &KmsKey{
-		keyID: *keyMetadata.KeyId,
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if keyMetadata == nil {
+		return entity
 	}
+	entity.keyID = aws.StringValue(keyMetadata.KeyId)
+	entity.ID = entity.keyID
+	entity.keyManager = aws.StringValue(keyMetadata.KeyManager)
+	entity.keyState = aws.StringValue(keyMetadata.KeyState)
 	entity.Region = region
+	hasAlias := details.AliasCount > 0
+	rotationUnsupported := !kmsKeyCanRotate(keyMetadata)
 	entity.
 		AddLabel(labelARN, keyMetadata.Arn).
 		AddLabel(labelID, keyMetadata.KeyId).
 		AddLabel(labelKMSKeyDescription, keyMetadata.Description).
 		AddLabel(labelKMSKeyState, keyMetadata.KeyState).
+		AddLabel(labelKMSKeyManager, keyMetadata.KeyManager).
+		AddLabel(labelKMSKeyUsage, keyMetadata.KeyUsage).
+		AddBoolLabel(labelKMSRotationEnabled, details.RotationEnabled).
+		AddBoolLabel(labelKMSRotationUnsupported, &rotationUnsupported).
+		AddBoolLabel(labelKMSMultiRegion, keyMetadata.MultiRegion).
+		AddBoolLabel(labelKMSHasAlias, &hasAlias).
+		AddInt64Label(labelKMSAliasCount, &details.AliasCount).
 		AddCreatedAt(keyMetadata.CreationDate)
+	if keyMetadata.DeletionDate != nil {
+		entity.AddLabel(labelKMSPendingDeletionAt, aws.String(keyMetadata.DeletionDate.UTC().Format("2006-01-02")))
+	}
 
-	for _, tag := range tags {
+	for _, tag := range details.Tags {
 		if tag == nil {
 			continue
 		}
@@ -61,29 +115,123 @@ func NewKMSKey(keyMetadata *kms.KeyMetadata, tags []*kms.Tag, region string) *Km
 	return entity
 }
 
-// EvalKMSKey walks through all kms keys
+// Remediate schedules the deletion of the key after the remediation's grace period, which AWS
+// requires to be between 7 and 30 days. The key is tagged with the policy that deleted it, so
+// whoever cancels the deletion knows why it was scheduled.
+func (k *KmsKey) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	if remediation.Action != policy.ActionDelete {
+		return errors.Errorf("kms key %s does not support the %s action", k.keyID, remediation.Action)
+	}
+	if k.keyManager == kms.KeyManagerTypeAws {
+		return errors.Errorf("kms key %s is managed by AWS", k.keyID)
+	}
+	if k.keyState == kms.KeyStatePendingDeletion {
+		return nil
+	}
+	pendingWindowDays := remediation.Delete.PendingWindowDaysOrDefault()
+	if pendingWindowDays < KMSMinPendingWindowDays || pendingWindowDays > KMSMaxPendingWindowDays {
+		return errors.Errorf("the pending window of kms keys must be between %d and %d days, not %d", KMSMinPendingWindowDays, KMSMaxPendingWindowDays, pendingWindowDays)
+	}
+
+	reason := "scheduled for deletion by reaper"
+	if remediation.Policy != "" {
+		reason = fmt.Sprintf("scheduled for deletion by reaper policy %s", remediation.Policy)
+	}
+	_, err := k.svc.TagResourceWithContext(ctx, &kms.TagResourceInput{
+		KeyId: &k.keyID,
+		Tags:  []*kms.Tag{{TagKey: aws.String(kmsKeyDeletionReasonTag), TagValue: &reason}},
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not tag kms key %s with its deletion reason", k.keyID)
+	}
+
+	log.Warnf("Scheduling the deletion of kms key %s in %d days", k.keyID, pendingWindowDays)
+	_, err = k.svc.ScheduleKeyDeletionWithContext(ctx, &kms.ScheduleKeyDeletionInput{
+		KeyId:               &k.keyID,
+		PendingWindowInDays: &pendingWindowDays,
+	})
+	return errors.Wrapf(err, "could not schedule the deletion of kms key %s", k.keyID)
+}
+
+// kmsKeyCanRotate returns true for keys supporting automatic rotation, which are symmetric keys
+// with key material generated by kms
+func kmsKeyCanRotate(keyMetadata *kms.KeyMetadata) bool {
+	return aws.StringValue(keyMetadata.CustomerMasterKeySpec) == kms.CustomerMasterKeySpecSymmetricDefault &&
+		aws.StringValue(keyMetadata.Origin) == kms.OriginTypeAwsKms
+}
+
+// countKMSAliases counts the aliases of each key in a region
+func countKMSAliases(ctx context.Context, svc kmsiface.KMSAPI) (map[string]int64, error) {
+	counts := map[string]int64{}
+	err := svc.ListAliasesPagesWithContext(ctx, &kms.ListAliasesInput{}, func(output *kms.ListAliasesOutput, lastPage bool) bool {
+		for _, alias := range output.Aliases {
+			if alias.TargetKeyId != nil {
+				counts[*alias.TargetKeyId]++
+			}
+		}
+		return true
+	})
+	return counts, errors.Wrap(err, "could not list kms aliases")
+}
+
+// getKMSKeyDetails looks up a key's tags and rotation status
+func getKMSKeyDetails(ctx context.Context, svc kmsiface.KMSAPI, keyMetadata *kms.KeyMetadata) (KMSKeyDetails, error) {
+	details := KMSKeyDetails{}
+	tags, err := svc.ListResourceTagsWithContext(ctx, &kms.ListResourceTagsInput{KeyId: keyMetadata.KeyId})
+	if err != nil {
+		return details, errors.Wrapf(err, "could not list tags for kms key %s", aws.StringValue(keyMetadata.KeyId))
+	}
+	details.Tags = tags.Tags
+
+	if !kmsKeyCanRotate(keyMetadata) || aws.StringValue(keyMetadata.KeyState) == kms.KeyStatePendingDeletion {
+		return details, nil
+	}
+	rotation, err := svc.GetKeyRotationStatusWithContext(ctx, &kms.GetKeyRotationStatusInput{KeyId: keyMetadata.KeyId})
+	if err != nil {
+		return details, errors.Wrapf(err, "could not get the rotation status of kms key %s", aws.StringValue(keyMetadata.KeyId))
+	}
+	details.RotationEnabled = rotation.KeyRotationEnabled
+	return details, nil
+}
+
+// EvalKMSKey walks through all customer managed kms keys
 func (c *Client) EvalKMSKey(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
 	var errs error
 	ctx := context.Background()
-	err := c.WalkAccountsAndRegions(accounts, regions, func(client *cziAws.Client, account *policy.Account, region string) {
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := kms.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		aliasCounts, err := countKMSAliases(ctx, svc)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+			return
+		}
 		input := &kms.ListKeysInput{}
-		err := client.KMS.Svc.ListKeysPagesWithContext(ctx, input, func(output *kms.ListKeysOutput, done bool) bool {
+		err = svc.ListKeysPagesWithContext(ctx, input, func(output *kms.ListKeysOutput, done bool) bool {
 			for _, key := range output.Keys {
-				if key != nil && key.KeyId != nil {
-					input := &kms.DescribeKeyInput{}
-					input.SetKeyId(*key.KeyId)
-					output, _ := client.KMS.Svc.DescribeKey(input)
-					keyMetadata := output.KeyMetadata
-
-					tagsInput := &kms.ListResourceTagsInput{KeyId: keyMetadata.KeyId}
-					tagsOutput, _ := client.KMS.Svc.ListResourceTags(tagsInput)
-					tags := tagsOutput.Tags
-
-					k := NewKMSKey(keyMetadata, tags, region)
-					if p.Match(k) {
-						violation := policy.NewViolation(p, k, false, account)
-						f(violation)
-					}
+				if key == nil || key.KeyId == nil {
+					continue
+				}
+				described, err := svc.DescribeKeyWithContext(ctx, &kms.DescribeKeyInput{KeyId: key.KeyId})
+				if err != nil {
+					errs = multierror.Append(errs, errors.Wrapf(err, "could not describe kms key %s", *key.KeyId))
+					continue
+				}
+				keyMetadata := described.KeyMetadata
+				if aws.StringValue(keyMetadata.KeyManager) == kms.KeyManagerTypeAws {
+					continue
+				}
+				details, err := getKMSKeyDetails(ctx, svc, keyMetadata)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				details.AliasCount = aliasCounts[*key.KeyId]
+
+				k := NewKMSKey(keyMetadata, details, region, svc)
+				if p.Match(k) {
+					violation := policy.NewViolation(p, k, p.Expired(k), account)
+					f(violation)
 				}
 			}
 			return true
@@ -94,3 +242,24 @@ func (c *Client) EvalKMSKey(accounts []*policy.Account, p policy.Policy, regions
 
 	return errs
 }
+
+func (c *Client) getKMSKey(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := kms.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	described, err := svc.DescribeKeyWithContext(ctx, &kms.DescribeKeyInput{KeyId: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe kms key %s", id)
+	}
+	keyMetadata := described.KeyMetadata
+	details, err := getKMSKeyDetails(ctx, svc, keyMetadata)
+	if err != nil {
+		return nil, err
+	}
+	err = svc.ListAliasesPagesWithContext(ctx, &kms.ListAliasesInput{KeyId: keyMetadata.KeyId}, func(output *kms.ListAliasesOutput, lastPage bool) bool {
+		details.AliasCount += int64(len(output.Aliases))
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list the aliases of kms key %s", id)
+	}
+	return NewKMSKey(keyMetadata, details, region, svc), nil
+}
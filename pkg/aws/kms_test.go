@@ -0,0 +1,112 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/kms"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewKMSKey(t *testing.T) {
+	a := assert.New(t)
+	deletionDate := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	key := reaperAws.NewKMSKey(&kms.KeyMetadata{
+		KeyId:                 aws.String("1234"),
+		KeyManager:            aws.String(kms.KeyManagerTypeCustomer),
+		KeyUsage:              aws.String(kms.KeyUsageTypeEncryptDecrypt),
+		KeyState:              aws.String(kms.KeyStatePendingDeletion),
+		CustomerMasterKeySpec: aws.String(kms.CustomerMasterKeySpecSymmetricDefault),
+		Origin:                aws.String(kms.OriginTypeAwsKms),
+		DeletionDate:          &deletionDate,
+		MultiRegion:           aws.Bool(true),
+	}, reaperAws.KMSKeyDetails{AliasCount: 2}, "us-west-2", nil)
+	labels := key.GetLabels()
+	a.Equal("CUSTOMER", labels["key_manager"])
+	a.Equal("ENCRYPT_DECRYPT", labels["key_usage"])
+	a.Equal("2024-03-01", labels["pending_deletion_date"])
+	a.Equal("true", labels["multi_region"])
+	a.Equal("true", labels["has_alias"])
+	a.Equal("2", labels["alias_count"])
+	a.NotContains(labels, "rotation_enabled")
+	a.NotContains(labels, "rotation_unsupported")
+
+	key = reaperAws.NewKMSKey(&kms.KeyMetadata{
+		KeyId:                 aws.String("5678"),
+		CustomerMasterKeySpec: aws.String(kms.CustomerMasterKeySpecRsa2048),
+		Origin:                aws.String(kms.OriginTypeAwsKms),
+	}, reaperAws.KMSKeyDetails{}, "us-west-2", nil)
+	labels = key.GetLabels()
+	a.Equal("true", labels["rotation_unsupported"])
+	a.Equal("0", labels["alias_count"])
+	a.NotContains(labels, "has_alias")
+	a.NotContains(labels, "multi_region")
+}
+
+func TestKMSKeyRemediate(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name        string
+		keyManager  string
+		keyState    string
+		remediation policy.Remediation
+		calls       []string
+		err         bool
+	}{
+		{
+			name:        "default waiting period",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStateEnabled,
+			remediation: policy.Remediation{Action: policy.ActionDelete, Policy: "unused-keys"},
+			calls:       []string{"tag scheduled for deletion by reaper policy unused-keys", "schedule 720h0m0s"},
+		},
+		{
+			name:        "configured waiting period",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStateDisabled,
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{PendingWindowDays: aws.Int64(7)}},
+			calls:       []string{"tag scheduled for deletion by reaper", "schedule 168h0m0s"},
+		},
+		{
+			name:        "too short waiting period",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStateEnabled,
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{PendingWindowDays: aws.Int64(1)}},
+			err:         true,
+		},
+		{
+			name:        "aws managed",
+			keyManager:  kms.KeyManagerTypeAws,
+			keyState:    kms.KeyStateEnabled,
+			remediation: policy.Remediation{Action: policy.ActionDelete},
+			err:         true,
+		},
+		{
+			name:        "already pending deletion",
+			keyManager:  kms.KeyManagerTypeCustomer,
+			keyState:    kms.KeyStatePendingDeletion,
+			remediation: policy.Remediation{Action: policy.ActionDelete},
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeKMS{}
+		key := reaperAws.NewKMSKey(&kms.KeyMetadata{
+			KeyId:      aws.String("1234"),
+			KeyManager: aws.String(test.keyManager),
+			KeyState:   aws.String(test.keyState),
+		}, reaperAws.KMSKeyDetails{}, "us-west-2", svc)
+		err := key.Remediate(test.remediation)
+		if test.err {
+			a.Error(err, test.name)
+		} else {
+			a.NoError(err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
@@ -0,0 +1,210 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	"github.com/aws/aws-sdk-go/service/lambda/lambdaiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// lambda_function specific labels
+const (
+	lambdaLabelRuntime           TypeEntityLabel = "runtime"
+	lambdaLabelRuntimeDeprecated TypeEntityLabel = "runtime_deprecated"
+	lambdaLabelMemorySize        TypeEntityLabel = "memory_size"
+	lambdaLabelTimeout           TypeEntityLabel = "timeout"
+	lambdaLabelLastModified      TypeEntityLabel = "last_modified"
+	lambdaLabelDaysSinceModified TypeEntityLabel = "days_since_modified"
+	lambdaLabelInVPC             TypeEntityLabel = "in_vpc"
+	lambdaLabelVpcID             TypeEntityLabel = "vpc_id"
+	lambdaLabelInvocations       TypeEntityLabel = "invocations"
+)
+
+// lambdaLastModifiedLayout is the format lambda reports LastModified in
+const lambdaLastModifiedLayout = "2006-01-02T15:04:05.000-0700"
+
+// lambdaRuntimeDeprecations maps from a runtime to the date AWS deprecated it, see
+// https://docs.aws.amazon.com/lambda/latest/dg/lambda-runtimes.html
+var lambdaRuntimeDeprecations = mustParseEndOfLife(map[string]string{
+	"nodejs":        "2016-10-31",
+	"nodejs4.3":     "2020-03-05",
+	"nodejs6.10":    "2019-08-12",
+	"nodejs8.10":    "2020-03-06",
+	"nodejs10.x":    "2021-07-30",
+	"nodejs12.x":    "2023-03-31",
+	"nodejs14.x":    "2023-12-04",
+	"nodejs16.x":    "2024-06-12",
+	"nodejs18.x":    "2025-09-01",
+	"python2.7":     "2021-07-15",
+	"python3.6":     "2022-07-18",
+	"python3.7":     "2023-12-04",
+	"python3.8":     "2024-10-14",
+	"python3.9":     "2025-12-15",
+	"java8":         "2024-01-08",
+	"go1.x":         "2024-01-08",
+	"provided":      "2024-01-08",
+	"dotnetcore1.0": "2019-07-30",
+	"dotnetcore2.0": "2019-05-30",
+	"dotnetcore2.1": "2022-01-05",
+	"dotnetcore3.1": "2023-04-03",
+	"dotnet5.0":     "2022-05-10",
+	"dotnet6":       "2024-12-20",
+	"dotnet7":       "2024-05-14",
+	"ruby2.5":       "2021-07-30",
+	"ruby2.7":       "2023-12-07",
+	"ruby3.2":       "2026-03-31",
+})
+
+// LambdaRuntimeDeprecated returns true if AWS deprecated runtime before now
+func LambdaRuntimeDeprecated(runtime string, now time.Time) bool {
+	return lambdaRuntimeDeprecations.ended(runtime, now)
+}
+
+// LambdaFunction is an evaluation entity representing a lambda function
+type LambdaFunction struct {
+	Entity
+	svc lambdaiface.LambdaAPI
+}
+
+// GetID returns the function name
+func (l *LambdaFunction) GetID() string {
+	return l.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (l *LambdaFunction) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/lambda/home?region=%s#/functions/%s"
+	return fmt.Sprintf(t, l.Region, l.Region, l.ID)
+}
+
+// NewLambdaFunction returns a new lambda function entity
+func NewLambdaFunction(function *lambda.FunctionConfiguration, tags map[string]*string, invocations float64, region string, svc lambdaiface.LambdaAPI) *LambdaFunction {
+	entity := &LambdaFunction{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if function == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if function.FunctionName != nil {
+		entity.ID = *function.FunctionName
+		entity.Name = *function.FunctionName
+	}
+
+	for key, value := range tags {
+		entity.AddTag(aws.String(key), value)
+	}
+
+	deprecated := LambdaRuntimeDeprecated(aws.StringValue(function.Runtime), time.Now())
+	inVPC := function.VpcConfig != nil && aws.StringValue(function.VpcConfig.VpcId) != ""
+	entity.
+		AddLabel(labelARN, function.FunctionArn).
+		AddLabel(lambdaLabelRuntime, function.Runtime).
+		AddBoolLabel(lambdaLabelRuntimeDeprecated, &deprecated).
+		AddInt64Label(lambdaLabelMemorySize, function.MemorySize).
+		AddInt64Label(lambdaLabelTimeout, function.Timeout).
+		AddBoolLabel(lambdaLabelInVPC, &inVPC).
+		AddLabel(lambdaLabelInvocations, formatMetric(invocations))
+	if inVPC {
+		entity.AddLabel(lambdaLabelVpcID, function.VpcConfig.VpcId)
+	}
+
+	if function.LastModified != nil {
+		lastModified, err := time.Parse(lambdaLastModifiedLayout, *function.LastModified)
+		if err != nil {
+			log.Warnf("could not parse last modified time %s of %s", *function.LastModified, entity.ID)
+		} else {
+			days := int64(math.Floor(time.Since(lastModified).Hours() / 24))
+			entity.
+				AddLabel(lambdaLabelLastModified, aws.String(lastModified.UTC().Format("2006-01-02"))).
+				AddInt64Label(lambdaLabelDaysSinceModified, &days)
+		}
+	}
+
+	return entity
+}
+
+// Delete deletes the function
+func (l *LambdaFunction) Delete() error {
+	log.Warnf("Deleting lambda function %s", l.ID)
+	_, err := l.svc.DeleteFunctionWithContext(context.Background(), &lambda.DeleteFunctionInput{FunctionName: &l.ID})
+	return errors.Wrapf(err, "could not delete lambda function %s", l.ID)
+}
+
+func getLambdaDetails(ctx context.Context, svc lambdaiface.LambdaAPI, cw cloudwatchiface.CloudWatchAPI, function *lambda.FunctionConfiguration, lookback time.Duration) (map[string]*string, float64, error) {
+	name := aws.StringValue(function.FunctionName)
+	tags, err := svc.ListTagsWithContext(ctx, &lambda.ListTagsInput{Resource: function.FunctionArn})
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "could not list tags of lambda function %s", name)
+	}
+	invocations, err := getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/Lambda",
+		Metric:     "Invocations",
+		Dimensions: map[string]string{"FunctionName": name},
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "could not get invocations of lambda function %s", name)
+	}
+	return tags.Tags, invocations, nil
+}
+
+// EvalLambdaFunction walks through all lambda functions
+func (c *Client) EvalLambdaFunction(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		cw := cloudwatch.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		svc := client.Lambda
+		err := svc.ListFunctionsPagesWithContext(ctx, &lambda.ListFunctionsInput{}, func(output *lambda.ListFunctionsOutput, lastPage bool) bool {
+			for _, function := range output.Functions {
+				// tags and invocations take a call each, skip them for functions that can't match
+				if !p.MightMatch(NewLambdaFunction(function, nil, 0, region, svc), []string{string(lambdaLabelInvocations)}, true) {
+					continue
+				}
+				tags, invocations, err := getLambdaDetails(ctx, svc, cw, function, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				l := NewLambdaFunction(function, tags, invocations, region, svc)
+				if p.Match(l) {
+					violation := policy.NewViolation(p, l, p.Expired(l), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getLambdaFunction(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	svc := client.Lambda
+	output, err := svc.GetFunctionConfigurationWithContext(ctx, &lambda.GetFunctionConfigurationInput{FunctionName: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get lambda function %s", id)
+	}
+	cw := cloudwatch.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	tags, invocations, err := getLambdaDetails(ctx, svc, cw, output, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewLambdaFunction(output, tags, invocations, region, svc), nil
+}
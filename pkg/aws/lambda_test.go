@@ -0,0 +1,76 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/lambda"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLambdaRuntimeDeprecated(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	a.True(reaperAws.LambdaRuntimeDeprecated("python2.7", now))
+	a.False(reaperAws.LambdaRuntimeDeprecated("python3.8", now))
+	a.True(reaperAws.LambdaRuntimeDeprecated("python3.8", now.AddDate(1, 0, 0)))
+	a.False(reaperAws.LambdaRuntimeDeprecated("python3.12", now))
+}
+
+func TestNewLambdaFunction(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name     string
+		function *lambda.FunctionConfiguration
+		labels   map[string]string
+		unset    []string
+	}{
+		{
+			name: "deprecated runtime in a vpc",
+			function: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("handler"),
+				Runtime:      aws.String("python2.7"),
+				MemorySize:   aws.Int64(128),
+				Timeout:      aws.Int64(3),
+				LastModified: aws.String("2020-01-02T03:04:05.000+0000"),
+				VpcConfig:    &lambda.VpcConfigResponse{VpcId: aws.String("vpc-1")},
+			},
+			labels: map[string]string{
+				"runtime":            "python2.7",
+				"runtime_deprecated": "true",
+				"memory_size":        "128",
+				"timeout":            "3",
+				"last_modified":      "2020-01-02",
+				"in_vpc":             "true",
+				"vpc_id":             "vpc-1",
+				"invocations":        "12",
+			},
+		},
+		{
+			name: "container image outside a vpc",
+			function: &lambda.FunctionConfiguration{
+				FunctionName: aws.String("handler"),
+				LastModified: aws.String("not a date"),
+				VpcConfig:    &lambda.VpcConfigResponse{VpcId: aws.String("")},
+			},
+			labels: map[string]string{"invocations": "12"},
+			unset:  []string{"runtime", "runtime_deprecated", "in_vpc", "vpc_id", "last_modified", "days_since_modified"},
+		},
+	}
+
+	for _, test := range tests {
+		function := reaperAws.NewLambdaFunction(test.function, map[string]*string{"team": aws.String("infra")}, 12, "us-west-2", nil)
+		labels := function.GetLabels()
+		for label, value := range test.labels {
+			a.Equal(value, labels[label], "%s: %s", test.name, label)
+		}
+		for _, label := range test.unset {
+			a.NotContains(labels, label, test.name)
+		}
+		a.Equal("infra", function.GetTags()["team"], test.name)
+	}
+}
@@ -0,0 +1,184 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs/cloudwatchlogsiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// log_group specific labels
+const (
+	logGroupLabelRetentionDays TypeEntityLabel = "retention_days"
+	logGroupLabelNeverExpire   TypeEntityLabel = "never_expire"
+	logGroupLabelStoredBytes   TypeEntityLabel = "stored_bytes"
+	logGroupLabelLastEvent     TypeEntityLabel = "last_event"
+	logGroupLabelIsEncrypted   TypeEntityLabel = "is_encrypted"
+	logGroupLabelKMSKeyID      TypeEntityLabel = "kms_key_id"
+)
+
+// LogGroupRetentionDays lists the retention periods CloudWatch Logs accepts
+var LogGroupRetentionDays = []int64{1, 3, 5, 7, 14, 30, 60, 90, 120, 150, 180, 365, 400, 545, 731, 1827, 3653}
+
+// LogGroup is an evaluation entity representing a CloudWatch Logs log group
+type LogGroup struct {
+	Entity
+	svc cloudwatchlogsiface.CloudWatchLogsAPI
+}
+
+// GetID returns the log group name
+func (l *LogGroup) GetID() string {
+	return l.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (l *LogGroup) GetConsoleURL() string {
+	// the console double escapes log group names in its fragment, with $ in place of %
+	name := strings.ReplaceAll(url.QueryEscape(url.QueryEscape(l.ID)), "%", "$")
+	t := "https://%s.console.aws.amazon.com/cloudwatch/home?region=%s#logsV2:log-groups/log-group/%s"
+	return fmt.Sprintf(t, l.Region, l.Region, name)
+}
+
+// NewLogGroup returns a new log group entity
+func NewLogGroup(group *cloudwatchlogs.LogGroup, tags map[string]*string, lastEventAt *time.Time, region string, svc cloudwatchlogsiface.CloudWatchLogsAPI) *LogGroup {
+	entity := &LogGroup{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if group == nil {
+		return entity
+	}
+
+	entity.Region = region
+	entity.ID = aws.StringValue(group.LogGroupName)
+	entity.Name = entity.ID
+
+	for key, value := range tags {
+		entity.AddTag(aws.String(key), value)
+	}
+
+	neverExpire := group.RetentionInDays == nil
+	encrypted := group.KmsKeyId != nil
+	entity.
+		AddLabel(labelARN, group.Arn).
+		AddInt64Label(logGroupLabelRetentionDays, group.RetentionInDays).
+		AddBoolLabel(logGroupLabelNeverExpire, &neverExpire).
+		AddInt64Label(logGroupLabelStoredBytes, group.StoredBytes).
+		AddBoolLabel(logGroupLabelIsEncrypted, &encrypted).
+		AddLabel(logGroupLabelKMSKeyID, group.KmsKeyId)
+	if lastEventAt != nil {
+		entity.AddLabel(logGroupLabelLastEvent, aws.String(lastEventAt.UTC().Format("2006-01-02")))
+	}
+	if group.CreationTime != nil {
+		createdAt := time.Unix(0, *group.CreationTime*int64(time.Millisecond))
+		entity.AddCreatedAt(&createdAt)
+	}
+
+	return entity
+}
+
+// Remediate sets the retention period of the log group, the only remediation we support since
+// deleting logs is rarely what anybody wants
+func (l *LogGroup) Remediate(remediation policy.Remediation) error {
+	if remediation.Action != policy.ActionSetRetention {
+		return errors.Errorf("log group %s does not support the %s action", l.ID, remediation.Action)
+	}
+	log.Warnf("Setting retention of log group %s to %d days", l.ID, remediation.SetRetention.Days)
+	_, err := l.svc.PutRetentionPolicyWithContext(context.Background(), &cloudwatchlogs.PutRetentionPolicyInput{
+		LogGroupName:    &l.ID,
+		RetentionInDays: aws.Int64(remediation.SetRetention.Days),
+	})
+	return errors.Wrapf(err, "could not set retention of log group %s", l.ID)
+}
+
+// Delete is not supported, set a retention period instead
+func (l *LogGroup) Delete() error {
+	return errors.Errorf("log group %s can not be deleted, set a retention period instead", l.ID)
+}
+
+// getLogGroupDetails looks up the tags of a log group and the time of its latest event
+func getLogGroupDetails(ctx context.Context, svc cloudwatchlogsiface.CloudWatchLogsAPI, name *string) (map[string]*string, *time.Time, error) {
+	tags, err := svc.ListTagsLogGroupWithContext(ctx, &cloudwatchlogs.ListTagsLogGroupInput{LogGroupName: name})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not list tags of log group %s", aws.StringValue(name))
+	}
+
+	streams, err := svc.DescribeLogStreamsWithContext(ctx, &cloudwatchlogs.DescribeLogStreamsInput{
+		LogGroupName: name,
+		OrderBy:      aws.String(cloudwatchlogs.OrderByLastEventTime),
+		Descending:   aws.Bool(true),
+		Limit:        aws.Int64(1),
+	})
+	if err != nil {
+		return nil, nil, errors.Wrapf(err, "could not describe streams of log group %s", aws.StringValue(name))
+	}
+	var lastEventAt *time.Time
+	for _, stream := range streams.LogStreams {
+		if stream.LastEventTimestamp != nil {
+			t := time.Unix(0, *stream.LastEventTimestamp*int64(time.Millisecond))
+			lastEventAt = &t
+		}
+	}
+	return tags.Tags, lastEventAt, nil
+}
+
+// EvalLogGroup walks through all log groups
+func (c *Client) EvalLogGroup(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := cloudwatchlogs.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		var groups []*cloudwatchlogs.LogGroup
+		err := svc.DescribeLogGroupsPagesWithContext(ctx, &cloudwatchlogs.DescribeLogGroupsInput{}, func(output *cloudwatchlogs.DescribeLogGroupsOutput, lastPage bool) bool {
+			groups = append(groups, output.LogGroups...)
+			return true
+		})
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not describe log groups in %s %s", account.Name, region))
+			return
+		}
+		for _, group := range groups {
+			tags, lastEventAt, err := getLogGroupDetails(ctx, svc, group.LogGroupName)
+			if err != nil {
+				errs = multierror.Append(errs, err)
+				continue
+			}
+			l := NewLogGroup(group, tags, lastEventAt, region, svc)
+			if p.Match(l) {
+				violation := policy.NewViolation(p, l, p.Expired(l), account)
+				f(violation)
+			}
+		}
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getLogGroup(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := cloudwatchlogs.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	output, err := svc.DescribeLogGroupsWithContext(ctx, &cloudwatchlogs.DescribeLogGroupsInput{LogGroupNamePrefix: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe log group %s", id)
+	}
+	for _, group := range output.LogGroups {
+		if aws.StringValue(group.LogGroupName) != id {
+			continue
+		}
+		tags, lastEventAt, err := getLogGroupDetails(ctx, svc, group.LogGroupName)
+		if err != nil {
+			return nil, err
+		}
+		return NewLogGroup(group, tags, lastEventAt, region, svc), nil
+	}
+	return nil, errors.Errorf("log group %s not found in %s", id, region)
+}
@@ -0,0 +1,87 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatchlogs"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewLogGroup(t *testing.T) {
+	a := assert.New(t)
+	created := time.Date(2021, 6, 1, 0, 0, 0, 0, time.UTC)
+	lastEvent := time.Date(2021, 9, 14, 18, 30, 0, 0, time.UTC)
+
+	group := reaperAws.NewLogGroup(&cloudwatchlogs.LogGroup{
+		LogGroupName: aws.String("/app/web"),
+		Arn:          aws.String("arn:aws:logs:us-west-2:123456789012:log-group:/app/web:*"),
+		StoredBytes:  aws.Int64(4096),
+		CreationTime: aws.Int64(created.UnixNano() / int64(time.Millisecond)),
+	}, map[string]*string{"owner": aws.String("web@example.com")}, &lastEvent, "us-west-2", nil)
+	a.Equal("/app/web", group.GetID())
+	a.Equal("web@example.com", group.GetTags()["owner"])
+	a.Equal(created, group.GetCreatedAt().UTC())
+	labels := group.GetLabels()
+	a.Equal("true", labels["never_expire"])
+	a.NotContains(labels, "retention_days")
+	a.Equal("4096", labels["stored_bytes"])
+	a.Equal("2021-09-14", labels["last_event"])
+	a.NotContains(labels, "is_encrypted")
+	a.NotContains(labels, "kms_key_id")
+
+	group = reaperAws.NewLogGroup(&cloudwatchlogs.LogGroup{
+		LogGroupName:    aws.String("/app/web"),
+		RetentionInDays: aws.Int64(30),
+		KmsKeyId:        aws.String("arn:aws:kms:us-west-2:123456789012:key/1234"),
+	}, nil, nil, "us-west-2", nil)
+	labels = group.GetLabels()
+	a.NotContains(labels, "never_expire")
+	a.Equal("30", labels["retention_days"])
+	a.NotContains(labels, "last_event")
+	a.Equal("true", labels["is_encrypted"])
+	a.Equal("arn:aws:kms:us-west-2:123456789012:key/1234", labels["kms_key_id"])
+}
+
+func TestLogGroupRemediate(t *testing.T) {
+	tests := []struct {
+		name        string
+		remediation policy.Remediation
+		calls       []string
+		err         string
+	}{
+		{
+			name:        "set retention",
+			remediation: policy.Remediation{Action: policy.ActionSetRetention, SetRetention: policy.SetRetentionOptions{Days: 90}},
+			calls:       []string{"retention 90 /app/web"},
+		},
+		{
+			name:        "delete",
+			remediation: policy.Remediation{Action: policy.ActionDelete},
+			err:         "log group /app/web does not support the delete action",
+		},
+		{
+			name:        "stop",
+			remediation: policy.Remediation{Action: policy.ActionStop},
+			err:         "log group /app/web does not support the stop action",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			svc := &fakeCloudWatchLogs{}
+			group := reaperAws.NewLogGroup(&cloudwatchlogs.LogGroup{LogGroupName: aws.String("/app/web")}, nil, nil, "us-west-2", svc)
+			err := test.remediation.Apply(group)
+			if test.err != "" {
+				a.Error(err)
+				a.Contains(err.Error(), test.err)
+			} else {
+				a.NoError(err)
+			}
+			a.Equal(test.calls, svc.calls)
+		})
+	}
+}
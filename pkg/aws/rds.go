@@ -0,0 +1,429 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	"github.com/aws/aws-sdk-go/service/rds/rdsiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// rds specific labels
+const (
+	rdsLabelEngine             TypeEntityLabel = "engine"
+	rdsLabelEngineVersion      TypeEntityLabel = "engine_version"
+	rdsLabelEngineMode         TypeEntityLabel = "engine_mode"
+	rdsLabelInstanceClass      TypeEntityLabel = "instance_class"
+	rdsLabelMultiAZ            TypeEntityLabel = "multi_az"
+	rdsLabelStorageType        TypeEntityLabel = "storage_type"
+	rdsLabelAllocatedStorage   TypeEntityLabel = "allocated_storage"
+	rdsLabelIsEncrypted        TypeEntityLabel = "is_encrypted"
+	rdsLabelPubliclyAccessible TypeEntityLabel = "publicly_accessible"
+	rdsLabelDeletionProtection TypeEntityLabel = "deletion_protection"
+	rdsLabelStatus             TypeEntityLabel = "status"
+	rdsLabelClusterID          TypeEntityLabel = "cluster_id"
+	rdsLabelMemberCount        TypeEntityLabel = "member_count"
+)
+
+// rds instance and cluster statuses remediation looks at, the SDK doesn't define them
+const (
+	rdsStatusAvailable = "available"
+	rdsStatusStopping  = "stopping"
+	rdsStatusStopped   = "stopped"
+	rdsStatusDeleting  = "deleting"
+)
+
+// finalSnapshotID returns an identifier for the snapshot taken before deleting a database
+func finalSnapshotID(id string) string {
+	return fmt.Sprintf("reaper-final-%s-%s", id, time.Now().UTC().Format("20060102150405"))
+}
+
+// RDSInstance is an evaluation entity representing an rds db instance
+type RDSInstance struct {
+	Entity
+	clusterID          string
+	deletionProtection bool
+	status             string
+	replicaSource      string
+	replicas           []*string
+	svc                rdsiface.RDSAPI
+}
+
+// GetID returns the db instance identifier
+func (r *RDSInstance) GetID() string {
+	return r.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (r *RDSInstance) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/rds/home?region=%s#database:id=%s;is-cluster=false"
+	return fmt.Sprintf(t, r.Region, r.Region, r.ID)
+}
+
+// NewRDSInstance returns a new rds instance entity
+func NewRDSInstance(instance *rds.DBInstance, tags []*rds.Tag, region string, svc rdsiface.RDSAPI) *RDSInstance {
+	entity := &RDSInstance{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if instance == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if instance.DBInstanceIdentifier != nil {
+		entity.ID = *instance.DBInstanceIdentifier
+		entity.Name = *instance.DBInstanceIdentifier
+	}
+	if instance.DBClusterIdentifier != nil {
+		entity.clusterID = *instance.DBClusterIdentifier
+	}
+	entity.deletionProtection = aws.BoolValue(instance.DeletionProtection)
+	entity.status = aws.StringValue(instance.DBInstanceStatus)
+	entity.replicaSource = aws.StringValue(instance.ReadReplicaSourceDBInstanceIdentifier)
+	entity.replicas = instance.ReadReplicaDBInstanceIdentifiers
+
+	for _, tag := range tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	entity.
+		AddLabel(labelARN, instance.DBInstanceArn).
+		AddLabel(rdsLabelEngine, instance.Engine).
+		AddLabel(rdsLabelEngineVersion, instance.EngineVersion).
+		AddLabel(rdsLabelInstanceClass, instance.DBInstanceClass).
+		AddBoolLabel(rdsLabelMultiAZ, instance.MultiAZ).
+		AddLabel(rdsLabelStorageType, instance.StorageType).
+		AddInt64Label(rdsLabelAllocatedStorage, instance.AllocatedStorage).
+		AddBoolLabel(rdsLabelIsEncrypted, instance.StorageEncrypted).
+		AddBoolLabel(rdsLabelPubliclyAccessible, instance.PubliclyAccessible).
+		AddBoolLabel(rdsLabelDeletionProtection, instance.DeletionProtection).
+		AddLabel(rdsLabelStatus, instance.DBInstanceStatus).
+		AddLabel(rdsLabelClusterID, instance.DBClusterIdentifier).
+		AddCreatedAt(instance.InstanceCreateTime)
+
+	return entity
+}
+
+// Delete deletes the instance, taking a final snapshot first
+func (r *RDSInstance) Delete() error {
+	return r.Remediate(policy.Remediation{Action: policy.ActionDelete})
+}
+
+// Remediate stops the instance, or deletes it after taking a final snapshot. Cluster members, read
+// replicas and instances with read replicas are refused, as are instances which are neither
+// available nor stopped.
+func (r *RDSInstance) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	if r.clusterID != "" {
+		return errors.Errorf("rds instance %s is part of cluster %s, remediate the cluster instead", r.ID, r.clusterID)
+	}
+	if r.replicaSource != "" {
+		return errors.Errorf("rds instance %s is a read replica of %s", r.ID, r.replicaSource)
+	}
+	if len(r.replicas) > 0 {
+		return errors.Errorf("rds instance %s has read replicas %s", r.ID, strings.Join(aws.StringValueSlice(r.replicas), ", "))
+	}
+
+	switch remediation.Action {
+	case policy.ActionStop:
+		switch r.status {
+		case rdsStatusStopping, rdsStatusStopped:
+			log.Infof("rds instance %s is already %s", r.ID, r.status)
+			return nil
+		case rdsStatusAvailable:
+		default:
+			return errors.Errorf("rds instance %s is %s, only available instances are stopped", r.ID, r.status)
+		}
+		log.Warnf("Stopping rds instance %s", r.ID)
+		_, err := r.svc.StopDBInstanceWithContext(ctx, &rds.StopDBInstanceInput{DBInstanceIdentifier: &r.ID})
+		return errors.Wrapf(err, "could not stop rds instance %s", r.ID)
+	case policy.ActionDelete:
+		if r.deletionProtection {
+			return errors.Errorf("rds instance %s has deletion protection enabled", r.ID)
+		}
+		switch r.status {
+		case rdsStatusDeleting:
+			log.Infof("rds instance %s is already deleting", r.ID)
+			return nil
+		case rdsStatusAvailable, rdsStatusStopped:
+		default:
+			return errors.Errorf("rds instance %s is %s, only available and stopped instances are deleted", r.ID, r.status)
+		}
+		snapshotID := finalSnapshotID(r.ID)
+		log.Warnf("Deleting rds instance %s with final snapshot %s", r.ID, snapshotID)
+		input := &rds.DeleteDBInstanceInput{
+			DBInstanceIdentifier:      &r.ID,
+			FinalDBSnapshotIdentifier: &snapshotID,
+		}
+		_, err := r.svc.DeleteDBInstanceWithContext(ctx, input)
+		return errors.Wrapf(err, "could not delete rds instance %s", r.ID)
+	}
+	return errors.Errorf("rds instance %s does not support the %s action", r.ID, remediation.Action)
+}
+
+// RDSCluster is an evaluation entity representing an rds db cluster, such as an aurora cluster
+type RDSCluster struct {
+	Entity
+	members            []string
+	deletionProtection bool
+	status             string
+	svc                rdsiface.RDSAPI
+}
+
+// GetID returns the db cluster identifier
+func (r *RDSCluster) GetID() string {
+	return r.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (r *RDSCluster) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/rds/home?region=%s#database:id=%s;is-cluster=true"
+	return fmt.Sprintf(t, r.Region, r.Region, r.ID)
+}
+
+// NewRDSCluster returns a new rds cluster entity
+func NewRDSCluster(cluster *rds.DBCluster, tags []*rds.Tag, region string, svc rdsiface.RDSAPI) *RDSCluster {
+	entity := &RDSCluster{
+		Entity: NewEntity(),
+		svc:    svc,
+	}
+	if cluster == nil {
+		return entity
+	}
+
+	entity.Region = region
+	if cluster.DBClusterIdentifier != nil {
+		entity.ID = *cluster.DBClusterIdentifier
+		entity.Name = *cluster.DBClusterIdentifier
+	}
+	entity.deletionProtection = aws.BoolValue(cluster.DeletionProtection)
+	entity.status = aws.StringValue(cluster.Status)
+	for _, member := range cluster.DBClusterMembers {
+		if member != nil && member.DBInstanceIdentifier != nil {
+			entity.members = append(entity.members, *member.DBInstanceIdentifier)
+		}
+	}
+
+	for _, tag := range tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+	memberCount := int64(len(entity.members))
+	entity.
+		AddLabel(labelARN, cluster.DBClusterArn).
+		AddLabel(rdsLabelEngine, cluster.Engine).
+		AddLabel(rdsLabelEngineVersion, cluster.EngineVersion).
+		AddLabel(rdsLabelEngineMode, cluster.EngineMode).
+		AddBoolLabel(rdsLabelMultiAZ, cluster.MultiAZ).
+		AddInt64Label(rdsLabelAllocatedStorage, cluster.AllocatedStorage).
+		AddBoolLabel(rdsLabelIsEncrypted, cluster.StorageEncrypted).
+		AddBoolLabel(rdsLabelDeletionProtection, cluster.DeletionProtection).
+		AddLabel(rdsLabelStatus, cluster.Status).
+		AddInt64Label(rdsLabelMemberCount, &memberCount).
+		AddCreatedAt(cluster.ClusterCreateTime)
+
+	return entity
+}
+
+// Delete deletes the cluster, taking a final snapshot first
+func (r *RDSCluster) Delete() error {
+	return r.Remediate(policy.Remediation{Action: policy.ActionDelete})
+}
+
+// Remediate stops the cluster, or deletes its instances and then the cluster after taking a
+// final snapshot. Cluster storage outlives the instances, so the snapshot covers them. Nothing is
+// deleted unless the cluster and every instance in it can be.
+func (r *RDSCluster) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	switch remediation.Action {
+	case policy.ActionStop:
+		switch r.status {
+		case rdsStatusStopping, rdsStatusStopped:
+			log.Infof("rds cluster %s is already %s", r.ID, r.status)
+			return nil
+		case rdsStatusAvailable:
+		default:
+			return errors.Errorf("rds cluster %s is %s, only available clusters are stopped", r.ID, r.status)
+		}
+		log.Warnf("Stopping rds cluster %s", r.ID)
+		_, err := r.svc.StopDBClusterWithContext(ctx, &rds.StopDBClusterInput{DBClusterIdentifier: &r.ID})
+		return errors.Wrapf(err, "could not stop rds cluster %s", r.ID)
+	case policy.ActionDelete:
+		if r.deletionProtection {
+			return errors.Errorf("rds cluster %s has deletion protection enabled", r.ID)
+		}
+		switch r.status {
+		case rdsStatusDeleting:
+			log.Infof("rds cluster %s is already deleting", r.ID)
+			return nil
+		case rdsStatusAvailable, rdsStatusStopped:
+		default:
+			return errors.Errorf("rds cluster %s is %s, only available and stopped clusters are deleted", r.ID, r.status)
+		}
+		members, err := r.deletableMembers(ctx)
+		if err != nil {
+			return err
+		}
+		for _, member := range members {
+			log.Warnf("Deleting rds instance %s in cluster %s", member, r.ID)
+			input := &rds.DeleteDBInstanceInput{DBInstanceIdentifier: aws.String(member)}
+			_, err := r.svc.DeleteDBInstanceWithContext(ctx, input)
+			if err != nil {
+				return errors.Wrapf(err, "could not delete rds instance %s in cluster %s", member, r.ID)
+			}
+		}
+		snapshotID := finalSnapshotID(r.ID)
+		log.Warnf("Deleting rds cluster %s with final snapshot %s", r.ID, snapshotID)
+		input := &rds.DeleteDBClusterInput{
+			DBClusterIdentifier:       &r.ID,
+			FinalDBSnapshotIdentifier: &snapshotID,
+		}
+		_, err = r.svc.DeleteDBClusterWithContext(ctx, input)
+		return errors.Wrapf(err, "could not delete rds cluster %s", r.ID)
+	}
+	return errors.Errorf("rds cluster %s does not support the %s action", r.ID, remediation.Action)
+}
+
+// deletableMembers describes the instances in the cluster now, rather than when it was evaluated,
+// and returns their ids. It fails if any of them can't be deleted, so that no instance is deleted
+// unless all of them can be.
+func (r *RDSCluster) deletableMembers(ctx context.Context) ([]string, error) {
+	var members []string
+	var errs error
+	input := &rds.DescribeDBInstancesInput{
+		Filters: []*rds.Filter{{Name: aws.String("db-cluster-id"), Values: aws.StringSlice([]string{r.ID})}},
+	}
+	err := r.svc.DescribeDBInstancesPagesWithContext(ctx, input, func(output *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+		for _, instance := range output.DBInstances {
+			id := aws.StringValue(instance.DBInstanceIdentifier)
+			status := aws.StringValue(instance.DBInstanceStatus)
+			switch {
+			case aws.BoolValue(instance.DeletionProtection):
+				errs = multierror.Append(errs, errors.Errorf("rds instance %s in cluster %s has deletion protection enabled", id, r.ID))
+			case status == rdsStatusDeleting:
+			case status != rdsStatusAvailable && status != rdsStatusStopped:
+				errs = multierror.Append(errs, errors.Errorf("rds instance %s in cluster %s is %s", id, r.ID, status))
+			default:
+				members = append(members, id)
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe the instances in rds cluster %s", r.ID)
+	}
+	if errs != nil {
+		return nil, errors.Wrapf(errs, "refusing to delete rds cluster %s", r.ID)
+	}
+	return members, nil
+}
+
+func rdsTags(ctx context.Context, svc rdsiface.RDSAPI, arn *string) ([]*rds.Tag, error) {
+	output, err := svc.ListTagsForResourceWithContext(ctx, &rds.ListTagsForResourceInput{ResourceName: arn})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list tags for %s", aws.StringValue(arn))
+	}
+	return output.TagList, nil
+}
+
+// EvalRDSInstance walks through all rds instances
+func (c *Client) EvalRDSInstance(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := rds.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		input := &rds.DescribeDBInstancesInput{}
+		err := svc.DescribeDBInstancesPagesWithContext(ctx, input, func(output *rds.DescribeDBInstancesOutput, lastPage bool) bool {
+			for _, instance := range output.DBInstances {
+				tags, err := rdsTags(ctx, svc, instance.DBInstanceArn)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				i := NewRDSInstance(instance, tags, region, svc)
+				if p.Match(i) {
+					violation := policy.NewViolation(p, i, p.Expired(i), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+// EvalRDSCluster walks through all rds clusters
+func (c *Client) EvalRDSCluster(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		svc := rds.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+		input := &rds.DescribeDBClustersInput{}
+		err := svc.DescribeDBClustersPagesWithContext(ctx, input, func(output *rds.DescribeDBClustersOutput, lastPage bool) bool {
+			for _, cluster := range output.DBClusters {
+				tags, err := rdsTags(ctx, svc, cluster.DBClusterArn)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				r := NewRDSCluster(cluster, tags, region, svc)
+				if p.Match(r) {
+					violation := policy.NewViolation(p, r, p.Expired(r), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getRDSInstance(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := rds.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	output, err := svc.DescribeDBInstancesWithContext(ctx, &rds.DescribeDBInstancesInput{DBInstanceIdentifier: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe rds instance %s", id)
+	}
+	for _, instance := range output.DBInstances {
+		tags, err := rdsTags(ctx, svc, instance.DBInstanceArn)
+		if err != nil {
+			return nil, err
+		}
+		return NewRDSInstance(instance, tags, region, svc), nil
+	}
+	return nil, errors.Errorf("rds instance %s not found in %s", id, region)
+}
+
+func (c *Client) getRDSCluster(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	svc := rds.New(c.GetSession(account.ID, account.Role, account.ExternalID, region))
+	output, err := svc.DescribeDBClustersWithContext(ctx, &rds.DescribeDBClustersInput{DBClusterIdentifier: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe rds cluster %s", id)
+	}
+	for _, cluster := range output.DBClusters {
+		tags, err := rdsTags(ctx, svc, cluster.DBClusterArn)
+		if err != nil {
+			return nil, err
+		}
+		return NewRDSCluster(cluster, tags, region, svc), nil
+	}
+	return nil, errors.Errorf("rds cluster %s not found in %s", id, region)
+}
@@ -0,0 +1,209 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/rds"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewRDSInstance(t *testing.T) {
+	a := assert.New(t)
+	created := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	instance := reaperAws.NewRDSInstance(&rds.DBInstance{
+		DBInstanceIdentifier: aws.String("db-1"),
+		DBInstanceArn:        aws.String("arn:aws:rds:us-west-2:123:db:db-1"),
+		Engine:               aws.String("postgres"),
+		DBInstanceClass:      aws.String("db.t3.micro"),
+		DBInstanceStatus:     aws.String("available"),
+		AllocatedStorage:     aws.Int64(20),
+		StorageEncrypted:     aws.Bool(true),
+		PubliclyAccessible:   aws.Bool(false),
+		InstanceCreateTime:   &created,
+	}, []*rds.Tag{{Key: aws.String("env"), Value: aws.String("dev")}}, "us-west-2", nil)
+	labels := instance.GetLabels()
+	a.Equal("db-1", instance.GetID())
+	a.Equal("postgres", labels["engine"])
+	a.Equal("db.t3.micro", labels["instance_class"])
+	a.Equal("available", labels["status"])
+	a.Equal("20", labels["allocated_storage"])
+	a.Equal("true", labels["is_encrypted"])
+	a.NotContains(labels, "publicly_accessible")
+	a.NotContains(labels, "cluster_id")
+	a.Equal("dev", instance.GetTags()["env"])
+	a.Equal(created, *instance.GetCreatedAt())
+
+	cluster := reaperAws.NewRDSCluster(&rds.DBCluster{
+		DBClusterIdentifier: aws.String("cluster-1"),
+		Engine:              aws.String("aurora-postgresql"),
+		Status:              aws.String("available"),
+		DBClusterMembers: []*rds.DBClusterMember{
+			{DBInstanceIdentifier: aws.String("db-1")},
+			{DBInstanceIdentifier: aws.String("db-2")},
+		},
+	}, nil, "us-west-2", nil)
+	a.Equal("2", cluster.GetLabels()["member_count"])
+}
+
+func TestRDSInstanceRemediate(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		name     string
+		instance *rds.DBInstance
+		action   string
+		calls    []string
+		err      string
+	}{
+		{
+			name:     "stop",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("available")},
+			action:   policy.ActionStop,
+			calls:    []string{"stop db-1"},
+		},
+		{
+			name:     "already stopped",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("stopped")},
+			action:   policy.ActionStop,
+		},
+		{
+			name:     "stop while modifying",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("modifying")},
+			action:   policy.ActionStop,
+			err:      "rds instance db-1 is modifying",
+		},
+		{
+			name:     "delete",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("stopped")},
+			action:   policy.ActionDelete,
+			calls:    []string{"delete db-1"},
+		},
+		{
+			name:     "already deleting",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("deleting")},
+			action:   policy.ActionDelete,
+		},
+		{
+			name:     "delete while backing up",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("backing-up")},
+			action:   policy.ActionDelete,
+			err:      "rds instance db-1 is backing-up",
+		},
+		{
+			name:     "deletion protection",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("available"), DeletionProtection: aws.Bool(true)},
+			action:   policy.ActionDelete,
+			err:      "deletion protection",
+		},
+		{
+			name:     "cluster member",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("available"), DBClusterIdentifier: aws.String("cluster-1")},
+			action:   policy.ActionStop,
+			err:      "part of cluster cluster-1",
+		},
+		{
+			name:     "read replica",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("available"), ReadReplicaSourceDBInstanceIdentifier: aws.String("db-0")},
+			action:   policy.ActionDelete,
+			err:      "read replica of db-0",
+		},
+		{
+			name:     "with read replicas",
+			instance: &rds.DBInstance{DBInstanceStatus: aws.String("available"), ReadReplicaDBInstanceIdentifiers: aws.StringSlice([]string{"db-2"})},
+			action:   policy.ActionStop,
+			err:      "has read replicas db-2",
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeRDS{}
+		test.instance.DBInstanceIdentifier = aws.String("db-1")
+		instance := reaperAws.NewRDSInstance(test.instance, nil, "us-west-2", svc)
+		err := instance.Remediate(policy.Remediation{Action: test.action})
+		if test.err == "" {
+			a.NoError(err, test.name)
+		} else if a.Error(err, test.name) {
+			a.Contains(err.Error(), test.err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
+
+func TestRDSClusterRemediate(t *testing.T) {
+	a := assert.New(t)
+	member := func(id, status string) *rds.DBInstance {
+		return &rds.DBInstance{DBInstanceIdentifier: aws.String(id), DBInstanceStatus: aws.String(status)}
+	}
+
+	tests := []struct {
+		name      string
+		status    string
+		action    string
+		instances []*rds.DBInstance
+		calls     []string
+		err       string
+	}{
+		{
+			name:   "stop",
+			status: "available",
+			action: policy.ActionStop,
+			calls:  []string{"stop cluster-1"},
+		},
+		{
+			name:   "already stopping",
+			status: "stopping",
+			action: policy.ActionStop,
+		},
+		{
+			name:      "delete",
+			status:    "available",
+			action:    policy.ActionDelete,
+			instances: []*rds.DBInstance{member("db-1", "available"), member("db-2", "stopped"), member("db-3", "deleting")},
+			calls:     []string{"delete db-1", "delete db-2", "delete cluster-1"},
+		},
+		{
+			name:      "member not deletable",
+			status:    "available",
+			action:    policy.ActionDelete,
+			instances: []*rds.DBInstance{member("db-1", "available"), member("db-2", "upgrading")},
+			err:       "rds instance db-2 in cluster cluster-1 is upgrading",
+		},
+		{
+			name:   "member with deletion protection",
+			status: "available",
+			action: policy.ActionDelete,
+			instances: []*rds.DBInstance{
+				member("db-1", "available"),
+				{DBInstanceIdentifier: aws.String("db-2"), DBInstanceStatus: aws.String("available"), DeletionProtection: aws.Bool(true)},
+			},
+			err: "rds instance db-2 in cluster cluster-1 has deletion protection enabled",
+		},
+		{
+			name:      "cluster not deletable",
+			status:    "backing-up",
+			action:    policy.ActionDelete,
+			instances: []*rds.DBInstance{member("db-1", "available")},
+			err:       "rds cluster cluster-1 is backing-up",
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeRDS{instances: test.instances}
+		cluster := reaperAws.NewRDSCluster(&rds.DBCluster{
+			DBClusterIdentifier: aws.String("cluster-1"),
+			Status:              aws.String(test.status),
+		}, nil, "us-west-2", svc)
+		err := cluster.Remediate(policy.Remediation{Action: test.action})
+		if test.err == "" {
+			a.NoError(err, test.name)
+		} else if a.Error(err, test.name) {
+			a.Contains(err.Error(), test.err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
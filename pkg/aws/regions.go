@@ -0,0 +1,88 @@
+package aws
+
+import (
+	"context"
+	"path"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// AccountRegions returns the regions to scan in account. The account's own regions override
+// the regions passed in, and we discover the regions enabled in the account when neither is
+// set or either is AllRegions. The account's allow and deny lists are applied last.
+func (c *Client) AccountRegions(account *policy.Account, regions []string) ([]string, error) {
+	if len(account.Regions) > 0 {
+		regions = account.Regions
+	}
+
+	if len(regions) == 0 || containsString(regions, policy.AllRegions) {
+		discovered, err := c.discoverRegions(account)
+		if err != nil {
+			return nil, err
+		}
+		regions = discovered
+	}
+
+	var res []string
+	for _, region := range regions {
+		if len(account.AllowRegions) > 0 && !matchesAny(account.AllowRegions, region) {
+			continue
+		}
+		if matchesAny(account.DenyRegions, region) {
+			continue
+		}
+		res = append(res, region)
+	}
+	return res, nil
+}
+
+// discoverRegions returns every region enabled in the account, including opted in regions
+func (c *Client) discoverRegions(account *policy.Account) ([]string, error) {
+	if regions, ok := c.discoveredRegions[account.ID]; ok {
+		return regions, nil
+	}
+
+	client := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion)
+	input := &ec2.DescribeRegionsInput{
+		Filters: []*ec2.Filter{{
+			Name:   aws.String("opt-in-status"),
+			Values: aws.StringSlice([]string{"opt-in-not-required", "opted-in"}),
+		}},
+	}
+	output, err := client.EC2.DescribeRegionsWithContext(context.Background(), input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not discover regions in account %s", account.Name)
+	}
+
+	regions := []string{}
+	for _, region := range output.Regions {
+		if region.RegionName != nil {
+			regions = append(regions, *region.RegionName)
+		}
+	}
+	log.Debugf("discovered regions %v in account %s", regions, account.Name)
+	c.discoveredRegions[account.ID] = regions
+	return regions, nil
+}
+
+func matchesAny(patterns []string, region string) bool {
+	for _, pattern := range patterns {
+		if ok, _ := path.Match(pattern, region); ok {
+			return true
+		}
+	}
+	return false
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
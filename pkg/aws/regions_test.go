@@ -0,0 +1,27 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountRegions(t *testing.T) {
+	a := assert.New(t)
+	c, err := aws.NewClient(nil, nil)
+	a.NoError(err)
+
+	account := &policy.Account{}
+	regions, err := c.AccountRegions(account, []string{"us-east-1", "us-west-2", "eu-west-1"})
+	a.NoError(err)
+	a.Equal([]string{"us-east-1", "us-west-2", "eu-west-1"}, regions)
+
+	account.Regions = []string{"us-west-2", "eu-west-1", "ap-east-1"}
+	account.AllowRegions = []string{"us-*", "eu-*"}
+	account.DenyRegions = []string{"eu-west-1"}
+	regions, err = c.AccountRegions(account, []string{"us-east-1"})
+	a.NoError(err)
+	a.Equal([]string{"us-west-2"}, regions)
+}
@@ -0,0 +1,703 @@
+package aws
+
+import (
+	"github.com/aws/aws-sdk-go/service/cloudformation"
+	"github.com/aws/aws-sdk-go/service/dynamodb"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	"github.com/aws/aws-sdk-go/service/ecr"
+	"github.com/aws/aws-sdk-go/service/ecs"
+	"github.com/aws/aws-sdk-go/service/eks"
+	"github.com/aws/aws-sdk-go/service/elbv2"
+	"github.com/aws/aws-sdk-go/service/iam"
+	"github.com/aws/aws-sdk-go/service/kinesis"
+	"github.com/aws/aws-sdk-go/service/kms"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+)
+
+// LabelDescription documents a label set by a resource type
+type LabelDescription struct {
+	Name        string
+	Description string
+	// Values lists every value the label can take, empty when it is free-form
+	Values []string
+}
+
+// ResourceType documents a resource type reaper knows how to evaluate
+type ResourceType struct {
+	Name        string
+	Description string
+	Labels      []LabelDescription
+	// Actions lists the remediation actions the resource type supports
+	Actions []string
+	// NoCreationTime is set for resource types AWS reports no creation time for, which never expire.
+	// Policies remediating them have to ignore their age.
+	NoCreationTime bool
+}
+
+// SupportsAction returns true if the resource type supports the remediation action
+func (rt ResourceType) SupportsAction(action string) bool {
+	return containsString(rt.Actions, action)
+}
+
+// boolean labels are only set when true
+var boolLabelValues = []string{"true"}
+
+// ResourceTypes lists the resource types reaper knows how to evaluate
+var ResourceTypes = []ResourceType{
+	{
+		Name:        "s3",
+		Description: "S3 buckets",
+		Labels: []LabelDescription{
+			{Name: string(s3LabelACLPublic), Description: "set when the bucket ACL grants anything to AllUsers or AuthenticatedUsers, which is any AWS account", Values: boolLabelValues},
+			{Name: string(s3LabelACLPublicRead), Description: "set when the bucket ACL grants READ to AllUsers or AuthenticatedUsers", Values: boolLabelValues},
+			{Name: string(s3LabelPolicyIsPublic), Description: "set when S3 considers the bucket policy public", Values: boolLabelValues},
+			{Name: string(s3LabelBlockPublicACLs), Description: "set when the bucket's Block Public Access settings block public ACLs", Values: boolLabelValues},
+			{Name: string(s3LabelIgnorePublicACLs), Description: "set when the bucket's Block Public Access settings ignore public ACLs", Values: boolLabelValues},
+			{Name: string(s3LabelBlockPublicPolicy), Description: "set when the bucket's Block Public Access settings block public bucket policies", Values: boolLabelValues},
+			{Name: string(s3LabelRestrictPublicBuckets), Description: "set when the bucket's Block Public Access settings restrict access to buckets with public policies", Values: boolLabelValues},
+			{Name: string(s3LabelPublicAccessFullyBlocked), Description: "set when all four of the bucket's Block Public Access settings are on. Account wide settings are not considered", Values: boolLabelValues},
+			{Name: string(s3LabelEncryption), Description: "default encryption of the bucket, unset without one", Values: []string{
+				s3.ServerSideEncryptionAes256,
+				s3.ServerSideEncryptionAwsKms,
+			}},
+			{Name: string(s3LabelKMSKeyID), Description: "kms key used for default encryption, unset for the aws managed key"},
+			{Name: string(s3LabelVersioning), Description: "versioning status, unset if it was never enabled", Values: []string{
+				s3.BucketVersioningStatusEnabled,
+				s3.BucketVersioningStatusSuspended,
+			}},
+			{Name: string(s3LabelMFADelete), Description: "set when deleting versions requires MFA", Values: boolLabelValues},
+			{Name: string(s3LabelAccessLogging), Description: "set when server access logging is enabled", Values: boolLabelValues},
+			{Name: string(s3LabelAccessLogBucket), Description: "bucket server access logs are delivered to"},
+			{Name: string(s3LabelObjectOwnership), Description: "object ownership setting, unset without ownership controls", Values: []string{
+				s3.ObjectOwnershipBucketOwnerEnforced,
+				s3.ObjectOwnershipBucketOwnerPreferred,
+				s3.ObjectOwnershipObjectWriter,
+			}},
+			{Name: string(s3LabelLifecycleRuleCount), Description: "number of enabled lifecycle rules"},
+			{Name: string(s3LabelHasReplication), Description: "set when the bucket replicates to another bucket", Values: boolLabelValues},
+			{Name: string(s3LabelConfigurationUnknown), Description: "set when reaper could not read one of the bucket's settings, e.g. because the bucket policy denies it. That setting's labels are left unset", Values: boolLabelValues},
+			{Name: string(s3LabelObjectCount), Description: "number of objects, including noncurrent versions, from the daily CloudWatch storage metrics. Unset when the size is unknown"},
+			{Name: string(s3LabelSizeBytes), Description: "bytes stored across all storage classes, from the daily CloudWatch storage metrics. Unset when the size is unknown"},
+			{Name: string(s3LabelSizeUnknown), Description: "set when CloudWatch has no storage metrics for the bucket, as for empty buckets and buckets created in the last day", Values: boolLabelValues},
+			{Name: string(s3LabelLastModified), Description: "date an object was last written or deleted, yyyy-mm-dd. Unset for empty buckets and buckets holding more than 1000 object versions and delete markers"},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "ec2_instance",
+		Description: "EC2 instances",
+		Labels: []LabelDescription{
+			{Name: ec2InstanceLabelVpcID, Description: "id of the vpc the instance runs in"},
+			{Name: ec2InstanceLabelPublicIP, Description: "public ip address of the instance"},
+			{Name: ec2InstanceLabelPrivateIP, Description: "private ip address of the instance"},
+		},
+	},
+	{
+		Name:        "vpc",
+		Description: "VPCs",
+		Labels: []LabelDescription{
+			{Name: string(vpcLabelIsDefault), Description: "set when this is the region's default vpc", Values: boolLabelValues},
+			{Name: string(vpcLabelSubnetCount), Description: "number of subnets in the vpc"},
+			{Name: string(vpcLabelENICount), Description: "number of network interfaces in the vpc, 0 for empty vpcs"},
+			{Name: string(vpcLabelInternetGatewayAttached), Description: "set when an internet gateway is attached", Values: boolLabelValues},
+			{Name: string(vpcLabelFlowLogsEnabled), Description: "set when an active flow log captures the vpc's traffic. Flow logs on single subnets or interfaces are not considered", Values: boolLabelValues},
+			{Name: string(vpcLabelPeeringConnectionCount), Description: "number of active peering connections the vpc requested or accepted"},
+			{Name: string(vpcLabelCIDRBlocks), Description: "comma separated ipv4 and ipv6 CIDR blocks associated with the vpc"},
+			{Name: string(vpcLabelCIDRBlockCount), Description: "number of CIDR blocks associated with the vpc"},
+		},
+		Actions:        []string{policy.ActionDelete},
+		NoCreationTime: true,
+	},
+	{
+		Name:        "iam_user",
+		Description: "IAM users",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the user"},
+			{Name: string(iamUserLabelHasMFA), Description: "set when the user has an active MFA device", Values: boolLabelValues},
+			{Name: string(iamUserLabelHasPassword), Description: "set when the user has a console password", Values: boolLabelValues},
+			{Name: string(iamUserLabelConsoleWithoutMFA), Description: "set when the user has a console password but no MFA device", Values: boolLabelValues},
+			{Name: string(iamUserLabelPasswordLastUsed), Description: "date the password was last used, e.g. 2020-01-31"},
+			{Name: string(iamUserLabelPasswordAgeDays), Description: "days since the password was last changed"},
+			{Name: string(iamUserLabelActiveAccessKeyCount), Description: "number of active access keys"},
+			{Name: string(iamUserLabelAccessKeyLastUsed), Description: "date any active access key was last used, e.g. 2020-01-31"},
+			{Name: string(iamUserLabelAccessKeyLastUsedService), Description: "service the most recently used access key was last used with, e.g. s3"},
+			{Name: string(iamUserLabelAttachedPolicyCount), Description: "number of managed policies attached to the user"},
+			{Name: string(iamUserLabelInlinePolicyCount), Description: "number of inline policies of the user"},
+			{Name: string(iamUserLabelHasAdministratorAccess), Description: "set when the AdministratorAccess policy is attached to the user directly", Values: boolLabelValues},
+			{Name: string(iamUserLabelGroupCount), Description: "number of groups the user is a member of"},
+			{Name: iamUserGroupLabelPrefix + "<group>", Description: "set for each group the user is a member of, e.g. group/admins", Values: boolLabelValues},
+			{Name: string(iamUserLabelNotInCredentialReport), Description: "set for users created after the last credential report, which AWS generates at most every 4 hours. The credential labels are unset", Values: boolLabelValues},
+		},
+	},
+	{
+		Name:        "ebs_volume",
+		Description: "EBS volumes",
+		Labels: []LabelDescription{
+			{Name: ec2EBSVolLabelAz, Description: "availability zone of the volume"},
+			{Name: ec2EBSVolLabelIsEncrypted, Description: "set when the volume is encrypted", Values: boolLabelValues},
+			{Name: ec2EBSVolLabelSize, Description: "size of the volume in GiB"},
+			{Name: ec2EBSVolLabelState, Description: "state of the volume", Values: []string{
+				ec2.VolumeStateCreating,
+				ec2.VolumeStateAvailable,
+				ec2.VolumeStateInUse,
+				ec2.VolumeStateDeleting,
+				ec2.VolumeStateDeleted,
+				ec2.VolumeStateError,
+			}},
+			{Name: ec2EBSVolLabelType, Description: "volume type", Values: []string{
+				ec2.VolumeTypeStandard,
+				ec2.VolumeTypeIo1,
+				ec2.VolumeTypeIo2,
+				ec2.VolumeTypeGp2,
+				ec2.VolumeTypeGp3,
+				ec2.VolumeTypeSc1,
+				ec2.VolumeTypeSt1,
+			}},
+			{Name: string(ec2EBSVolLabelInstanceID), Description: "id of the instance the volume is attached to"},
+			{Name: string(ec2EBSVolLabelDeleteOnTermination), Description: "set when the volume is deleted with the instance it is attached to", Values: boolLabelValues},
+			{Name: string(ec2EBSVolLabelIops), Description: "provisioned iops, or the baseline iops of gp2 volumes"},
+			{Name: string(ec2EBSVolLabelThroughput), Description: "throughput of gp3 volumes in MiB/s"},
+			{Name: string(ec2EBSVolLabelLastSnapshot), Description: "date the most recent completed snapshot of the volume was started"},
+			{Name: string(ec2EBSVolLabelLastSnapshotAgeDays), Description: "days since the most recent completed snapshot of the volume was started"},
+			{Name: string(ec2EBSVolLabelNeverSnapshotted), Description: "set when the account has no completed snapshot of the volume", Values: boolLabelValues},
+			{Name: string(ec2EBSVolLabelAvailableSince), Description: "date an available volume was detached, from its DetachVolume event or the TerminateInstances event of its instance in CloudTrail. Volumes never attached are available since they were created"},
+			{Name: string(ec2EBSVolLabelAvailableDays), Description: "days an available volume has been detached"},
+			{Name: string(ec2EBSVolLabelAvailableDaysIsMinimum), Description: "set when CloudTrail's 90 day event history does not tell when the volume was detached, available_days is then how long since reaper first found it available", Values: boolLabelValues},
+			{Name: string(ec2EBSVolLabelAvailableSinceUnknown), Description: "set on available volumes neither CloudTrail nor reaper's first seen tag tell the detach time of", Values: boolLabelValues},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "ec2_security_group",
+		Description: "EC2 security groups",
+		Labels: []LabelDescription{
+			{Name: vpcID, Description: "id of the vpc the security group belongs to"},
+			{Name: publicIngress, Description: "set when an ingress rule allows an ipv4 range with public addresses. Private, carrier grade nat, loopback, link local and documentation ranges are not public", Values: boolLabelValues},
+			{Name: string(securityGroupLabelPublicIngressIPv6), Description: "set when an ingress rule allows an ipv6 range with public addresses. Unique local, loopback, link local and documentation ranges are not public", Values: boolLabelValues},
+			{Name: string(securityGroupLabelPublicPorts), Description: "comma separated ports and port ranges public ranges can reach, e.g. 22,8000-8080. all for every port, or the protocol for rules without ports such as icmp"},
+			{Name: securityGroupPublicPortLabelPrefix + "<port>", Description: "set for each single port public ranges can reach, and for commonly attacked ports such as 22 and 3389 when a public port range includes them", Values: boolLabelValues},
+			{Name: string(securityGroupLabelPublicAllPorts), Description: "set when public ranges can reach every port", Values: boolLabelValues},
+			{Name: string(securityGroupLabelEgressUnrestricted), Description: "set when an egress rule allows all traffic to 0.0.0.0/0 or ::/0", Values: boolLabelValues},
+			{Name: string(securityGroupLabelIngressPrefixListCount), Description: "number of prefix lists ingress rules allow, these are not checked for public ranges"},
+			{Name: string(securityGroupLabelIngressGroupCount), Description: "number of security groups ingress rules allow"},
+			{Name: string(securityGroupLabelAttachedENICount), Description: "number of network interfaces using the security group"},
+			{Name: string(securityGroupLabelIsDefault), Description: "set for the default security group of a vpc", Values: boolLabelValues},
+		},
+		Actions:        []string{policy.ActionRevokePublicIngress, policy.ActionDelete},
+		NoCreationTime: true,
+	},
+	{
+		Name:        "kms_key",
+		Description: "KMS keys",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the key"},
+			{Name: labelID, Description: "id of the key"},
+			{Name: string(labelKMSKeyDescription), Description: "description of the key"},
+			{Name: string(labelKMSKeyState), Description: "state of the key", Values: []string{
+				kms.KeyStateEnabled,
+				kms.KeyStateDisabled,
+				kms.KeyStatePendingDeletion,
+				kms.KeyStatePendingImport,
+				kms.KeyStateUnavailable,
+			}},
+			{Name: string(labelKMSKeyManager), Description: "who manages the key. Only customer managed keys are evaluated", Values: []string{
+				kms.KeyManagerTypeCustomer,
+				kms.KeyManagerTypeAws,
+			}},
+			{Name: string(labelKMSKeyUsage), Description: "what the key is used for", Values: []string{
+				kms.KeyUsageTypeEncryptDecrypt,
+				kms.KeyUsageTypeSignVerify,
+			}},
+			{Name: string(labelKMSRotationEnabled), Description: "set when automatic key rotation is enabled", Values: boolLabelValues},
+			{Name: string(labelKMSRotationUnsupported), Description: "set for keys that can't rotate automatically, which are asymmetric keys and keys with imported or custom key store key material", Values: boolLabelValues},
+			{Name: string(labelKMSPendingDeletionAt), Description: "date the key will be deleted, for keys pending deletion"},
+			{Name: string(labelKMSMultiRegion), Description: "set for multi-Region keys", Values: boolLabelValues},
+			{Name: string(labelKMSHasAlias), Description: "set when the key has an alias", Values: boolLabelValues},
+			{Name: string(labelKMSAliasCount), Description: "number of aliases of the key"},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "iam_access_key",
+		Description: "IAM user access keys",
+		Labels: []LabelDescription{
+			{Name: string(iamAccessKeyLabelStatus), Description: "status of the key", Values: []string{
+				iam.StatusTypeActive,
+				iam.StatusTypeInactive,
+			}},
+			{Name: string(iamAccessKeyLabelUsername), Description: "name of the user owning the key"},
+			{Name: string(iamAccessKeyLabelAge), Description: "age of the key in seconds"},
+			{Name: string(iamAccessKeyLabelAgeDays), Description: "age of the key in days"},
+			{Name: string(iamAccessKeyLabelLastUsedAt), Description: "date the key was last used, e.g. 2020-01-31"},
+			{Name: string(iamAccessKeyLabelLastUsedService), Description: "service the key was last used with, e.g. s3"},
+			{Name: string(iamAccessKeyLabelLastUsedRegion), Description: "region the key was last used in"},
+			{Name: string(iamAccessKeyLabelNeverUsed), Description: "set when the key was never used", Values: boolLabelValues},
+			{Name: string(iamAccessKeyLabelUnusedDays), Description: "days since the key was last used, or created if it never was"},
+			{Name: string(iamAccessKeyLabelDeactivatedAt), Description: "date reaper deactivated the key, e.g. 2020-01-31"},
+		},
+		Actions: []string{policy.ActionDeactivate, policy.ActionDelete},
+	},
+	{
+		Name:        "rds_instance",
+		Description: "RDS database instances, including members of aurora clusters",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the instance"},
+			{Name: string(rdsLabelEngine), Description: "database engine, e.g. postgres or aurora-mysql"},
+			{Name: string(rdsLabelEngineVersion), Description: "version of the database engine"},
+			{Name: string(rdsLabelInstanceClass), Description: "instance class, e.g. db.t3.micro"},
+			{Name: string(rdsLabelMultiAZ), Description: "set when the instance is deployed in multiple availability zones", Values: boolLabelValues},
+			{Name: string(rdsLabelStorageType), Description: "storage type, e.g. gp2 or io1"},
+			{Name: string(rdsLabelAllocatedStorage), Description: "allocated storage in GiB"},
+			{Name: string(rdsLabelIsEncrypted), Description: "set when the storage is encrypted", Values: boolLabelValues},
+			{Name: string(rdsLabelPubliclyAccessible), Description: "set when the instance is publicly accessible", Values: boolLabelValues},
+			{Name: string(rdsLabelDeletionProtection), Description: "set when deletion protection is enabled", Values: boolLabelValues},
+			{Name: string(rdsLabelStatus), Description: "status of the instance, e.g. available or stopped"},
+			{Name: string(rdsLabelClusterID), Description: "id of the cluster the instance is a member of"},
+		},
+		Actions: []string{policy.ActionStop, policy.ActionDelete},
+	},
+	{
+		Name:        "rds_cluster",
+		Description: "RDS database clusters, such as aurora clusters",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the cluster"},
+			{Name: string(rdsLabelEngine), Description: "database engine, e.g. aurora-postgresql"},
+			{Name: string(rdsLabelEngineVersion), Description: "version of the database engine"},
+			{Name: string(rdsLabelEngineMode), Description: "engine mode, e.g. provisioned or serverless"},
+			{Name: string(rdsLabelMultiAZ), Description: "set when the cluster has instances in multiple availability zones", Values: boolLabelValues},
+			{Name: string(rdsLabelAllocatedStorage), Description: "allocated storage in GiB"},
+			{Name: string(rdsLabelIsEncrypted), Description: "set when the storage is encrypted", Values: boolLabelValues},
+			{Name: string(rdsLabelDeletionProtection), Description: "set when deletion protection is enabled", Values: boolLabelValues},
+			{Name: string(rdsLabelStatus), Description: "status of the cluster, e.g. available or stopped"},
+			{Name: string(rdsLabelMemberCount), Description: "number of instances in the cluster"},
+		},
+		Actions: []string{policy.ActionStop, policy.ActionDelete},
+	},
+	{
+		Name:        "ebs_snapshot",
+		Description: "EBS snapshots owned by the account",
+		Labels: []LabelDescription{
+			{Name: string(ebsSnapshotLabelSize), Description: "size of the source volume in GiB"},
+			{Name: string(ebsSnapshotLabelState), Description: "state of the snapshot", Values: []string{
+				ec2.SnapshotStatePending,
+				ec2.SnapshotStateCompleted,
+				ec2.SnapshotStateError,
+			}},
+			{Name: string(ebsSnapshotLabelIsEncrypted), Description: "set when the snapshot is encrypted", Values: boolLabelValues},
+			{Name: string(ebsSnapshotLabelVolumeID), Description: "id of the volume the snapshot was taken from"},
+			{Name: string(ebsSnapshotLabelVolumeExists), Description: "set when the source volume still exists", Values: boolLabelValues},
+			{Name: string(ebsSnapshotLabelReferencedByAMI), Description: "set when an ami owned by the account references the snapshot", Values: boolLabelValues},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "ami",
+		Description: "AMIs owned by the account",
+		Labels: []LabelDescription{
+			{Name: string(amiLabelState), Description: "state of the ami", Values: []string{
+				ec2.ImageStatePending,
+				ec2.ImageStateAvailable,
+				ec2.ImageStateInvalid,
+				ec2.ImageStateDeregistered,
+				ec2.ImageStateTransient,
+				ec2.ImageStateFailed,
+				ec2.ImageStateError,
+			}},
+			{Name: string(amiLabelSize), Description: "total size of the ami's ebs snapshots in GiB"},
+			{Name: string(amiLabelIsPublic), Description: "set when the ami is shared publicly", Values: boolLabelValues},
+			{Name: string(amiLabelSnapshotCount), Description: "number of ebs snapshots backing the ami"},
+			{Name: string(amiLabelInUse), Description: "set when an instance that isn't terminated or a launch template uses the ami", Values: boolLabelValues},
+			{Name: string(amiLabelUsedByInstance), Description: "set when an instance that isn't terminated was launched from the ami", Values: boolLabelValues},
+			{Name: string(amiLabelUsedByLaunchTemplate), Description: "set when the default or latest version of a launch template uses the ami", Values: boolLabelValues},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "elastic_ip",
+		Description: "Elastic IP addresses",
+		Labels: []LabelDescription{
+			{Name: string(elasticIPLabelPublicIP), Description: "the public ip address"},
+			{Name: string(elasticIPLabelDomain), Description: "whether the address is for use in a vpc or ec2 classic", Values: []string{
+				ec2.DomainTypeVpc,
+				ec2.DomainTypeStandard,
+			}},
+			{Name: string(elasticIPLabelIsAssociated), Description: "set when the address is associated with an instance or network interface", Values: boolLabelValues},
+			{Name: string(elasticIPLabelInstanceID), Description: "id of the instance the address is associated with"},
+			{Name: string(elasticIPLabelNetworkInterfaceID), Description: "id of the network interface the address is associated with"},
+		},
+		Actions:        []string{policy.ActionRelease},
+		NoCreationTime: true,
+	},
+	{
+		Name:        "network_interface",
+		Description: "Elastic network interfaces",
+		Labels: []LabelDescription{
+			{Name: string(networkInterfaceLabelStatus), Description: "status of the network interface", Values: []string{
+				ec2.NetworkInterfaceStatusAvailable,
+				ec2.NetworkInterfaceStatusAssociated,
+				ec2.NetworkInterfaceStatusAttaching,
+				ec2.NetworkInterfaceStatusInUse,
+				ec2.NetworkInterfaceStatusDetaching,
+			}},
+			{Name: string(networkInterfaceLabelIsAttached), Description: "set when the network interface is attached", Values: boolLabelValues},
+			{Name: string(networkInterfaceLabelInstanceID), Description: "id of the instance the network interface is attached to"},
+			{Name: string(networkInterfaceLabelInterfaceType), Description: "type of the network interface, e.g. interface or nat_gateway"},
+			{Name: string(networkInterfaceLabelRequesterManaged), Description: "set when another AWS service manages the network interface", Values: boolLabelValues},
+			{Name: string(networkInterfaceLabelHasPublicIP), Description: "set when the network interface has a public ip", Values: boolLabelValues},
+			{Name: string(networkInterfaceLabelVpcID), Description: "id of the vpc of the network interface"},
+			{Name: string(networkInterfaceLabelSubnetID), Description: "id of the subnet of the network interface"},
+			{Name: string(networkInterfaceLabelAvailabilityZone), Description: "availability zone of the network interface"},
+			{Name: string(networkInterfaceLabelSecurityGroupCount), Description: "number of security groups of the network interface"},
+		},
+		Actions:        []string{policy.ActionDelete},
+		NoCreationTime: true,
+	},
+	{
+		Name:        "nat_gateway",
+		Description: "NAT gateways",
+		Labels: []LabelDescription{
+			{Name: string(natGatewayLabelState), Description: "state of the nat gateway", Values: []string{
+				ec2.NatGatewayStatePending,
+				ec2.NatGatewayStateFailed,
+				ec2.NatGatewayStateAvailable,
+				ec2.NatGatewayStateDeleting,
+				ec2.NatGatewayStateDeleted,
+			}},
+			{Name: string(natGatewayLabelVpcID), Description: "id of the vpc of the nat gateway"},
+			{Name: string(natGatewayLabelSubnetID), Description: "id of the subnet of the nat gateway"},
+			{Name: string(natGatewayLabelPublicIP), Description: "public ip address of the nat gateway"},
+			{Name: string(natGatewayLabelBytesProcessed), Description: "bytes the nat gateway received from sources and destinations over the policy's lookback, unset for deleted gateways"},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "elb_classic",
+		Description: "Classic load balancers",
+		Labels: []LabelDescription{
+			{Name: string(loadBalancerLabelScheme), Description: "scheme of the load balancer", Values: []string{"internet-facing", "internal"}},
+			{Name: string(loadBalancerLabelIsInternetFacing), Description: "set when the load balancer is internet-facing", Values: boolLabelValues},
+			{Name: string(loadBalancerLabelVpcID), Description: "id of the vpc of the load balancer"},
+			{Name: string(loadBalancerLabelListenerCount), Description: "number of listeners"},
+			{Name: string(loadBalancerLabelRegisteredTargetCount), Description: "number of registered instances"},
+			{Name: string(loadBalancerLabelHealthyTargetCount), Description: "number of healthy instances"},
+			{Name: string(loadBalancerLabelPeakHealthyTargetCount), Description: "highest number of healthy instances over the policy's lookback"},
+			{Name: string(loadBalancerLabelAccessLogging), Description: "set when access logging is enabled", Values: boolLabelValues},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "alb",
+		Description: "Application load balancers",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the load balancer"},
+			{Name: string(loadBalancerLabelState), Description: "state of the load balancer", Values: []string{
+				elbv2.LoadBalancerStateEnumActive,
+				elbv2.LoadBalancerStateEnumProvisioning,
+				elbv2.LoadBalancerStateEnumActiveImpaired,
+				elbv2.LoadBalancerStateEnumFailed,
+			}},
+			{Name: string(loadBalancerLabelScheme), Description: "scheme of the load balancer", Values: []string{"internet-facing", "internal"}},
+			{Name: string(loadBalancerLabelIsInternetFacing), Description: "set when the load balancer is internet-facing", Values: boolLabelValues},
+			{Name: string(loadBalancerLabelVpcID), Description: "id of the vpc of the load balancer"},
+			{Name: string(loadBalancerLabelListenerCount), Description: "number of listeners"},
+			{Name: string(loadBalancerLabelTargetGroupCount), Description: "number of target groups"},
+			{Name: string(loadBalancerLabelRegisteredTargetCount), Description: "number of registered targets across all target groups"},
+			{Name: string(loadBalancerLabelHealthyTargetCount), Description: "number of healthy targets across all target groups"},
+			{Name: string(loadBalancerLabelPeakHealthyTargetCount), Description: "highest number of healthy targets over the policy's lookback, added up across target groups"},
+			{Name: string(loadBalancerLabelAccessLogging), Description: "set when access logging is enabled", Values: boolLabelValues},
+			{Name: string(loadBalancerLabelDeletionProtection), Description: "set when deletion protection is enabled", Values: boolLabelValues},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "nlb",
+		Description: "Network load balancers",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the load balancer"},
+			{Name: string(loadBalancerLabelState), Description: "state of the load balancer", Values: []string{
+				elbv2.LoadBalancerStateEnumActive,
+				elbv2.LoadBalancerStateEnumProvisioning,
+				elbv2.LoadBalancerStateEnumActiveImpaired,
+				elbv2.LoadBalancerStateEnumFailed,
+			}},
+			{Name: string(loadBalancerLabelScheme), Description: "scheme of the load balancer", Values: []string{"internet-facing", "internal"}},
+			{Name: string(loadBalancerLabelIsInternetFacing), Description: "set when the load balancer is internet-facing", Values: boolLabelValues},
+			{Name: string(loadBalancerLabelVpcID), Description: "id of the vpc of the load balancer"},
+			{Name: string(loadBalancerLabelListenerCount), Description: "number of listeners"},
+			{Name: string(loadBalancerLabelTargetGroupCount), Description: "number of target groups"},
+			{Name: string(loadBalancerLabelRegisteredTargetCount), Description: "number of registered targets across all target groups"},
+			{Name: string(loadBalancerLabelHealthyTargetCount), Description: "number of healthy targets across all target groups"},
+			{Name: string(loadBalancerLabelPeakHealthyTargetCount), Description: "highest number of healthy targets over the policy's lookback, added up across target groups"},
+			{Name: string(loadBalancerLabelAccessLogging), Description: "set when access logging is enabled", Values: boolLabelValues},
+			{Name: string(loadBalancerLabelDeletionProtection), Description: "set when deletion protection is enabled", Values: boolLabelValues},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "lambda_function",
+		Description: "Lambda functions",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the function"},
+			{Name: string(lambdaLabelRuntime), Description: "runtime of the function, e.g. python3.8"},
+			{Name: string(lambdaLabelRuntimeDeprecated), Description: "set when AWS has deprecated the runtime", Values: boolLabelValues},
+			{Name: string(lambdaLabelMemorySize), Description: "memory of the function in MB"},
+			{Name: string(lambdaLabelTimeout), Description: "timeout of the function in seconds"},
+			{Name: string(lambdaLabelLastModified), Description: "date the function was last modified, e.g. 2020-01-31"},
+			{Name: string(lambdaLabelDaysSinceModified), Description: "days since the function was last modified"},
+			{Name: string(lambdaLabelInVPC), Description: "set when the function is attached to a vpc", Values: boolLabelValues},
+			{Name: string(lambdaLabelVpcID), Description: "id of the vpc the function is attached to"},
+			{Name: string(lambdaLabelInvocations), Description: "number of invocations over the policy's lookback"},
+		},
+		Actions:        []string{policy.ActionDelete},
+		NoCreationTime: true,
+	},
+	{
+		Name:        "cloudformation_stack",
+		Description: "CloudFormation stacks",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the stack"},
+			{Name: string(stackLabelStatus), Description: "status of the stack, e.g. CREATE_COMPLETE or ROLLBACK_COMPLETE"},
+			{Name: string(stackLabelDriftStatus), Description: "drift status from the last drift detection", Values: []string{
+				cloudformation.StackDriftStatusDrifted,
+				cloudformation.StackDriftStatusInSync,
+				cloudformation.StackDriftStatusUnknown,
+				cloudformation.StackDriftStatusNotChecked,
+			}},
+			{Name: string(stackLabelTerminationProtection), Description: "set when termination protection is enabled", Values: boolLabelValues},
+			{Name: string(stackLabelIsNested), Description: "set when the stack is nested in another stack", Values: boolLabelValues},
+			{Name: string(stackLabelLastUpdated), Description: "date the stack was last updated, or created if it never was, e.g. 2020-01-31"},
+			{Name: string(stackLabelDaysSinceUpdated), Description: "days since the stack was last updated, or created if it never was"},
+		},
+		Actions: []string{policy.ActionDelete},
+	},
+	{
+		Name:        "ecs_cluster",
+		Description: "ECS clusters",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the cluster"},
+			{Name: string(ecsLabelStatus), Description: "status of the cluster", Values: []string{"ACTIVE", "PROVISIONING", "DEPROVISIONING", "FAILED", "INACTIVE"}},
+			{Name: string(ecsLabelRunningTaskCount), Description: "number of running tasks"},
+			{Name: string(ecsLabelPendingTaskCount), Description: "number of pending tasks"},
+			{Name: string(ecsLabelActiveServiceCount), Description: "number of active services"},
+			{Name: string(ecsLabelContainerInstanceCount), Description: "number of registered container instances"},
+		},
+	},
+	{
+		Name:        "ecs_service",
+		Description: "ECS services, identified as cluster/service",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the service"},
+			{Name: string(ecsLabelCluster), Description: "name of the cluster the service runs in"},
+			{Name: string(ecsLabelStatus), Description: "status of the service", Values: []string{"ACTIVE", "DRAINING", "INACTIVE"}},
+			{Name: string(ecsLabelLaunchType), Description: "launch type of the service", Values: []string{
+				ecs.LaunchTypeEc2,
+				ecs.LaunchTypeFargate,
+			}},
+			{Name: string(ecsLabelDesiredCount), Description: "desired number of tasks"},
+			{Name: string(ecsLabelRunningTaskCount), Description: "number of running tasks"},
+			{Name: string(ecsLabelPendingTaskCount), Description: "number of pending tasks"},
+		},
+	},
+	{
+		Name:        "eks_cluster",
+		Description: "EKS clusters",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the cluster"},
+			{Name: string(eksLabelVersion), Description: "kubernetes version of the cluster, e.g. 1.16"},
+			{Name: string(eksLabelVersionEndOfLife), Description: "set when EKS no longer supports the kubernetes version", Values: boolLabelValues},
+			{Name: string(eksLabelStatus), Description: "status of the cluster", Values: []string{
+				eks.ClusterStatusCreating,
+				eks.ClusterStatusActive,
+				eks.ClusterStatusDeleting,
+				eks.ClusterStatusFailed,
+			}},
+			{Name: string(eksLabelEndpointPublicAccess), Description: "set when the kubernetes api is reachable from the internet", Values: boolLabelValues},
+			{Name: string(eksLabelNodegroupCount), Description: "number of managed nodegroups"},
+		},
+	},
+	{
+		Name:        "eks_nodegroup",
+		Description: "EKS managed nodegroups, identified as cluster/nodegroup",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the nodegroup"},
+			{Name: string(eksLabelCluster), Description: "name of the cluster of the nodegroup"},
+			{Name: string(eksLabelVersion), Description: "kubernetes version of the nodegroup, e.g. 1.16"},
+			{Name: string(eksLabelVersionEndOfLife), Description: "set when EKS no longer supports the kubernetes version", Values: boolLabelValues},
+			{Name: string(eksLabelStatus), Description: "status of the nodegroup, e.g. ACTIVE or DEGRADED"},
+			{Name: string(eksLabelAMIType), Description: "ami type of the nodegroup, e.g. AL2_x86_64"},
+			{Name: string(eksLabelDesiredSize), Description: "desired number of nodes"},
+		},
+	},
+	{
+		Name:        "ecr_repository",
+		Description: "ECR repositories",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the repository"},
+			{Name: string(ecrLabelImageCount), Description: "number of images in the repository"},
+			{Name: string(ecrLabelLastPush), Description: "date an image was last pushed, e.g. 2020-01-31. Unset for empty repositories"},
+			{Name: string(ecrLabelDaysSinceLastPush), Description: "days since an image was last pushed. Unset for empty repositories"},
+			{Name: string(ecrLabelHasLifecyclePolicy), Description: "set when the repository has a lifecycle policy", Values: boolLabelValues},
+			{Name: string(ecrLabelScanOnPush), Description: "set when images are scanned on push", Values: boolLabelValues},
+			{Name: string(ecrLabelTagMutability), Description: "whether image tags can be overwritten", Values: []string{
+				ecr.ImageTagMutabilityMutable,
+				ecr.ImageTagMutabilityImmutable,
+			}},
+		},
+	},
+	{
+		Name:        "dynamodb_table",
+		Description: "DynamoDB tables",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the table"},
+			{Name: string(dynamoDBLabelStatus), Description: "status of the table", Values: []string{
+				dynamodb.TableStatusCreating,
+				dynamodb.TableStatusUpdating,
+				dynamodb.TableStatusDeleting,
+				dynamodb.TableStatusActive,
+				dynamodb.TableStatusInaccessibleEncryptionCredentials,
+				dynamodb.TableStatusArchiving,
+				dynamodb.TableStatusArchived,
+			}},
+			{Name: string(dynamoDBLabelBillingMode), Description: "billing mode of the table", Values: []string{
+				dynamodb.BillingModeProvisioned,
+				dynamodb.BillingModePayPerRequest,
+			}},
+			{Name: string(dynamoDBLabelReadCapacity), Description: "provisioned read capacity units, unset for on-demand tables"},
+			{Name: string(dynamoDBLabelWriteCapacity), Description: "provisioned write capacity units, unset for on-demand tables"},
+			{Name: string(dynamoDBLabelItemCount), Description: "approximate number of items, updated by AWS every 6 hours"},
+			{Name: string(dynamoDBLabelSizeBytes), Description: "approximate size of the table in bytes, updated by AWS every 6 hours"},
+			{Name: string(dynamoDBLabelEncryption), Description: "key the table is encrypted with", Values: []string{
+				dynamoDBEncryptionAWSOwned,
+				dynamoDBEncryptionKMS,
+			}},
+			{Name: string(dynamoDBLabelConsumedReadCapacity), Description: "read capacity units consumed over the policy's lookback"},
+			{Name: string(dynamoDBLabelConsumedWriteCapacity), Description: "write capacity units consumed over the policy's lookback"},
+		},
+	},
+	{
+		Name:        "sqs_queue",
+		Description: "SQS queues",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the queue"},
+			{Name: string(sqsLabelIsFifo), Description: "set for fifo queues", Values: boolLabelValues},
+			{Name: string(sqsLabelIsEncrypted), Description: "set when the queue is encrypted with kms", Values: boolLabelValues},
+			{Name: string(sqsLabelHasDeadLetterQueue), Description: "set when the queue has a dead letter queue", Values: boolLabelValues},
+			{Name: string(sqsLabelMessageCount), Description: "approximate number of messages available"},
+			{Name: string(sqsLabelInFlightMessageCount), Description: "approximate number of messages received but not deleted"},
+			{Name: string(sqsLabelOldestMessageAge), Description: "highest age in seconds of the oldest message over the policy's lookback"},
+			{Name: string(sqsLabelMessagesSent), Description: "messages sent to the queue over the policy's lookback"},
+		},
+	},
+	{
+		Name:        "sns_topic",
+		Description: "SNS topics",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the topic"},
+			{Name: string(snsLabelIsFifo), Description: "set for fifo topics", Values: boolLabelValues},
+			{Name: string(snsLabelIsEncrypted), Description: "set when the topic is encrypted with kms", Values: boolLabelValues},
+			{Name: string(snsLabelSubscriptionCount), Description: "number of confirmed subscriptions"},
+			{Name: string(snsLabelPendingSubscriptionCount), Description: "number of subscriptions pending confirmation"},
+			{Name: string(snsLabelMessagesPublished), Description: "messages published to the topic over the policy's lookback"},
+		},
+		NoCreationTime: true,
+	},
+	{
+		Name:        "kinesis_stream",
+		Description: "Kinesis data streams",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the stream"},
+			{Name: string(kinesisLabelStatus), Description: "status of the stream", Values: []string{
+				kinesis.StreamStatusCreating,
+				kinesis.StreamStatusDeleting,
+				kinesis.StreamStatusActive,
+				kinesis.StreamStatusUpdating,
+			}},
+			{Name: string(kinesisLabelOpenShardCount), Description: "number of open shards"},
+			{Name: string(kinesisLabelRetentionHours), Description: "how long records are retained, in hours"},
+			{Name: string(kinesisLabelIsEncrypted), Description: "set when the stream is encrypted with kms", Values: boolLabelValues},
+			{Name: string(kinesisLabelIncomingBytes), Description: "bytes put into the stream over the policy's lookback"},
+			{Name: string(kinesisLabelIncomingRecords), Description: "records put into the stream over the policy's lookback"},
+		},
+	},
+	{
+		Name:        "log_group",
+		Description: "CloudWatch Logs log groups",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the log group"},
+			{Name: string(logGroupLabelRetentionDays), Description: "days events are kept for, unset when they never expire"},
+			{Name: string(logGroupLabelNeverExpire), Description: "set when the log group has no retention period", Values: boolLabelValues},
+			{Name: string(logGroupLabelStoredBytes), Description: "bytes stored in the log group"},
+			{Name: string(logGroupLabelLastEvent), Description: "date of the latest event, e.g. 2020-01-31. Unset for log groups without events"},
+			{Name: string(logGroupLabelIsEncrypted), Description: "set when the log group is encrypted with kms", Values: boolLabelValues},
+			{Name: string(logGroupLabelKMSKeyID), Description: "arn of the kms key the log group is encrypted with"},
+		},
+		Actions: []string{policy.ActionSetRetention},
+	},
+	{
+		Name:        "iam_role",
+		Description: "IAM roles",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the role"},
+			{Name: string(iamRoleLabelPath), Description: "path of the role"},
+			{Name: string(iamRoleLabelIsServiceLinked), Description: "set for roles managed by an AWS service", Values: boolLabelValues},
+			{Name: string(iamRoleLabelLastUsed), Description: "date the role was last assumed, e.g. 2020-01-31. AWS tracks the last 400 days"},
+			{Name: string(iamRoleLabelLastUsedRegion), Description: "region the role was last used in"},
+			{Name: string(iamRoleLabelNeverUsed), Description: "set when the role was not used in the last 400 days", Values: boolLabelValues},
+			{Name: string(iamRoleLabelUnusedDays), Description: "days since the role was last used, or created if it never was. Select with e.g. unused_days>90"},
+			{Name: string(iamRoleLabelTrustsOtherAccounts), Description: "set when the trust policy lets principals in other accounts assume the role", Values: boolLabelValues},
+			{Name: string(iamRoleLabelTrustedAccountCount), Description: "number of other accounts the trust policy names"},
+			{Name: string(iamRoleLabelTrustsWildcard), Description: "set when the trust policy lets any AWS principal assume the role, regardless of conditions", Values: boolLabelValues},
+			{Name: string(iamRoleLabelTrustsService), Description: "set when the trust policy lets an AWS service assume the role", Values: boolLabelValues},
+			{Name: string(iamRoleLabelTrustsFederated), Description: "set when the trust policy lets a saml or web identity provider assume the role", Values: boolLabelValues},
+			{Name: string(iamRoleLabelTrustPolicyUnparsable), Description: "set when reaper could not parse the trust policy, the trust labels are unset", Values: boolLabelValues},
+			{Name: string(iamRoleLabelAttachedPolicyCount), Description: "number of managed policies attached to the role"},
+			{Name: string(iamRoleLabelInlinePolicyCount), Description: "number of inline policies of the role"},
+			{Name: string(iamRoleLabelHasAdministratorAccess), Description: "set when the AdministratorAccess policy is attached", Values: boolLabelValues},
+			{Name: string(iamRoleLabelHasPermissionsBoundary), Description: "set when the role has a permissions boundary", Values: boolLabelValues},
+		},
+	},
+	{
+		Name:        "iam_policy",
+		Description: "customer managed IAM policies",
+		Labels: []LabelDescription{
+			{Name: labelARN, Description: "arn of the policy"},
+			{Name: string(iamPolicyLabelPath), Description: "path of the policy"},
+			{Name: string(iamPolicyLabelAttachmentCount), Description: "number of users, groups and roles the policy is attached to"},
+			{Name: string(iamPolicyLabelIsAttached), Description: "set when the policy is attached to anything", Values: boolLabelValues},
+			{Name: string(iamPolicyLabelPermissionsBoundaryUsageCount), Description: "number of users and roles using the policy as a permissions boundary"},
+			{Name: string(iamPolicyLabelVersion), Description: "id of the default version, e.g. v3"},
+			{Name: string(iamPolicyLabelAllowsAdmin), Description: "set when the default version allows every action on every resource", Values: boolLabelValues},
+		},
+	},
+	{
+		Name:        "trusted_advisor_finding",
+		Description: "results of Trusted Advisor checks, one per check and account, identified by check id. Needs a Business or Enterprise support plan, other accounts are skipped",
+		Labels: []LabelDescription{
+			{Name: string(trustedAdvisorLabelCheckID), Description: "id of the check"},
+			{Name: string(trustedAdvisorLabelCheckName), Description: "name of the check in lower case with underscores, e.g. low_utilization_amazon_ec2_instances"},
+			{Name: string(trustedAdvisorLabelCategory), Description: "category of the check", Values: []string{
+				trustedAdvisorCategoryCostOptimizing,
+				trustedAdvisorCategorySecurity,
+				trustedAdvisorCategoryFaultTolerance,
+				trustedAdvisorCategoryPerformance,
+				trustedAdvisorCategoryServiceLimits,
+			}},
+			{Name: string(trustedAdvisorLabelStatus), Description: "status of the check result", Values: []string{
+				trustedAdvisorStatusOK,
+				trustedAdvisorStatusWarning,
+				trustedAdvisorStatusError,
+				trustedAdvisorStatusNotAvailable,
+			}},
+			{Name: string(trustedAdvisorLabelFlaggedResourceCount), Description: "number of resources the check flagged"},
+			{Name: string(trustedAdvisorLabelSuppressedResourceCount), Description: "number of resources excluded from the check"},
+			{Name: string(trustedAdvisorLabelProcessedResourceCount), Description: "number of resources the check looked at"},
+			{Name: string(trustedAdvisorLabelFlaggedRegions), Description: "comma separated regions of the flagged resources, for display"},
+			{Name: string(trustedAdvisorLabelMonthlySavings), Description: "estimated monthly savings in whole dollars if the flagged resources were fixed. Only set by cost_optimizing checks"},
+			{Name: string(trustedAdvisorLabelPercentMonthlySavings), Description: "estimated monthly savings as a whole percentage of the current monthly costs. Only set by cost_optimizing checks"},
+			{Name: string(trustedAdvisorLabelLastRefreshed), Description: "date the check was last refreshed, e.g. 2020-01-31"},
+			{Name: string(trustedAdvisorLabelLastRefreshedDays), Description: "days since the check was last refreshed"},
+		},
+		NoCreationTime: true,
+	},
+}
@@ -6,7 +6,9 @@ import (
 
 	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
 	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	multierror "github.com/hashicorp/go-multierror"
 	"github.com/pkg/errors"
@@ -15,30 +17,68 @@ import (
 
 // s3 specific labels
 const (
-	s3LabelACLPublic     TypeEntityLabel = "s3_acl_public"
-	s3LabelACLPublicRead TypeEntityLabel = "s3_acl_public_read"
+	s3LabelACLPublic                TypeEntityLabel = "s3_acl_public"
+	s3LabelACLPublicRead            TypeEntityLabel = "s3_acl_public_read"
+	s3LabelPolicyIsPublic           TypeEntityLabel = "policy_is_public"
+	s3LabelBlockPublicACLs          TypeEntityLabel = "block_public_acls"
+	s3LabelIgnorePublicACLs         TypeEntityLabel = "ignore_public_acls"
+	s3LabelBlockPublicPolicy        TypeEntityLabel = "block_public_policy"
+	s3LabelRestrictPublicBuckets    TypeEntityLabel = "restrict_public_buckets"
+	s3LabelPublicAccessFullyBlocked TypeEntityLabel = "public_access_fully_blocked"
+	s3LabelEncryption               TypeEntityLabel = "encryption"
+	s3LabelKMSKeyID                 TypeEntityLabel = "kms_key_id"
+	s3LabelVersioning               TypeEntityLabel = "versioning"
+	s3LabelMFADelete                TypeEntityLabel = "mfa_delete"
+	s3LabelAccessLogging            TypeEntityLabel = "access_logging"
+	s3LabelAccessLogBucket          TypeEntityLabel = "access_log_bucket"
+	s3LabelObjectOwnership          TypeEntityLabel = "object_ownership"
+	s3LabelLifecycleRuleCount       TypeEntityLabel = "lifecycle_rule_count"
+	s3LabelHasReplication           TypeEntityLabel = "has_replication"
+	s3LabelConfigurationUnknown     TypeEntityLabel = "configuration_unknown"
+)
+
+// grantees we consider public, any AWS account counts as the public
+var s3PublicGrantees = []string{
+	"http://acs.amazonaws.com/groups/global/AllUsers",
+	"http://acs.amazonaws.com/groups/global/AuthenticatedUsers",
+}
+
+// error codes S3 returns for bucket configuration that isn't set. The SDK doesn't define them.
+const (
+	s3ErrCodeNoSuchTagSet                     = "NoSuchTagSet"
+	s3ErrCodeNoSuchBucketPolicy               = "NoSuchBucketPolicy"
+	s3ErrCodeNoSuchPublicAccessBlock          = "NoSuchPublicAccessBlockConfiguration"
+	s3ErrCodeNoEncryption                     = "ServerSideEncryptionConfigurationNotFoundError"
+	s3ErrCodeNoSuchLifecycleConfiguration     = "NoSuchLifecycleConfiguration"
+	s3ErrCodeReplicationConfigurationNotFound = "ReplicationConfigurationNotFoundError"
+	s3ErrCodeOwnershipControlsNotFound        = "OwnershipControlsNotFoundError"
 )
 
 // S3Bucket is an evaluation entity representing an s3 bucket
 type S3Bucket struct {
 	Entity
 	name string
+	// sizeBytes comes from the bucket's CloudWatch storage metrics, sizeUnknown is set for
+	// buckets without them
+	sizeBytes   float64
+	sizeUnknown bool
+	svc         s3iface.S3API
 }
 
 // NewS3Bucket returns a new s3 bucket entity
-func NewS3Bucket(name string) *S3Bucket {
+func NewS3Bucket(name string, svc s3iface.S3API) *S3Bucket {
 	bucket := &S3Bucket{
 		Entity: NewEntity(),
 		name:   name,
+		svc:    svc,
 	}
 	bucket.ID = name
 	return bucket
 }
 
-// Delete deletes this bucket
+// Delete deletes this bucket and everything in it, unless it is larger than the default size limit
 func (s *S3Bucket) Delete() error {
-	log.Warnf("Would delete bucket %s", s.name)
-	return nil
+	return s.Remediate(policy.Remediation{Action: policy.ActionDelete})
 }
 
 // GetID returns the s3 bucket id
@@ -60,7 +100,7 @@ func (c *Client) EvalS3(accounts []*policy.Account, p policy.Policy) ([]policy.V
 	ctx := context.Background()
 	for _, account := range accounts {
 		log.Infof("walking account %s (%d)", account.Name, account.ID)
-		listOutput, err := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion).S3.ListBuckets(ctx)
+		listOutput, err := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion).S3.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
 		if err != nil {
 			return nil, errors.Wrap(err, "Could not list buckets")
 		}
@@ -75,8 +115,15 @@ func (c *Client) EvalS3(accounts []*policy.Account, p policy.Policy) ([]policy.V
 				log.Debugf("Nil bucket - nothing to do")
 				continue
 			}
+			if p.MightMatch(res, []string{string(s3LabelLastModified)}, false) {
+				err = DescribeS3BucketLastModified(ctx, res.svc, res)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+			}
 			if p.Match(res) {
-				violation := policy.NewViolation(p, res, false, account)
+				violation := policy.NewViolation(p, res, p.Expired(res), account)
 				violations = append(violations, violation)
 			}
 
@@ -86,6 +133,12 @@ func (c *Client) EvalS3(accounts []*policy.Account, p policy.Policy) ([]policy.V
 	return violations, errs
 }
 
+// isS3ErrCode returns true if err is an S3 error with the given code
+func isS3ErrCode(err error, code string) bool {
+	aerr, ok := errors.Cause(err).(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
 // DescribeS3Bucket describes the bucket
 func (c *Client) DescribeS3Bucket(accountID int64, roleName string, externalID string, b *s3.Bucket) (*S3Bucket, error) {
 	ctx := context.Background()
@@ -94,67 +147,199 @@ func (c *Client) DescribeS3Bucket(accountID int64, roleName string, externalID s
 	}
 	log.Debugf("Describing bucket %s", *b.Name)
 	name := *b.Name
-	bucket := NewS3Bucket(name)
-	bucket.AddCreatedAt(b.CreationDate)
 
-	location, err := c.Get(accountID, roleName, externalID, DefaultRegion).S3.GetBucketLocation(ctx, name)
+	locationOutput, err := c.Get(accountID, roleName, externalID, DefaultRegion).S3.GetBucketLocationWithContext(ctx, &s3.GetBucketLocationInput{Bucket: b.Name})
 	if err != nil {
-		return nil, err
+		return nil, errors.Wrapf(err, "Error getting bucket %s location", name)
 	}
-	bucket.Region = location
+	// buckets in us-east-1 have no location constraint
+	location := s3.NormalizeBucketLocation(aws.StringValue(locationOutput.LocationConstraint))
 
-	tagInput := &s3.GetBucketTaggingInput{}
-	tagInput.SetBucket(name)
 	regionalClient := c.Get(accountID, roleName, externalID, location)
 	if regionalClient == nil {
 		log.Debugf("Skipping over bucket %s because it is in unknown region %s", name, location)
 		return nil, nil
 	}
+	bucket := NewS3Bucket(name, regionalClient.S3)
+	bucket.AddCreatedAt(b.CreationDate)
+	bucket.Region = location
 
-	tags, err := regionalClient.S3.GetBucketTagging(ctx, name)
+	tags, err := regionalClient.S3.GetBucketTaggingWithContext(ctx, &s3.GetBucketTaggingInput{Bucket: b.Name})
+	if isS3ErrCode(err, s3ErrCodeNoSuchTagSet) {
+		log.Debugf("Bucket %s has no tags", name)
+	} else if err != nil {
+		return nil, errors.Wrapf(err, "Error fetching tagset for bucket %s", name)
+	} else {
+		for _, tag := range tags.TagSet {
+			if tag == nil {
+				continue
+			}
+			bucket.AddTag(tag.Key, tag.Value)
+		}
+	}
+
+	DescribeS3BucketConfiguration(ctx, regionalClient.S3, bucket)
+	err = DescribeS3BucketStorage(ctx, cloudwatch.New(c.GetSession(accountID, roleName, externalID, location)), bucket)
+	if err != nil {
+		return nil, err
+	}
+	return bucket, nil
+}
+
+// configurationUnknown marks the bucket as having a setting we could not read, whose labels are
+// left unset. Policies such as !public_access_fully_blocked can exclude these buckets with
+// !configuration_unknown rather than have a bucket we know nothing about count as a violation.
+func (s *S3Bucket) configurationUnknown(setting string, err error) {
+	log.Warnf("Could not get the %s of bucket %s: %s", setting, s.name, err)
+	unknown := true
+	s.AddBoolLabel(s3LabelConfigurationUnknown, &unknown)
+}
+
+// DescribeS3BucketConfiguration labels the bucket with its ACL, public access, encryption,
+// versioning, logging, ownership, lifecycle and replication settings. A setting that can't be read,
+// e.g. because a bucket policy denies reaper access to it, leaves its labels unset and sets
+// configuration_unknown rather than failing the bucket.
+func DescribeS3BucketConfiguration(ctx context.Context, svc s3iface.S3API, bucket *S3Bucket) {
+	name := aws.String(bucket.name)
+
+	acl, err := svc.GetBucketAclWithContext(ctx, &s3.GetBucketAclInput{Bucket: name})
 	if err != nil {
-		if aerr, ok := err.(awserr.Error); ok {
-			switch aerr.Code() {
-			// Bucket not tagged
-			case "NoSuchTagSet": // looks like it is not defined in the s3.Err* codes....
-				log.Debugf("Bucket %s has no tags", name)
-			default:
-				return nil, errors.Wrapf(err, "Error fetching tagset for bucket %s", name)
+		bucket.configurationUnknown("acl", err)
+	} else {
+		log.Debugf("reading grants for bucket %s", bucket.name)
+		for _, grant := range acl.Grants {
+
+			log.Debugf("grant %#v", grant)
+
+			if grant != nil &&
+				grant.Grantee != nil &&
+				grant.Grantee.Type != nil &&
+				*grant.Grantee.Type == s3.TypeGroup &&
+				grant.Grantee.URI != nil &&
+				containsString(s3PublicGrantees, *grant.Grantee.URI) {
+
+				log.Debugf("public bucket %s", bucket.name)
+				public := true
+				bucket.AddBoolLabel(s3LabelACLPublic, &public)
+
+				if grant.Permission != nil &&
+					*grant.Permission == s3.PermissionRead {
+					bucket.AddBoolLabel(s3LabelACLPublicRead, &public)
+				}
 			}
 		}
 	}
-	for _, tag := range tags.TagSet {
-		if tag == nil {
-			continue
+
+	policyStatus, err := svc.GetBucketPolicyStatusWithContext(ctx, &s3.GetBucketPolicyStatusInput{Bucket: name})
+	if err != nil && !isS3ErrCode(err, s3ErrCodeNoSuchBucketPolicy) {
+		bucket.configurationUnknown("policy status", err)
+	} else if err == nil && policyStatus.PolicyStatus != nil {
+		bucket.AddBoolLabel(s3LabelPolicyIsPublic, policyStatus.PolicyStatus.IsPublic)
+	}
+
+	publicAccessBlock, err := svc.GetPublicAccessBlockWithContext(ctx, &s3.GetPublicAccessBlockInput{Bucket: name})
+	if err != nil && !isS3ErrCode(err, s3ErrCodeNoSuchPublicAccessBlock) {
+		bucket.configurationUnknown("public access block", err)
+	} else if err == nil && publicAccessBlock.PublicAccessBlockConfiguration != nil {
+		block := publicAccessBlock.PublicAccessBlockConfiguration
+		fullyBlocked := aws.BoolValue(block.BlockPublicAcls) &&
+			aws.BoolValue(block.IgnorePublicAcls) &&
+			aws.BoolValue(block.BlockPublicPolicy) &&
+			aws.BoolValue(block.RestrictPublicBuckets)
+		bucket.
+			AddBoolLabel(s3LabelBlockPublicACLs, block.BlockPublicAcls).
+			AddBoolLabel(s3LabelIgnorePublicACLs, block.IgnorePublicAcls).
+			AddBoolLabel(s3LabelBlockPublicPolicy, block.BlockPublicPolicy).
+			AddBoolLabel(s3LabelRestrictPublicBuckets, block.RestrictPublicBuckets).
+			AddBoolLabel(s3LabelPublicAccessFullyBlocked, &fullyBlocked)
+	}
+
+	encryption, err := svc.GetBucketEncryptionWithContext(ctx, &s3.GetBucketEncryptionInput{Bucket: name})
+	if err != nil && !isS3ErrCode(err, s3ErrCodeNoEncryption) {
+		bucket.configurationUnknown("encryption", err)
+	} else if err == nil && encryption.ServerSideEncryptionConfiguration != nil {
+		for _, rule := range encryption.ServerSideEncryptionConfiguration.Rules {
+			if rule.ApplyServerSideEncryptionByDefault == nil {
+				continue
+			}
+			bucket.
+				AddLabel(s3LabelEncryption, rule.ApplyServerSideEncryptionByDefault.SSEAlgorithm).
+				AddLabel(s3LabelKMSKeyID, rule.ApplyServerSideEncryptionByDefault.KMSMasterKeyID)
 		}
-		bucket.AddTag(tag.Key, tag.Value)
 	}
 
-	acl, err := regionalClient.S3.GetBucketACL(ctx, name)
+	versioning, err := svc.GetBucketVersioningWithContext(ctx, &s3.GetBucketVersioningInput{Bucket: name})
 	if err != nil {
-		return nil, err
+		bucket.configurationUnknown("versioning", err)
+	} else {
+		mfaDelete := aws.StringValue(versioning.MFADelete) == s3.MFADeleteStatusEnabled
+		bucket.
+			AddLabel(s3LabelVersioning, versioning.Status).
+			AddBoolLabel(s3LabelMFADelete, &mfaDelete)
 	}
 
-	log.Debugf("reading grants for bucket %s", name)
-	for _, grant := range acl.Grants {
+	logging, err := svc.GetBucketLoggingWithContext(ctx, &s3.GetBucketLoggingInput{Bucket: name})
+	if err != nil {
+		bucket.configurationUnknown("logging", err)
+	} else if logging.LoggingEnabled != nil {
+		accessLogging := true
+		bucket.
+			AddBoolLabel(s3LabelAccessLogging, &accessLogging).
+			AddLabel(s3LabelAccessLogBucket, logging.LoggingEnabled.TargetBucket)
+	}
 
-		log.Debugf("grant %#v", grant)
+	ownership, err := svc.GetBucketOwnershipControlsWithContext(ctx, &s3.GetBucketOwnershipControlsInput{Bucket: name})
+	if err != nil && !isS3ErrCode(err, s3ErrCodeOwnershipControlsNotFound) {
+		bucket.configurationUnknown("ownership controls", err)
+	} else if err == nil && ownership.OwnershipControls != nil && len(ownership.OwnershipControls.Rules) > 0 {
+		bucket.AddLabel(s3LabelObjectOwnership, ownership.OwnershipControls.Rules[0].ObjectOwnership)
+	}
 
-		if grant != nil &&
-			grant.Grantee != nil &&
-			grant.Grantee.Type != nil &&
-			*grant.Grantee.Type == "Group" &&
-			grant.Grantee.URI != nil &&
-			*grant.Grantee.URI == "http://acs.amazonaws.com/groups/global/AllUsers" {
+	lifecycle, err := svc.GetBucketLifecycleConfigurationWithContext(ctx, &s3.GetBucketLifecycleConfigurationInput{Bucket: name})
+	if err != nil && !isS3ErrCode(err, s3ErrCodeNoSuchLifecycleConfiguration) {
+		bucket.configurationUnknown("lifecycle configuration", err)
+	} else {
+		var lifecycleRuleCount int64
+		if err == nil {
+			for _, rule := range lifecycle.Rules {
+				if aws.StringValue(rule.Status) == s3.ExpirationStatusEnabled {
+					lifecycleRuleCount++
+				}
+			}
+		}
+		bucket.AddInt64Label(s3LabelLifecycleRuleCount, &lifecycleRuleCount)
+	}
 
-			log.Debugf("public bucket %s", name)
-			bucket.AddLabel(s3LabelACLPublic, aws.String(""))
+	_, err = svc.GetBucketReplicationWithContext(ctx, &s3.GetBucketReplicationInput{Bucket: name})
+	if err != nil && !isS3ErrCode(err, s3ErrCodeReplicationConfigurationNotFound) {
+		bucket.configurationUnknown("replication configuration", err)
+	} else {
+		hasReplication := err == nil
+		bucket.AddBoolLabel(s3LabelHasReplication, &hasReplication)
+	}
+}
 
-			if grant.Permission != nil &&
-				*grant.Permission == "READ" {
-				bucket.AddLabel(s3LabelACLPublicRead, aws.String(""))
-			}
+func (c *Client) getS3Bucket(ctx context.Context, account *policy.Account, name string) (policy.Subject, error) {
+	listOutput, err := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion).S3.ListBucketsWithContext(ctx, &s3.ListBucketsInput{})
+	if err != nil {
+		return nil, errors.Wrap(err, "Could not list buckets")
+	}
+	for _, bucket := range listOutput.Buckets {
+		if bucket.Name == nil || *bucket.Name != name {
+			continue
+		}
+		res, err := c.DescribeS3Bucket(account.ID, account.Role, account.ExternalID, bucket)
+		if err != nil {
+			return nil, err
+		}
+		if res == nil {
+			return nil, errors.Errorf("bucket %s is in an unknown region", name)
 		}
+		err = DescribeS3BucketLastModified(ctx, res.svc, res)
+		if err != nil {
+			return nil, err
+		}
+		return res, nil
 	}
-	return bucket, nil
+	return nil, errors.Errorf("bucket %s not found", name)
 }
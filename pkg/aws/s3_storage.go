@@ -0,0 +1,207 @@
+package aws
+
+import (
+	"context"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/s3"
+	"github.com/aws/aws-sdk-go/service/s3/s3iface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	units "github.com/docker/go-units"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// s3 storage labels
+const (
+	s3LabelObjectCount TypeEntityLabel = "object_count"
+	s3LabelSizeBytes   TypeEntityLabel = "size_bytes"
+	s3LabelSizeUnknown TypeEntityLabel = "size_unknown"
+	// s3LabelLastModified is only looked up for buckets a policy might select with it
+	s3LabelLastModified TypeEntityLabel = "last_modified"
+)
+
+// S3 reports storage metrics once a day, we look back a few days to always find the latest
+const s3StorageMetricLookback = 3 * 24 * time.Hour
+
+// DescribeS3BucketStorage labels the bucket with how much it stores, from its daily CloudWatch
+// storage metrics. Buckets without them, such as empty buckets and those created in the last day,
+// are labeled size_unknown instead.
+func DescribeS3BucketStorage(ctx context.Context, cw cloudwatchiface.CloudWatchAPI, bucket *S3Bucket) error {
+	objectCount, objectCountFound, err := lookupMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/S3",
+		Metric:     "NumberOfObjects",
+		Dimensions: map[string]string{"BucketName": bucket.name, "StorageType": "AllStorageTypes"},
+		Statistic:  cloudwatch.StatisticMaximum,
+	}, s3StorageMetricLookback)
+	if err != nil {
+		return errors.Wrapf(err, "could not get the object count of bucket %s", bucket.name)
+	}
+
+	// sizes are reported per storage class
+	var queries []metricQuery
+	input := &cloudwatch.ListMetricsInput{
+		Namespace:  aws.String("AWS/S3"),
+		MetricName: aws.String("BucketSizeBytes"),
+		Dimensions: []*cloudwatch.DimensionFilter{{Name: aws.String("BucketName"), Value: aws.String(bucket.name)}},
+	}
+	err = cw.ListMetricsPagesWithContext(ctx, input, func(output *cloudwatch.ListMetricsOutput, lastPage bool) bool {
+		for _, metric := range output.Metrics {
+			q := metricQuery{
+				Namespace:  "AWS/S3",
+				Metric:     "BucketSizeBytes",
+				Dimensions: map[string]string{},
+				Statistic:  cloudwatch.StatisticMaximum,
+			}
+			for _, dimension := range metric.Dimensions {
+				q.Dimensions[aws.StringValue(dimension.Name)] = aws.StringValue(dimension.Value)
+			}
+			queries = append(queries, q)
+		}
+		return true
+	})
+	if err != nil {
+		return errors.Wrapf(err, "could not list the size metrics of bucket %s", bucket.name)
+	}
+	var sizeBytes float64
+	sizeFound := false
+	for _, q := range queries {
+		size, found, err := lookupMetric(ctx, cw, q, s3StorageMetricLookback)
+		if err != nil {
+			return errors.Wrapf(err, "could not get the size of bucket %s", bucket.name)
+		}
+		sizeBytes += size
+		sizeFound = sizeFound || found
+	}
+
+	if !objectCountFound || !sizeFound {
+		bucket.sizeUnknown = true
+		bucket.AddBoolLabel(s3LabelSizeUnknown, &bucket.sizeUnknown)
+		return nil
+	}
+	bucket.sizeBytes = sizeBytes
+	bucket.
+		AddLabel(s3LabelObjectCount, formatMetric(objectCount)).
+		AddLabel(s3LabelSizeBytes, formatMetric(sizeBytes))
+	return nil
+}
+
+// DescribeS3BucketLastModified labels the bucket with when an object in it was last written or
+// deleted. It reads a single page of object versions and delete markers, so buckets holding more
+// than a page, 1000 of them, are left without the label, as are empty buckets.
+func DescribeS3BucketLastModified(ctx context.Context, svc s3iface.S3API, bucket *S3Bucket) error {
+	output, err := svc.ListObjectVersionsWithContext(ctx, &s3.ListObjectVersionsInput{Bucket: aws.String(bucket.name)})
+	if err != nil {
+		return errors.Wrapf(err, "could not list object versions in bucket %s", bucket.name)
+	}
+	if aws.BoolValue(output.IsTruncated) {
+		log.Debugf("Bucket %s holds more than a page of object versions, not labeling when it was last modified", bucket.name)
+		return nil
+	}
+
+	var lastModified *time.Time
+	for _, version := range output.Versions {
+		if version.LastModified != nil && (lastModified == nil || version.LastModified.After(*lastModified)) {
+			lastModified = version.LastModified
+		}
+	}
+	for _, marker := range output.DeleteMarkers {
+		if marker.LastModified != nil && (lastModified == nil || marker.LastModified.After(*lastModified)) {
+			lastModified = marker.LastModified
+		}
+	}
+	if lastModified != nil {
+		bucket.AddLabel(s3LabelLastModified, aws.String(lastModified.UTC().Format("2006-01-02")))
+	}
+	return nil
+}
+
+// Remediate deletes the bucket along with every object version and delete marker in it. Buckets
+// larger than the remediation's size limit are refused unless it allows large buckets.
+func (s *S3Bucket) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	if remediation.Action != policy.ActionDelete {
+		return errors.Errorf("bucket %s does not support the %s action", s.name, remediation.Action)
+	}
+
+	if !remediation.Delete.AllowLargeBuckets {
+		maxSize := remediation.Delete.MaxBucketSizeOrDefault()
+		if !s.sizeUnknown && s.sizeBytes > float64(maxSize) {
+			return errors.Errorf("bucket %s stores %s, more than the %s limit", s.name, units.BytesSize(s.sizeBytes), units.BytesSize(float64(maxSize)))
+		}
+		// CloudWatch lags by a day and has nothing on some buckets, make sure the bucket didn't
+		// grow since or is small to begin with
+		size, err := s.versionsSize(ctx, maxSize)
+		if err != nil {
+			return err
+		}
+		if size > maxSize {
+			return errors.Errorf("bucket %s stores more than the %s limit", s.name, units.BytesSize(float64(maxSize)))
+		}
+	}
+
+	err := s.empty(ctx)
+	if err != nil {
+		return err
+	}
+	log.Warnf("Deleting bucket %s", s.name)
+	_, err = s.svc.DeleteBucketWithContext(ctx, &s3.DeleteBucketInput{Bucket: aws.String(s.name)})
+	return errors.Wrapf(err, "could not delete bucket %s", s.name)
+}
+
+// versionsSize adds up the size of every object version in the bucket, stopping once it is over limit
+func (s *S3Bucket) versionsSize(ctx context.Context, limit int64) (int64, error) {
+	var size int64
+	input := &s3.ListObjectVersionsInput{Bucket: aws.String(s.name)}
+	err := s.svc.ListObjectVersionsPagesWithContext(ctx, input, func(output *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		for _, version := range output.Versions {
+			size += aws.Int64Value(version.Size)
+		}
+		return size <= limit
+	})
+	return size, errors.Wrapf(err, "could not list object versions in bucket %s", s.name)
+}
+
+// empty deletes every object version and delete marker in the bucket, a page at a time. Pages
+// hold at most 1000 keys, which is as many as DeleteObjects takes.
+func (s *S3Bucket) empty(ctx context.Context) error {
+	var deleted int
+	var deleteErr error
+	input := &s3.ListObjectVersionsInput{Bucket: aws.String(s.name)}
+	err := s.svc.ListObjectVersionsPagesWithContext(ctx, input, func(output *s3.ListObjectVersionsOutput, lastPage bool) bool {
+		var objects []*s3.ObjectIdentifier
+		for _, version := range output.Versions {
+			objects = append(objects, &s3.ObjectIdentifier{Key: version.Key, VersionId: version.VersionId})
+		}
+		for _, marker := range output.DeleteMarkers {
+			objects = append(objects, &s3.ObjectIdentifier{Key: marker.Key, VersionId: marker.VersionId})
+		}
+		if len(objects) == 0 {
+			return true
+		}
+
+		result, err := s.svc.DeleteObjectsWithContext(ctx, &s3.DeleteObjectsInput{
+			Bucket: aws.String(s.name),
+			Delete: &s3.Delete{Objects: objects, Quiet: aws.Bool(true)},
+		})
+		if err != nil {
+			deleteErr = errors.Wrapf(err, "could not delete objects in bucket %s", s.name)
+			return false
+		}
+		if len(result.Errors) > 0 {
+			first := result.Errors[0]
+			deleteErr = errors.Errorf("could not delete %d objects in bucket %s, e.g. %s: %s", len(result.Errors), s.name, aws.StringValue(first.Key), aws.StringValue(first.Message))
+			return false
+		}
+		deleted += len(objects)
+		log.Infof("Deleted %d objects from bucket %s", deleted, s.name)
+		return true
+	})
+	if deleteErr != nil {
+		return deleteErr
+	}
+	return errors.Wrapf(err, "could not list object versions in bucket %s", s.name)
+}
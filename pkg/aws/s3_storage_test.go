@@ -0,0 +1,175 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/s3"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+// storageMetrics returns the datapoints of a bucket storing size bytes in count objects
+func storageMetrics(count float64, size float64) map[string][]*cloudwatch.Datapoint {
+	return map[string][]*cloudwatch.Datapoint{
+		"NumberOfObjects": {{Maximum: aws.Float64(count)}},
+		"BucketSizeBytes": {{Maximum: aws.Float64(size)}},
+	}
+}
+
+func TestDescribeS3BucketStorage(t *testing.T) {
+	a := assert.New(t)
+
+	bucket := reaperAws.NewS3Bucket("logs", nil)
+	err := reaperAws.DescribeS3BucketStorage(context.Background(), &fakeCloudWatch{datapoints: storageMetrics(3, 2048)}, bucket)
+	a.NoError(err)
+	labels := bucket.GetLabels()
+	a.Equal("3", labels["object_count"])
+	a.Equal("2048", labels["size_bytes"])
+	a.NotContains(labels, "size_unknown")
+
+	// empty buckets and buckets created in the last day have no storage metrics
+	bucket = reaperAws.NewS3Bucket("logs", nil)
+	err = reaperAws.DescribeS3BucketStorage(context.Background(), &fakeCloudWatch{}, bucket)
+	a.NoError(err)
+	labels = bucket.GetLabels()
+	a.Equal("true", labels["size_unknown"])
+	a.NotContains(labels, "object_count")
+	a.NotContains(labels, "size_bytes")
+}
+
+func TestDescribeS3BucketLastModified(t *testing.T) {
+	a := assert.New(t)
+	day := func(d int) *time.Time {
+		return aws.Time(time.Date(2020, 6, d, 12, 0, 0, 0, time.UTC))
+	}
+
+	tests := []struct {
+		name         string
+		svc          fakeS3
+		lastModified string
+	}{
+		{
+			name: "latest version",
+			svc: fakeS3{versions: []*s3.ObjectVersion{
+				{Key: aws.String("a"), LastModified: day(3)},
+				{Key: aws.String("b"), LastModified: day(5)},
+				{Key: aws.String("c"), LastModified: day(4)},
+			}},
+			lastModified: "2020-06-05",
+		},
+		{
+			name: "deleted since",
+			svc: fakeS3{
+				versions:      []*s3.ObjectVersion{{Key: aws.String("a"), LastModified: day(3)}},
+				deleteMarkers: []*s3.DeleteMarkerEntry{{Key: aws.String("b"), LastModified: day(9)}},
+			},
+			lastModified: "2020-06-09",
+		},
+		{
+			name: "more than a page",
+			svc: fakeS3{
+				versions:          []*s3.ObjectVersion{{Key: aws.String("a"), LastModified: day(3)}},
+				versionsTruncated: true,
+			},
+		},
+		{
+			name: "empty",
+		},
+	}
+	for _, test := range tests {
+		svc := &test.svc
+		bucket := reaperAws.NewS3Bucket("logs", svc)
+		a.NoError(reaperAws.DescribeS3BucketLastModified(context.Background(), svc, bucket), test.name)
+		if test.lastModified == "" {
+			a.NotContains(bucket.GetLabels(), "last_modified", test.name)
+		} else {
+			a.Equal(test.lastModified, bucket.GetLabels()["last_modified"], test.name)
+		}
+	}
+}
+
+func TestS3BucketRemediate(t *testing.T) {
+	a := assert.New(t)
+	versions := func(sizes ...int64) []*s3.ObjectVersion {
+		var res []*s3.ObjectVersion
+		for i, size := range sizes {
+			res = append(res, &s3.ObjectVersion{Key: aws.String(string(rune('a' + i))), VersionId: aws.String("1"), Size: aws.Int64(size)})
+		}
+		return res
+	}
+	maxSize := int64(1024)
+
+	tests := []struct {
+		name        string
+		metrics     map[string][]*cloudwatch.Datapoint
+		versions    []*s3.ObjectVersion
+		remediation policy.Remediation
+		calls       []string
+		err         bool
+	}{
+		{
+			name:        "small bucket",
+			metrics:     storageMetrics(2, 300),
+			versions:    versions(100, 200),
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{MaxBucketSize: &maxSize}},
+			calls:       []string{"delete-object a", "delete-object b", "delete-bucket logs"},
+		},
+		{
+			name:        "over the limit in cloudwatch",
+			metrics:     storageMetrics(2, 4096),
+			versions:    versions(2048, 2048),
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{MaxBucketSize: &maxSize}},
+			err:         true,
+		},
+		{
+			name:        "grew past the limit since cloudwatch last reported",
+			metrics:     storageMetrics(1, 100),
+			versions:    versions(100, 1000),
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{MaxBucketSize: &maxSize}},
+			err:         true,
+		},
+		{
+			name:        "unknown size checked against versions",
+			versions:    versions(100),
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{MaxBucketSize: &maxSize}},
+			calls:       []string{"delete-object a", "delete-bucket logs"},
+		},
+		{
+			name:        "unknown size over the limit",
+			versions:    versions(2048),
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{MaxBucketSize: &maxSize}},
+			err:         true,
+		},
+		{
+			name:        "large buckets allowed",
+			metrics:     storageMetrics(1, 4096),
+			versions:    versions(4096),
+			remediation: policy.Remediation{Action: policy.ActionDelete, Delete: policy.DeleteOptions{AllowLargeBuckets: true}},
+			calls:       []string{"delete-object a", "delete-bucket logs"},
+		},
+		{
+			name:        "unsupported action",
+			metrics:     storageMetrics(0, 0),
+			remediation: policy.Remediation{Action: policy.ActionStop},
+			err:         true,
+		},
+	}
+
+	for _, test := range tests {
+		svc := &fakeS3{versions: test.versions}
+		bucket := reaperAws.NewS3Bucket("logs", svc)
+		a.NoError(reaperAws.DescribeS3BucketStorage(context.Background(), &fakeCloudWatch{datapoints: test.metrics}, bucket), test.name)
+		err := bucket.Remediate(test.remediation)
+		if test.err {
+			a.Error(err, test.name)
+		} else {
+			a.NoError(err, test.name)
+		}
+		a.Equal(test.calls, svc.calls, test.name)
+	}
+}
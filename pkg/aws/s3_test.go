@@ -0,0 +1,111 @@
+package aws_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/s3"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribeS3BucketConfiguration(t *testing.T) {
+	accessDenied := awserr.New("AccessDenied", "Access Denied", nil)
+	group := func(uri string, permission string) *s3.Grant {
+		return &s3.Grant{
+			Grantee:    &s3.Grantee{Type: aws.String(s3.TypeGroup), URI: aws.String(uri)},
+			Permission: aws.String(permission),
+		}
+	}
+
+	tests := []struct {
+		name   string
+		svc    fakeS3
+		labels map[string]string
+		unset  []string
+	}{
+		{
+			name: "private bucket without any settings",
+			svc:  fakeS3{grants: []*s3.Grant{{Grantee: &s3.Grantee{Type: aws.String(s3.TypeCanonicalUser)}, Permission: aws.String(s3.PermissionFullControl)}}},
+			labels: map[string]string{
+				"lifecycle_rule_count": "0",
+			},
+			unset: []string{"s3_acl_public", "s3_acl_public_read", "policy_is_public", "public_access_fully_blocked", "encryption", "versioning", "has_replication", "configuration_unknown"},
+		},
+		{
+			name: "readable by anyone",
+			svc:  fakeS3{grants: []*s3.Grant{group("http://acs.amazonaws.com/groups/global/AllUsers", s3.PermissionRead)}},
+			labels: map[string]string{
+				"s3_acl_public":      "true",
+				"s3_acl_public_read": "true",
+			},
+		},
+		{
+			name: "writable by any aws account",
+			svc:  fakeS3{grants: []*s3.Grant{group("http://acs.amazonaws.com/groups/global/AuthenticatedUsers", s3.PermissionWrite)}},
+			labels: map[string]string{
+				"s3_acl_public": "true",
+			},
+			unset: []string{"s3_acl_public_read"},
+		},
+		{
+			name:  "log delivery is not public",
+			svc:   fakeS3{grants: []*s3.Grant{group("http://acs.amazonaws.com/groups/s3/LogDelivery", s3.PermissionWrite)}},
+			unset: []string{"s3_acl_public"},
+		},
+		{
+			name: "fully blocked",
+			svc: fakeS3{publicAccessBlock: &s3.PublicAccessBlockConfiguration{
+				BlockPublicAcls:       aws.Bool(true),
+				IgnorePublicAcls:      aws.Bool(true),
+				BlockPublicPolicy:     aws.Bool(true),
+				RestrictPublicBuckets: aws.Bool(true),
+			}},
+			labels: map[string]string{
+				"block_public_acls":           "true",
+				"public_access_fully_blocked": "true",
+			},
+		},
+		{
+			name: "partly blocked",
+			svc: fakeS3{publicAccessBlock: &s3.PublicAccessBlockConfiguration{
+				BlockPublicAcls:  aws.Bool(true),
+				IgnorePublicAcls: aws.Bool(false),
+			}},
+			labels: map[string]string{
+				"block_public_acls": "true",
+			},
+			unset: []string{"ignore_public_acls", "public_access_fully_blocked"},
+		},
+		{
+			name: "settings we can't read",
+			svc: fakeS3{errs: map[string]error{
+				"GetBucketAcl":                    accessDenied,
+				"GetPublicAccessBlock":            accessDenied,
+				"GetBucketLifecycleConfiguration": accessDenied,
+				"GetBucketReplication":            accessDenied,
+			}},
+			labels: map[string]string{
+				"configuration_unknown": "true",
+			},
+			unset: []string{"s3_acl_public", "public_access_fully_blocked", "lifecycle_rule_count", "has_replication"},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := assert.New(t)
+			bucket := reaperAws.NewS3Bucket("logs", &test.svc)
+			reaperAws.DescribeS3BucketConfiguration(context.Background(), &test.svc, bucket)
+			labels := bucket.GetLabels()
+			for label, value := range test.labels {
+				a.Equal(value, labels[label], label)
+			}
+			for _, label := range test.unset {
+				a.NotContains(labels, label)
+			}
+		})
+	}
+}
@@ -0,0 +1,186 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/aws/aws-sdk-go/aws/endpoints"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/sns"
+	"github.com/aws/aws-sdk-go/service/sns/snsiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// sns_topic specific labels
+const (
+	snsLabelIsFifo                   TypeEntityLabel = "is_fifo"
+	snsLabelIsEncrypted              TypeEntityLabel = "is_encrypted"
+	snsLabelSubscriptionCount        TypeEntityLabel = "subscription_count"
+	snsLabelPendingSubscriptionCount TypeEntityLabel = "pending_subscription_count"
+	snsLabelMessagesPublished        TypeEntityLabel = "messages_published"
+)
+
+// SNSTopic is an evaluation entity representing an sns topic
+type SNSTopic struct {
+	Entity
+	arn string
+}
+
+// GetID returns the topic name
+func (s *SNSTopic) GetID() string {
+	return s.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (s *SNSTopic) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/sns/v3/home?region=%s#/topic/%s"
+	return fmt.Sprintf(t, s.Region, s.Region, s.arn)
+}
+
+// SNSTopicDetails are looked up separately from the topic arn
+type SNSTopicDetails struct {
+	Attributes        map[string]*string
+	Tags              []*sns.Tag
+	MessagesPublished float64
+}
+
+// snsTopicName returns the name of a topic from its arn
+func snsTopicName(topicARN string) string {
+	return topicARN[strings.LastIndex(topicARN, ":")+1:]
+}
+
+// NewSNSTopic returns a new sns topic entity
+func NewSNSTopic(topicARN string, details *SNSTopicDetails, region string) *SNSTopic {
+	entity := &SNSTopic{
+		Entity: NewEntity(),
+		arn:    topicARN,
+	}
+
+	entity.Region = region
+	entity.ID = snsTopicName(topicARN)
+	entity.Name = entity.ID
+
+	for _, tag := range details.Tags {
+		if tag == nil {
+			continue
+		}
+		entity.AddTag(tag.Key, tag.Value)
+	}
+
+	attributes := details.Attributes
+	fifo := aws.StringValue(attributes["FifoTopic"]) == "true"
+	encrypted := aws.StringValue(attributes["KmsMasterKeyId"]) != ""
+	entity.
+		AddLabel(labelARN, &topicARN).
+		AddBoolLabel(snsLabelIsFifo, &fifo).
+		AddBoolLabel(snsLabelIsEncrypted, &encrypted).
+		AddLabel(snsLabelSubscriptionCount, attributes["SubscriptionsConfirmed"]).
+		AddLabel(snsLabelPendingSubscriptionCount, attributes["SubscriptionsPending"]).
+		AddLabel(snsLabelMessagesPublished, formatMetric(details.MessagesPublished))
+
+	return entity
+}
+
+func getSNSTopicDetails(ctx context.Context, svc snsiface.SNSAPI, topicARN string) (*SNSTopicDetails, error) {
+	details := &SNSTopicDetails{}
+	name := snsTopicName(topicARN)
+
+	attributes, err := svc.GetTopicAttributesWithContext(ctx, &sns.GetTopicAttributesInput{TopicArn: &topicARN})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get attributes of sns topic %s", name)
+	}
+	details.Attributes = attributes.Attributes
+
+	tags, err := svc.ListTagsForResourceWithContext(ctx, &sns.ListTagsForResourceInput{ResourceArn: &topicARN})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list tags of sns topic %s", name)
+	}
+	details.Tags = tags.Tags
+	return details, nil
+}
+
+// snsMetricLabels are the labels getSNSTopicMetrics looks up
+var snsMetricLabels = []string{string(snsLabelMessagesPublished)}
+
+// getSNSTopicMetrics adds the cloudwatch metrics of the topic to its details
+func getSNSTopicMetrics(ctx context.Context, cw cloudwatchiface.CloudWatchAPI, topicARN string, details *SNSTopicDetails, lookback time.Duration) error {
+	name := snsTopicName(topicARN)
+	var err error
+	details.MessagesPublished, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/SNS",
+		Metric:     "NumberOfMessagesPublished",
+		Dimensions: map[string]string{"TopicName": name},
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	return errors.Wrapf(err, "could not get messages published to sns topic %s", name)
+}
+
+// EvalSNSTopic walks through all sns topics
+func (c *Client) EvalSNSTopic(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+		svc := sns.New(sess, conf)
+		cw := cloudwatch.New(sess, conf)
+		err := svc.ListTopicsPagesWithContext(ctx, &sns.ListTopicsInput{}, func(output *sns.ListTopicsOutput, lastPage bool) bool {
+			for _, topic := range output.Topics {
+				topicARN := aws.StringValue(topic.TopicArn)
+				details, err := getSNSTopicDetails(ctx, svc, topicARN)
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if !p.MightMatch(NewSNSTopic(topicARN, details, region), snsMetricLabels, false) {
+					continue
+				}
+				err = getSNSTopicMetrics(ctx, cw, topicARN, details, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				s := NewSNSTopic(topicARN, details, region)
+				if p.Match(s) {
+					violation := policy.NewViolation(p, s, p.Expired(s), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, err)
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getSNSTopic(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	partition, ok := endpoints.PartitionForRegion(endpoints.DefaultPartitions(), region)
+	if !ok {
+		return nil, errors.Errorf("could not find the partition of region %s", region)
+	}
+	sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+	topicARN := arn.ARN{
+		Partition: partition.ID(),
+		Service:   sns.ServiceName,
+		Region:    region,
+		AccountID: fmt.Sprintf("%012d", account.ID),
+		Resource:  id,
+	}.String()
+	details, err := getSNSTopicDetails(ctx, sns.New(sess, conf), topicARN)
+	if err != nil {
+		return nil, err
+	}
+	err = getSNSTopicMetrics(ctx, cloudwatch.New(sess, conf), topicARN, details, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewSNSTopic(topicARN, details, region), nil
+}
@@ -0,0 +1,34 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sns"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSNSTopic(t *testing.T) {
+	a := assert.New(t)
+
+	topic := reaperAws.NewSNSTopic("arn:aws:sns:us-west-2:123456789012:alerts", &reaperAws.SNSTopicDetails{
+		Attributes: map[string]*string{
+			"KmsMasterKeyId":         aws.String("alias/aws/sns"),
+			"SubscriptionsConfirmed": aws.String("2"),
+			"SubscriptionsPending":   aws.String("0"),
+		},
+		Tags:              []*sns.Tag{{Key: aws.String("owner"), Value: aws.String("ops@example.com")}, nil},
+		MessagesPublished: 0,
+	}, "us-west-2")
+	a.Equal("alerts", topic.GetID())
+	a.Equal("ops@example.com", topic.GetTags()["owner"])
+	labels := topic.GetLabels()
+	a.Equal("arn:aws:sns:us-west-2:123456789012:alerts", labels["arn"])
+	a.Equal("true", labels["is_encrypted"])
+	a.NotContains(labels, "is_fifo")
+	a.Equal("2", labels["subscription_count"])
+	a.Equal("0", labels["pending_subscription_count"])
+	a.Equal("0", labels["messages_published"])
+	a.Nil(topic.GetCreatedAt())
+}
@@ -0,0 +1,198 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"path"
+	"strconv"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/cloudwatch"
+	"github.com/aws/aws-sdk-go/service/cloudwatch/cloudwatchiface"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	"github.com/aws/aws-sdk-go/service/sqs/sqsiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+// sqs_queue specific labels
+const (
+	sqsLabelIsFifo               TypeEntityLabel = "is_fifo"
+	sqsLabelIsEncrypted          TypeEntityLabel = "is_encrypted"
+	sqsLabelHasDeadLetterQueue   TypeEntityLabel = "has_dead_letter_queue"
+	sqsLabelMessageCount         TypeEntityLabel = "message_count"
+	sqsLabelInFlightMessageCount TypeEntityLabel = "in_flight_message_count"
+	sqsLabelOldestMessageAge     TypeEntityLabel = "oldest_message_age"
+	sqsLabelMessagesSent         TypeEntityLabel = "messages_sent"
+)
+
+// SQSQueue is an evaluation entity representing an sqs queue
+type SQSQueue struct {
+	Entity
+	url string
+}
+
+// GetID returns the queue name
+func (s *SQSQueue) GetID() string {
+	return s.ID
+}
+
+// GetConsoleURL will return a URL for this resource in the AWS console
+func (s *SQSQueue) GetConsoleURL() string {
+	t := "https://%s.console.aws.amazon.com/sqs/v2/home?region=%s#/queues/%s"
+	return fmt.Sprintf(t, s.Region, s.Region, url.QueryEscape(s.url))
+}
+
+// SQSQueueDetails are looked up separately from the queue url
+type SQSQueueDetails struct {
+	Attributes       map[string]*string
+	Tags             map[string]*string
+	OldestMessageAge float64
+	MessagesSent     float64
+}
+
+// NewSQSQueue returns a new sqs queue entity
+func NewSQSQueue(queueURL string, details *SQSQueueDetails, region string) *SQSQueue {
+	entity := &SQSQueue{
+		Entity: NewEntity(),
+		url:    queueURL,
+	}
+
+	entity.Region = region
+	entity.ID = path.Base(queueURL)
+	entity.Name = entity.ID
+
+	for key, value := range details.Tags {
+		entity.AddTag(aws.String(key), value)
+	}
+
+	attributes := details.Attributes
+	fifo := aws.StringValue(attributes[sqs.QueueAttributeNameFifoQueue]) == "true"
+	encrypted := aws.StringValue(attributes[sqs.QueueAttributeNameKmsMasterKeyId]) != ""
+	hasDeadLetterQueue := aws.StringValue(attributes[sqs.QueueAttributeNameRedrivePolicy]) != ""
+	entity.
+		AddLabel(labelARN, attributes[sqs.QueueAttributeNameQueueArn]).
+		AddBoolLabel(sqsLabelIsFifo, &fifo).
+		AddBoolLabel(sqsLabelIsEncrypted, &encrypted).
+		AddBoolLabel(sqsLabelHasDeadLetterQueue, &hasDeadLetterQueue).
+		AddLabel(sqsLabelMessageCount, attributes[sqs.QueueAttributeNameApproximateNumberOfMessages]).
+		AddLabel(sqsLabelInFlightMessageCount, attributes[sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible]).
+		AddLabel(sqsLabelOldestMessageAge, formatMetric(details.OldestMessageAge)).
+		AddLabel(sqsLabelMessagesSent, formatMetric(details.MessagesSent))
+
+	if created, ok := attributes[sqs.QueueAttributeNameCreatedTimestamp]; ok {
+		seconds, err := strconv.ParseInt(aws.StringValue(created), 10, 64)
+		if err == nil {
+			createdAt := time.Unix(seconds, 0)
+			entity.AddCreatedAt(&createdAt)
+		}
+	}
+
+	return entity
+}
+
+func getSQSQueueDetails(ctx context.Context, svc sqsiface.SQSAPI, queueURL string) (*SQSQueueDetails, error) {
+	details := &SQSQueueDetails{}
+	name := path.Base(queueURL)
+
+	attributes, err := svc.GetQueueAttributesWithContext(ctx, &sqs.GetQueueAttributesInput{
+		QueueUrl:       &queueURL,
+		AttributeNames: aws.StringSlice([]string{sqs.QueueAttributeNameAll}),
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get attributes of sqs queue %s", name)
+	}
+	details.Attributes = attributes.Attributes
+
+	tags, err := svc.ListQueueTagsWithContext(ctx, &sqs.ListQueueTagsInput{QueueUrl: &queueURL})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not list tags of sqs queue %s", name)
+	}
+	details.Tags = tags.Tags
+	return details, nil
+}
+
+// sqsMetricLabels are the labels getSQSQueueMetrics looks up
+var sqsMetricLabels = []string{string(sqsLabelOldestMessageAge), string(sqsLabelMessagesSent)}
+
+// getSQSQueueMetrics adds the cloudwatch metrics of the queue to its details
+func getSQSQueueMetrics(ctx context.Context, cw cloudwatchiface.CloudWatchAPI, queueURL string, details *SQSQueueDetails, lookback time.Duration) error {
+	name := path.Base(queueURL)
+	dimensions := map[string]string{"QueueName": name}
+	var err error
+	details.OldestMessageAge, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/SQS",
+		Metric:     "ApproximateAgeOfOldestMessage",
+		Dimensions: dimensions,
+		Statistic:  cloudwatch.StatisticMaximum,
+	}, lookback)
+	if err != nil {
+		return errors.Wrapf(err, "could not get age of oldest message in sqs queue %s", name)
+	}
+	details.MessagesSent, err = getMetric(ctx, cw, metricQuery{
+		Namespace:  "AWS/SQS",
+		Metric:     "NumberOfMessagesSent",
+		Dimensions: dimensions,
+		Statistic:  cloudwatch.StatisticSum,
+	}, lookback)
+	return errors.Wrapf(err, "could not get messages sent to sqs queue %s", name)
+}
+
+// EvalSQSQueue walks through all sqs queues
+func (c *Client) EvalSQSQueue(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
+	var errs error
+	ctx := context.Background()
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+		svc := sqs.New(sess, conf)
+		cw := cloudwatch.New(sess, conf)
+		err := svc.ListQueuesPagesWithContext(ctx, &sqs.ListQueuesInput{}, func(output *sqs.ListQueuesOutput, lastPage bool) bool {
+			for _, queueURL := range output.QueueUrls {
+				details, err := getSQSQueueDetails(ctx, svc, aws.StringValue(queueURL))
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				if !p.MightMatch(NewSQSQueue(aws.StringValue(queueURL), details, region), sqsMetricLabels, false) {
+					continue
+				}
+				err = getSQSQueueMetrics(ctx, cw, aws.StringValue(queueURL), details, p.LookbackPeriod())
+				if err != nil {
+					errs = multierror.Append(errs, err)
+					continue
+				}
+				s := NewSQSQueue(aws.StringValue(queueURL), details, region)
+				if p.Match(s) {
+					violation := policy.NewViolation(p, s, p.Expired(s), account)
+					f(violation)
+				}
+			}
+			return true
+		})
+		errs = multierror.Append(errs, errors.Wrapf(err, "could not list sqs queues in %s %s", account.Name, region))
+	})
+	errs = multierror.Append(errs, err)
+
+	return errs
+}
+
+func (c *Client) getSQSQueue(ctx context.Context, account *policy.Account, region string, id string, lookback time.Duration) (policy.Subject, error) {
+	sess, conf := c.GetSession(account.ID, account.Role, account.ExternalID, region)
+	svc := sqs.New(sess, conf)
+	output, err := svc.GetQueueUrlWithContext(ctx, &sqs.GetQueueUrlInput{QueueName: &id})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not get url of sqs queue %s", id)
+	}
+	details, err := getSQSQueueDetails(ctx, svc, aws.StringValue(output.QueueUrl))
+	if err != nil {
+		return nil, err
+	}
+	err = getSQSQueueMetrics(ctx, cloudwatch.New(sess, conf), aws.StringValue(output.QueueUrl), details, lookback)
+	if err != nil {
+		return nil, err
+	}
+	return NewSQSQueue(aws.StringValue(output.QueueUrl), details, region), nil
+}
@@ -0,0 +1,51 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/sqs"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSQSQueue(t *testing.T) {
+	a := assert.New(t)
+
+	queue := reaperAws.NewSQSQueue("https://sqs.us-west-2.amazonaws.com/123456789012/jobs.fifo", &reaperAws.SQSQueueDetails{
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNameQueueArn:                              aws.String("arn:aws:sqs:us-west-2:123456789012:jobs.fifo"),
+			sqs.QueueAttributeNameFifoQueue:                             aws.String("true"),
+			sqs.QueueAttributeNameKmsMasterKeyId:                        aws.String("alias/aws/sqs"),
+			sqs.QueueAttributeNameApproximateNumberOfMessages:           aws.String("4"),
+			sqs.QueueAttributeNameApproximateNumberOfMessagesNotVisible: aws.String("1"),
+			sqs.QueueAttributeNameCreatedTimestamp:                      aws.String("1577836800"),
+		},
+		Tags:             map[string]*string{"owner": aws.String("jobs@example.com")},
+		OldestMessageAge: 3600.4,
+		MessagesSent:     12,
+	}, "us-west-2")
+	a.Equal("jobs.fifo", queue.GetID())
+	a.Equal("jobs@example.com", queue.GetTags()["owner"])
+	labels := queue.GetLabels()
+	a.Equal("true", labels["is_fifo"])
+	a.Equal("true", labels["is_encrypted"])
+	a.NotContains(labels, "has_dead_letter_queue")
+	a.Equal("4", labels["message_count"])
+	a.Equal("1", labels["in_flight_message_count"])
+	a.Equal("3600", labels["oldest_message_age"])
+	a.Equal("12", labels["messages_sent"])
+	a.Equal(int64(1577836800), queue.GetCreatedAt().Unix())
+
+	queue = reaperAws.NewSQSQueue("https://sqs.us-west-2.amazonaws.com/123456789012/jobs", &reaperAws.SQSQueueDetails{
+		Attributes: map[string]*string{
+			sqs.QueueAttributeNameRedrivePolicy:    aws.String(`{"maxReceiveCount":"5"}`),
+			sqs.QueueAttributeNameCreatedTimestamp: aws.String("not a time"),
+		},
+	}, "us-west-2")
+	labels = queue.GetLabels()
+	a.Equal("true", labels["has_dead_letter_queue"])
+	a.NotContains(labels, "is_fifo")
+	a.NotContains(labels, "is_encrypted")
+	a.Nil(queue.GetCreatedAt())
+}
@@ -0,0 +1,206 @@
+package aws
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/arn"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/pkg/errors"
+)
+
+// ResourceID identifies a single resource, as parsed from an id or an ARN
+type ResourceID struct {
+	// Type is the reaper resource type, empty if it could not be inferred
+	Type string
+	// Region is the region from the ARN, empty if not known
+	Region string
+	ID     string
+}
+
+// arnResourceTypes maps from "service:resource-type" in an ARN to a reaper resource type
+var arnResourceTypes = map[string]string{
+	"ec2:instance":          "ec2_instance",
+	"ec2:volume":            "ebs_volume",
+	"ec2:security-group":    "ec2_security_group",
+	"ec2:vpc":               "vpc",
+	"ec2:snapshot":          "ebs_snapshot",
+	"ec2:image":             "ami",
+	"ec2:elastic-ip":        "elastic_ip",
+	"ec2:network-interface": "network_interface",
+	"ec2:natgateway":        "nat_gateway",
+	"lambda:function":       "lambda_function",
+	"cloudformation:stack":  "cloudformation_stack",
+	"ecs:cluster":           "ecs_cluster",
+	"ecs:service":           "ecs_service",
+	"eks:cluster":           "eks_cluster",
+	"eks:nodegroup":         "eks_nodegroup",
+	"ecr:repository":        "ecr_repository",
+	"dynamodb:table":        "dynamodb_table",
+	"kinesis:stream":        "kinesis_stream",
+	"logs:log-group":        "log_group",
+	"kms:key":               "kms_key",
+	"iam:user":              "iam_user",
+	"iam:role":              "iam_role",
+	"iam:policy":            "iam_policy",
+	"rds:db":                "rds_instance",
+	"rds:cluster":           "rds_cluster",
+}
+
+// arnServiceResourceTypes maps from services whose ARNs end with just the resource name to a
+// reaper resource type
+var arnServiceResourceTypes = map[string]string{
+	"s3":  "s3",
+	"sns": "sns_topic",
+	"sqs": "sqs_queue",
+}
+
+// idPrefixResourceTypes maps from well known id prefixes to a reaper resource type
+var idPrefixResourceTypes = map[string]string{
+	"i-":        "ec2_instance",
+	"vol-":      "ebs_volume",
+	"sg-":       "ec2_security_group",
+	"vpc-":      "vpc",
+	"snap-":     "ebs_snapshot",
+	"ami-":      "ami",
+	"eipalloc-": "elastic_ip",
+	"eni-":      "network_interface",
+	"nat-":      "nat_gateway",
+	"AKIA":      "iam_access_key",
+}
+
+// ParseResourceID parses an id or ARN, inferring the resource type where possible
+func ParseResourceID(id string) ResourceID {
+	if !arn.IsARN(id) {
+		for prefix, resourceType := range idPrefixResourceTypes {
+			if strings.HasPrefix(id, prefix) {
+				return ResourceID{Type: resourceType, ID: id}
+			}
+		}
+		return ResourceID{ID: id}
+	}
+
+	a, err := arn.Parse(id)
+	if err != nil {
+		return ResourceID{ID: id}
+	}
+	if resourceType, ok := arnServiceResourceTypes[a.Service]; ok {
+		return ResourceID{Type: resourceType, Region: a.Region, ID: a.Resource}
+	}
+
+	// most services separate the resource type with a slash, some such as rds with a colon
+	resourceType, name := a.Resource, a.Resource
+	if sep := strings.IndexAny(a.Resource, "/:"); sep >= 0 {
+		resourceType, name = a.Resource[:sep], a.Resource[sep+1:]
+	}
+	res := ResourceID{
+		Type:   arnResourceTypes[a.Service+":"+resourceType],
+		Region: a.Region,
+		ID:     name,
+	}
+	// application and network load balancers share the loadbalancer resource type with classic
+	// ones, and are named app/name/id or net/name/id
+	if a.Service == "elasticloadbalancing" && resourceType == "loadbalancer" {
+		parts := strings.Split(name, "/")
+		switch {
+		case len(parts) == 3 && parts[0] == "app":
+			return ResourceID{Type: "alb", Region: a.Region, ID: parts[1]}
+		case len(parts) == 3 && parts[0] == "net":
+			return ResourceID{Type: "nlb", Region: a.Region, ID: parts[1]}
+		default:
+			return ResourceID{Type: "elb_classic", Region: a.Region, ID: name}
+		}
+	}
+	// stack arns end with the stack's unique id
+	if a.Service == "cloudformation" {
+		res.ID = strings.SplitN(res.ID, "/", 2)[0]
+	}
+	// nodegroup arns end with the nodegroup's unique id
+	if i := strings.LastIndex(res.ID, "/"); a.Service == "eks" && resourceType == "nodegroup" && i >= 0 {
+		res.ID = res.ID[:i]
+	}
+	// log group arns usually end with a :* wildcard for the group's streams
+	if a.Service == "logs" {
+		res.ID = strings.TrimSuffix(res.ID, ":*")
+	}
+	// iam paths live between the resource type and the name
+	if a.Service == "iam" {
+		res.ID = res.ID[strings.LastIndex(res.ID, "/")+1:]
+	}
+	return res
+}
+
+// GetSubject fetches the single resource of resourceType identified by id. Labels computed from
+// usage metrics look back over lookback.
+func (c *Client) GetSubject(account *policy.Account, region string, resourceType string, id string, lookback time.Duration) (policy.Subject, error) {
+	ctx := context.Background()
+	switch resourceType {
+	case "s3":
+		return c.getS3Bucket(ctx, account, id)
+	case "ec2_instance":
+		return c.getEc2Instance(ctx, account, region, id)
+	case "vpc":
+		return c.getVPC(ctx, account, region, id)
+	case "iam_user":
+		return c.getIAMUser(ctx, account, id)
+	case "ebs_volume":
+		return c.getEbsVolume(ctx, account, region, id)
+	case "ec2_security_group":
+		return c.getEC2SG(ctx, account, region, id)
+	case "kms_key":
+		return c.getKMSKey(ctx, account, region, id)
+	case "iam_access_key":
+		return c.getIAMAccessKey(ctx, account, id)
+	case "rds_instance":
+		return c.getRDSInstance(ctx, account, region, id)
+	case "rds_cluster":
+		return c.getRDSCluster(ctx, account, region, id)
+	case "ebs_snapshot":
+		return c.getEBSSnapshot(ctx, account, region, id)
+	case "ami":
+		return c.getAMI(ctx, account, region, id)
+	case "elastic_ip":
+		return c.getElasticIP(ctx, account, region, id)
+	case "network_interface":
+		return c.getNetworkInterface(ctx, account, region, id)
+	case "nat_gateway":
+		return c.getNatGateway(ctx, account, region, id, lookback)
+	case "elb_classic":
+		return c.getELBClassic(ctx, account, region, id, lookback)
+	case "alb", "nlb":
+		return c.getLoadBalancerV2(ctx, account, region, id, lookback)
+	case "lambda_function":
+		return c.getLambdaFunction(ctx, account, region, id, lookback)
+	case "cloudformation_stack":
+		return c.getCloudFormationStack(ctx, account, region, id)
+	case "ecs_cluster":
+		return c.getECSCluster(ctx, account, region, id)
+	case "ecs_service":
+		return c.getECSService(ctx, account, region, id)
+	case "eks_cluster":
+		return c.getEKSCluster(ctx, account, region, id)
+	case "eks_nodegroup":
+		return c.getEKSNodegroup(ctx, account, region, id)
+	case "ecr_repository":
+		return c.getECRRepository(ctx, account, region, id)
+	case "dynamodb_table":
+		return c.getDynamoDBTable(ctx, account, region, id, lookback)
+	case "sqs_queue":
+		return c.getSQSQueue(ctx, account, region, id, lookback)
+	case "sns_topic":
+		return c.getSNSTopic(ctx, account, region, id, lookback)
+	case "kinesis_stream":
+		return c.getKinesisStream(ctx, account, region, id, lookback)
+	case "log_group":
+		return c.getLogGroup(ctx, account, region, id)
+	case "iam_role":
+		return c.getIAMRole(ctx, account, id)
+	case "iam_policy":
+		return c.getIAMPolicy(ctx, account, id)
+	case "trusted_advisor_finding":
+		return c.getTrustedAdvisorFinding(ctx, account, id)
+	default:
+		return nil, errors.Errorf("unknown resource type %s", resourceType)
+	}
+}
@@ -0,0 +1,38 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResourceID(t *testing.T) {
+	tests := []struct {
+		id       string
+		expected aws.ResourceID
+	}{
+		{"i-0123456789abcdef0", aws.ResourceID{Type: "ec2_instance", ID: "i-0123456789abcdef0"}},
+		{"something", aws.ResourceID{ID: "something"}},
+		{"arn:aws:s3:::bucket", aws.ResourceID{Type: "s3", ID: "bucket"}},
+		{"arn:aws:ec2:us-west-2:123456789012:volume/vol-123", aws.ResourceID{Type: "ebs_volume", Region: "us-west-2", ID: "vol-123"}},
+		{"arn:aws:iam::123456789012:user/path/to/bob", aws.ResourceID{Type: "iam_user", ID: "bob"}},
+		{"arn:aws:rds:us-east-1:123456789012:db:mydb", aws.ResourceID{Type: "rds_instance", Region: "us-east-1", ID: "mydb"}},
+		{"arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/app/web/50dc6c495c0c9188", aws.ResourceID{Type: "alb", Region: "us-east-1", ID: "web"}},
+		{"arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/net/tcp/50dc6c495c0c9188", aws.ResourceID{Type: "nlb", Region: "us-east-1", ID: "tcp"}},
+		{"arn:aws:elasticloadbalancing:us-east-1:123456789012:loadbalancer/old", aws.ResourceID{Type: "elb_classic", Region: "us-east-1", ID: "old"}},
+		{"arn:aws:cloudformation:us-east-1:123456789012:stack/test/c8d2a3b0-1234-11ea-8d71-362b9e155667", aws.ResourceID{Type: "cloudformation_stack", Region: "us-east-1", ID: "test"}},
+		{"arn:aws:eks:us-east-1:123456789012:nodegroup/prod/workers/a2b8c7d6-1234-5678-90ab-cdef12345678", aws.ResourceID{Type: "eks_nodegroup", Region: "us-east-1", ID: "prod/workers"}},
+		{"arn:aws:ecr:us-east-1:123456789012:repository/team/app", aws.ResourceID{Type: "ecr_repository", Region: "us-east-1", ID: "team/app"}},
+		{"arn:aws:sns:us-east-1:123456789012:alerts", aws.ResourceID{Type: "sns_topic", Region: "us-east-1", ID: "alerts"}},
+		{"arn:aws:dynamodb:us-east-1:123456789012:table/users", aws.ResourceID{Type: "dynamodb_table", Region: "us-east-1", ID: "users"}},
+		{"arn:aws:logs:us-east-1:123456789012:log-group:/aws/lambda/foo:*", aws.ResourceID{Type: "log_group", Region: "us-east-1", ID: "/aws/lambda/foo"}},
+		{"arn:aws:iam::123456789012:role/service-role/lambda-exec", aws.ResourceID{Type: "iam_role", ID: "lambda-exec"}},
+		{"arn:aws:iam::123456789012:policy/deploy", aws.ResourceID{Type: "iam_policy", ID: "deploy"}},
+	}
+	for _, test := range tests {
+		t.Run(test.id, func(t *testing.T) {
+			assert.Equal(t, test.expected, aws.ParseResourceID(test.id))
+		})
+	}
+}
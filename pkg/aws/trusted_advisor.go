@@ -0,0 +1,422 @@
+package aws
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/aws/awserr"
+	"github.com/aws/aws-sdk-go/service/support"
+	"github.com/aws/aws-sdk-go/service/support/supportiface"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// trusted_advisor_finding specific labels
+const (
+	trustedAdvisorLabelCheckID                 TypeEntityLabel = "check_id"
+	trustedAdvisorLabelCheckName               TypeEntityLabel = "check_name"
+	trustedAdvisorLabelCategory                TypeEntityLabel = "category"
+	trustedAdvisorLabelStatus                  TypeEntityLabel = "status"
+	trustedAdvisorLabelFlaggedResourceCount    TypeEntityLabel = "flagged_resource_count"
+	trustedAdvisorLabelSuppressedResourceCount TypeEntityLabel = "suppressed_resource_count"
+	trustedAdvisorLabelProcessedResourceCount  TypeEntityLabel = "processed_resource_count"
+	trustedAdvisorLabelFlaggedRegions          TypeEntityLabel = "flagged_regions"
+	trustedAdvisorLabelMonthlySavings          TypeEntityLabel = "estimated_monthly_savings"
+	trustedAdvisorLabelPercentMonthlySavings   TypeEntityLabel = "estimated_percent_monthly_savings"
+	trustedAdvisorLabelLastRefreshed           TypeEntityLabel = "last_refreshed"
+	trustedAdvisorLabelLastRefreshedDays       TypeEntityLabel = "last_refreshed_days"
+)
+
+// the statuses of a check result, DescribeTrustedAdvisorCheckResult has no enum for them
+const (
+	trustedAdvisorStatusOK           = "ok"
+	trustedAdvisorStatusWarning      = "warning"
+	trustedAdvisorStatusError        = "error"
+	trustedAdvisorStatusNotAvailable = "not_available"
+)
+
+// the statuses of a check refresh we care about, besides none and success
+const (
+	trustedAdvisorRefreshEnqueued   = "enqueued"
+	trustedAdvisorRefreshProcessing = "processing"
+	trustedAdvisorRefreshAbandoned  = "abandoned"
+)
+
+// the categories checks belong to
+const (
+	trustedAdvisorCategoryCostOptimizing = "cost_optimizing"
+	trustedAdvisorCategorySecurity       = "security"
+	trustedAdvisorCategoryFaultTolerance = "fault_tolerance"
+	trustedAdvisorCategoryPerformance    = "performance"
+	trustedAdvisorCategoryServiceLimits  = "service_limits"
+)
+
+// error codes of the support api the sdk has no constants for
+const (
+	// returned to accounts without a Business or Enterprise support plan
+	supportErrCodeSubscriptionRequired = "SubscriptionRequiredException"
+	// returned when refreshing a check AWS refreshes automatically
+	supportErrCodeInvalidParameterValue = "InvalidParameterValueException"
+)
+
+// how often and, by default, for how long we poll checks we refreshed
+const (
+	trustedAdvisorRefreshPollInterval = 10 * time.Second
+	// DefaultTrustedAdvisorRefreshTimeout is how long we wait for refreshed checks to finish
+	DefaultTrustedAdvisorRefreshTimeout = 10 * time.Minute
+)
+
+// trustedAdvisorLanguage is the language of check names and metadata
+const trustedAdvisorLanguage = "en"
+
+// label values can only hold alphanumerics, dashes, underscores and dots
+var trustedAdvisorCheckNameInvalid = regexp.MustCompile(`[^a-z0-9]+`)
+
+// TrustedAdvisorFinding is an evaluation entity representing the result of a Trusted Advisor
+// check in an account
+type TrustedAdvisorFinding struct {
+	Entity
+	category         string
+	flaggedResources []TrustedAdvisorFlaggedResource
+}
+
+// TrustedAdvisorFlaggedResource is a resource a check flagged
+type TrustedAdvisorFlaggedResource struct {
+	ResourceID string
+	Region     string
+	Status     string
+	// Metadata maps from the columns of the check, such as Instance ID, to their values
+	Metadata map[string]string
+}
+
+// GetID returns the check id
+func (t *TrustedAdvisorFinding) GetID() string {
+	return t.ID
+}
+
+// GetConsoleURL will return a URL for this check in the AWS console
+func (t *TrustedAdvisorFinding) GetConsoleURL() string {
+	s := "https://console.aws.amazon.com/trustedadvisor/home#/category/%s?checkId=%s"
+	return fmt.Sprintf(s, strings.ReplaceAll(t.category, "_", "-"), t.ID)
+}
+
+// FlaggedResources returns the resources the check flagged and which were not suppressed, for
+// use in notification templates
+func (t *TrustedAdvisorFinding) FlaggedResources() []TrustedAdvisorFlaggedResource {
+	return t.flaggedResources
+}
+
+// NewTrustedAdvisorFinding returns a new trusted advisor finding entity
+func NewTrustedAdvisorFinding(check *support.TrustedAdvisorCheckDescription, summary *support.TrustedAdvisorCheckSummary, flagged []*support.TrustedAdvisorResourceDetail, now time.Time) *TrustedAdvisorFinding {
+	entity := &TrustedAdvisorFinding{
+		Entity: NewEntity(),
+	}
+	if check == nil {
+		return entity
+	}
+	entity.ID = aws.StringValue(check.Id)
+	entity.Name = aws.StringValue(check.Name)
+	entity.category = aws.StringValue(check.Category)
+	checkName := trustedAdvisorCheckLabel(entity.Name)
+	entity.
+		AddLabel(trustedAdvisorLabelCheckID, check.Id).
+		AddLabel(trustedAdvisorLabelCheckName, &checkName).
+		AddLabel(trustedAdvisorLabelCategory, check.Category)
+	if summary == nil {
+		return entity
+	}
+
+	entity.AddLabel(trustedAdvisorLabelStatus, summary.Status)
+	if resources := summary.ResourcesSummary; resources != nil {
+		entity.
+			AddInt64Label(trustedAdvisorLabelFlaggedResourceCount, resources.ResourcesFlagged).
+			AddInt64Label(trustedAdvisorLabelSuppressedResourceCount, resources.ResourcesSuppressed).
+			AddInt64Label(trustedAdvisorLabelProcessedResourceCount, resources.ResourcesProcessed)
+	}
+	if summary.CategorySpecificSummary != nil && summary.CategorySpecificSummary.CostOptimizing != nil {
+		costOptimizing := summary.CategorySpecificSummary.CostOptimizing
+		// selectors can only compare whole numbers
+		savings := int64(math.Floor(aws.Float64Value(costOptimizing.EstimatedMonthlySavings)))
+		percentSavings := int64(math.Floor(aws.Float64Value(costOptimizing.EstimatedPercentMonthlySavings)))
+		entity.
+			AddInt64Label(trustedAdvisorLabelMonthlySavings, &savings).
+			AddInt64Label(trustedAdvisorLabelPercentMonthlySavings, &percentSavings)
+	}
+	refreshed, err := time.Parse(time.RFC3339, aws.StringValue(summary.Timestamp))
+	if err == nil {
+		days := int64(math.Floor(now.Sub(refreshed).Hours() / 24))
+		entity.
+			AddLabel(trustedAdvisorLabelLastRefreshed, aws.String(refreshed.UTC().Format("2006-01-02"))).
+			AddInt64Label(trustedAdvisorLabelLastRefreshedDays, &days)
+	}
+
+	regions := []string{}
+	for _, resource := range flagged {
+		if resource == nil || aws.BoolValue(resource.IsSuppressed) || aws.StringValue(resource.Status) == trustedAdvisorStatusOK {
+			continue
+		}
+		r := TrustedAdvisorFlaggedResource{
+			ResourceID: aws.StringValue(resource.ResourceId),
+			Region:     aws.StringValue(resource.Region),
+			Status:     aws.StringValue(resource.Status),
+			Metadata:   map[string]string{},
+		}
+		for i, column := range check.Metadata {
+			if i < len(resource.Metadata) && column != nil && resource.Metadata[i] != nil {
+				r.Metadata[*column] = *resource.Metadata[i]
+			}
+		}
+		entity.flaggedResources = append(entity.flaggedResources, r)
+		if r.Region != "" && !containsString(regions, r.Region) {
+			regions = append(regions, r.Region)
+		}
+	}
+	if len(regions) > 0 {
+		sort.Strings(regions)
+		entity.AddLabel(trustedAdvisorLabelFlaggedRegions, aws.String(strings.Join(regions, ",")))
+	}
+
+	return entity
+}
+
+// trustedAdvisorCheckLabel turns the name of a check into a label value, e.g.
+// Low Utilization Amazon EC2 Instances becomes low_utilization_amazon_ec2_instances
+func trustedAdvisorCheckLabel(name string) string {
+	label := strings.Trim(trustedAdvisorCheckNameInvalid.ReplaceAllString(strings.ToLower(name), "_"), "_")
+	// label values are at most 63 characters long
+	if len(label) > 63 {
+		label = strings.TrimRight(label[:63], "_")
+	}
+	return label
+}
+
+// isSupportErrCode returns true if err is an error of the support api with the code
+func isSupportErrCode(err error, code string) bool {
+	aerr, ok := errors.Cause(err).(awserr.Error)
+	return ok && aerr.Code() == code
+}
+
+// describeTrustedAdvisorChecks lists the checks available to an account
+func describeTrustedAdvisorChecks(ctx context.Context, svc supportiface.SupportAPI) ([]*support.TrustedAdvisorCheckDescription, error) {
+	output, err := svc.DescribeTrustedAdvisorChecksWithContext(ctx, &support.DescribeTrustedAdvisorChecksInput{
+		Language: aws.String(trustedAdvisorLanguage),
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe trusted advisor checks")
+	}
+	return output.Checks, nil
+}
+
+// getTrustedAdvisorFindings reads the result of the checks, every check when checkID is empty. We
+// only fetch the flagged resources of checks whose summary says they have some.
+func getTrustedAdvisorFindings(ctx context.Context, svc supportiface.SupportAPI, checkID string, now time.Time) ([]*TrustedAdvisorFinding, error) {
+	checks, err := describeTrustedAdvisorChecks(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+	checkIDs := []*string{}
+	checksByID := map[string]*support.TrustedAdvisorCheckDescription{}
+	for _, check := range checks {
+		if checkID != "" && aws.StringValue(check.Id) != checkID {
+			continue
+		}
+		checkIDs = append(checkIDs, check.Id)
+		checksByID[aws.StringValue(check.Id)] = check
+	}
+	if len(checkIDs) == 0 {
+		return nil, nil
+	}
+
+	summaries, err := svc.DescribeTrustedAdvisorCheckSummariesWithContext(ctx, &support.DescribeTrustedAdvisorCheckSummariesInput{CheckIds: checkIDs})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe trusted advisor check summaries")
+	}
+
+	var errs *multierror.Error
+	findings := []*TrustedAdvisorFinding{}
+	for _, summary := range summaries.Summaries {
+		check, ok := checksByID[aws.StringValue(summary.CheckId)]
+		if !ok {
+			continue
+		}
+		var flagged []*support.TrustedAdvisorResourceDetail
+		if aws.BoolValue(summary.HasFlaggedResources) {
+			result, err := svc.DescribeTrustedAdvisorCheckResultWithContext(ctx, &support.DescribeTrustedAdvisorCheckResultInput{
+				CheckId:  check.Id,
+				Language: aws.String(trustedAdvisorLanguage),
+			})
+			if err != nil {
+				errs = multierror.Append(errs, errors.Wrapf(err, "could not describe the result of trusted advisor check %s", aws.StringValue(check.Name)))
+				continue
+			}
+			flagged = result.Result.FlaggedResources
+		}
+		findings = append(findings, NewTrustedAdvisorFinding(check, summary, flagged, now))
+	}
+	return findings, errs.ErrorOrNil()
+}
+
+// trustedAdvisorRefresh is a refresh of the checks of an account that is still queued or processing
+type trustedAdvisorRefresh struct {
+	account *policy.Account
+	svc     supportiface.SupportAPI
+	pending []*string
+}
+
+// startTrustedAdvisorRefresh refreshes every check AWS doesn't refresh automatically and returns
+// those that are queued or processing. Checks that can't be refreshed are skipped.
+func startTrustedAdvisorRefresh(ctx context.Context, svc supportiface.SupportAPI) ([]*string, error) {
+	checks, err := describeTrustedAdvisorChecks(ctx, svc)
+	if err != nil {
+		return nil, err
+	}
+
+	pending := []*string{}
+	for _, check := range checks {
+		output, err := svc.RefreshTrustedAdvisorCheckWithContext(ctx, &support.RefreshTrustedAdvisorCheckInput{CheckId: check.Id})
+		if isSupportErrCode(err, supportErrCodeInvalidParameterValue) {
+			log.Debugf("trusted advisor check %s is refreshed automatically", aws.StringValue(check.Name))
+			continue
+		}
+		if err != nil {
+			log.Warnf("Skipping trusted advisor check %s, which could not be refreshed: %s", aws.StringValue(check.Name), err)
+			continue
+		}
+		if trustedAdvisorRefreshPending(output.Status) {
+			pending = append(pending, check.Id)
+		}
+	}
+	return pending, nil
+}
+
+// pollTrustedAdvisorRefresh returns the checks whose refresh is still queued or processing
+func pollTrustedAdvisorRefresh(ctx context.Context, svc supportiface.SupportAPI, checkIDs []*string) ([]*string, error) {
+	output, err := svc.DescribeTrustedAdvisorCheckRefreshStatusesWithContext(ctx, &support.DescribeTrustedAdvisorCheckRefreshStatusesInput{CheckIds: checkIDs})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe trusted advisor check refresh statuses")
+	}
+	pending := []*string{}
+	for _, status := range output.Statuses {
+		if trustedAdvisorRefreshPending(status) {
+			pending = append(pending, status.CheckId)
+		}
+		if aws.StringValue(status.Status) == trustedAdvisorRefreshAbandoned {
+			log.Warnf("AWS abandoned the refresh of trusted advisor check %s", aws.StringValue(status.CheckId))
+		}
+	}
+	return pending, nil
+}
+
+// trustedAdvisorRefreshPending returns true while a check is waiting to be or being refreshed
+func trustedAdvisorRefreshPending(status *support.TrustedAdvisorCheckRefreshStatus) bool {
+	if status == nil {
+		return false
+	}
+	s := aws.StringValue(status.Status)
+	return s == trustedAdvisorRefreshEnqueued || s == trustedAdvisorRefreshProcessing
+}
+
+// RefreshTrustedAdvisorChecks refreshes the Trusted Advisor checks of every account and waits for
+// them to finish, for at most timeout. The refreshes of all accounts are started before waiting on
+// any of them. Accounts without a Business or Enterprise support plan can't use the api and are
+// skipped.
+func (c *Client) RefreshTrustedAdvisorChecks(accounts []*policy.Account, timeout *time.Duration) error {
+	var errs *multierror.Error
+	t := DefaultTrustedAdvisorRefreshTimeout
+	if timeout != nil {
+		t = *timeout
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), t)
+	defer cancel()
+
+	refreshes := []*trustedAdvisorRefresh{}
+	for _, account := range accounts {
+		log.Infof("Refreshing trusted advisor checks for %s", account.Name)
+		svc := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion).Support
+		pending, err := startTrustedAdvisorRefresh(ctx, svc)
+		if isSupportErrCode(err, supportErrCodeSubscriptionRequired) {
+			log.Warnf("Skipping trusted advisor checks in %s, which needs a Business or Enterprise support plan", account.Name)
+			continue
+		}
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not refresh trusted advisor checks in %s", account.Name))
+			continue
+		}
+		if len(pending) > 0 {
+			refreshes = append(refreshes, &trustedAdvisorRefresh{account: account, svc: svc, pending: pending})
+		}
+	}
+
+	for len(refreshes) > 0 {
+		log.Infof("Waiting for trusted advisor checks to refresh in %d accounts", len(refreshes))
+		select {
+		case <-ctx.Done():
+			for _, refresh := range refreshes {
+				errs = multierror.Append(errs, errors.Errorf("gave up waiting for %d trusted advisor checks to refresh in %s", len(refresh.pending), refresh.account.Name))
+			}
+			return errs.ErrorOrNil()
+		case <-time.After(trustedAdvisorRefreshPollInterval):
+		}
+
+		stillRefreshing := []*trustedAdvisorRefresh{}
+		for _, refresh := range refreshes {
+			pending, err := pollTrustedAdvisorRefresh(ctx, refresh.svc, refresh.pending)
+			if err != nil {
+				errs = multierror.Append(errs, errors.Wrapf(err, "could not refresh trusted advisor checks in %s", refresh.account.Name))
+				continue
+			}
+			if len(pending) > 0 {
+				refresh.pending = pending
+				stillRefreshing = append(stillRefreshing, refresh)
+			}
+		}
+		refreshes = stillRefreshing
+	}
+	return errs.ErrorOrNil()
+}
+
+// EvalTrustedAdvisorFinding walks through the Trusted Advisor check results of every account
+func (c *Client) EvalTrustedAdvisorFinding(accounts []*policy.Account, p policy.Policy, f func(policy.Violation)) error {
+	var errs *multierror.Error
+	ctx := context.Background()
+	now := time.Now()
+	for _, account := range accounts {
+		log.Infof("Walking trusted advisor checks for %s", account.Name)
+		svc := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion).Support
+		findings, err := getTrustedAdvisorFindings(ctx, svc, "", now)
+		if isSupportErrCode(err, supportErrCodeSubscriptionRequired) {
+			log.Warnf("Skipping trusted advisor checks in %s, which needs a Business or Enterprise support plan", account.Name)
+			continue
+		}
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not read trusted advisor checks in %s", account.Name))
+		}
+		for _, finding := range findings {
+			if p.Match(finding) {
+				violation := policy.NewViolation(p, finding, p.Expired(finding), account)
+				f(violation)
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+func (c *Client) getTrustedAdvisorFinding(ctx context.Context, account *policy.Account, id string) (policy.Subject, error) {
+	svc := c.Get(account.ID, account.Role, account.ExternalID, DefaultRegion).Support
+	findings, err := getTrustedAdvisorFindings(ctx, svc, id, time.Now())
+	if err != nil {
+		return nil, err
+	}
+	if len(findings) == 0 {
+		return nil, errors.Errorf("no trusted advisor check %s", id)
+	}
+	return findings[0], nil
+}
@@ -0,0 +1,104 @@
+package aws_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/support"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewTrustedAdvisorFinding(t *testing.T) {
+	a := assert.New(t)
+	now := time.Date(2020, 4, 10, 12, 0, 0, 0, time.UTC)
+	check := &support.TrustedAdvisorCheckDescription{
+		Id:       aws.String("Qch7DwouX1"),
+		Name:     aws.String("Low Utilization Amazon EC2 Instances"),
+		Category: aws.String("cost_optimizing"),
+		Metadata: aws.StringSlice([]string{"Region/AZ", "Instance ID", "Instance Name"}),
+	}
+
+	tests := []struct {
+		name    string
+		summary *support.TrustedAdvisorCheckSummary
+		flagged []*support.TrustedAdvisorResourceDetail
+		labels  map[string]string
+		unset   []string
+		ids     []string
+	}{
+		{
+			name: "flagged instances",
+			summary: &support.TrustedAdvisorCheckSummary{
+				Status:    aws.String("warning"),
+				Timestamp: aws.String("2020-04-01T08:00:00Z"),
+				ResourcesSummary: &support.TrustedAdvisorResourcesSummary{
+					ResourcesFlagged:    aws.Int64(2),
+					ResourcesSuppressed: aws.Int64(1),
+					ResourcesProcessed:  aws.Int64(40),
+				},
+				CategorySpecificSummary: &support.TrustedAdvisorCategorySpecificSummary{
+					CostOptimizing: &support.TrustedAdvisorCostOptimizingSummary{
+						EstimatedMonthlySavings:        aws.Float64(123.75),
+						EstimatedPercentMonthlySavings: aws.Float64(4.5),
+					},
+				},
+			},
+			flagged: []*support.TrustedAdvisorResourceDetail{
+				{ResourceId: aws.String("r1"), Region: aws.String("us-west-2"), Status: aws.String("warning"), Metadata: aws.StringSlice([]string{"us-west-2a", "i-1", "web"})},
+				{ResourceId: aws.String("r2"), Region: aws.String("us-east-1"), Status: aws.String("warning"), Metadata: aws.StringSlice([]string{"us-east-1a", "i-2", "worker"})},
+				{ResourceId: aws.String("r3"), Region: aws.String("eu-west-1"), Status: aws.String("warning"), IsSuppressed: aws.Bool(true)},
+			},
+			labels: map[string]string{
+				"check_id":                          "Qch7DwouX1",
+				"check_name":                        "low_utilization_amazon_ec2_instances",
+				"category":                          "cost_optimizing",
+				"status":                            "warning",
+				"flagged_resource_count":            "2",
+				"suppressed_resource_count":         "1",
+				"processed_resource_count":          "40",
+				"flagged_regions":                   "us-east-1,us-west-2",
+				"estimated_monthly_savings":         "123",
+				"estimated_percent_monthly_savings": "4",
+				"last_refreshed":                    "2020-04-01",
+				"last_refreshed_days":               "9",
+			},
+			ids: []string{"r1", "r2"},
+		},
+		{
+			name: "nothing flagged",
+			summary: &support.TrustedAdvisorCheckSummary{
+				Status:           aws.String("ok"),
+				ResourcesSummary: &support.TrustedAdvisorResourcesSummary{ResourcesFlagged: aws.Int64(0)},
+			},
+			labels: map[string]string{
+				"status":                 "ok",
+				"flagged_resource_count": "0",
+			},
+			unset: []string{"flagged_regions", "estimated_monthly_savings", "last_refreshed"},
+		},
+	}
+
+	for _, test := range tests {
+		finding := reaperAws.NewTrustedAdvisorFinding(check, test.summary, test.flagged, now)
+		labels := finding.GetLabels()
+		for label, value := range test.labels {
+			a.Equal(value, labels[label], "%s: %s", test.name, label)
+		}
+		for _, label := range test.unset {
+			a.NotContains(labels, label, test.name)
+		}
+		a.Equal("Low Utilization Amazon EC2 Instances", finding.GetName(), test.name)
+
+		ids := []string{}
+		for _, resource := range finding.FlaggedResources() {
+			ids = append(ids, resource.ResourceID)
+		}
+		a.ElementsMatch(test.ids, ids, test.name)
+	}
+
+	resources := reaperAws.NewTrustedAdvisorFinding(check, tests[0].summary, tests[0].flagged, now).FlaggedResources()
+	a.Equal("i-1", resources[0].Metadata["Instance ID"])
+	a.Equal("web", resources[0].Metadata["Instance Name"])
+}
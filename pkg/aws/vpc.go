@@ -3,18 +3,48 @@ package aws
 import (
 	"context"
 	"fmt"
+	"strings"
 
+	"github.com/aws/aws-sdk-go/aws"
 	"github.com/aws/aws-sdk-go/service/ec2"
-	cziAws "github.com/chanzuckerberg/go-misc/aws"
+	"github.com/aws/aws-sdk-go/service/ec2/ec2iface"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// vpc specific labels
+const (
+	vpcLabelIsDefault               TypeEntityLabel = "is_default"
+	vpcLabelSubnetCount             TypeEntityLabel = "subnet_count"
+	vpcLabelENICount                TypeEntityLabel = "eni_count"
+	vpcLabelInternetGatewayAttached TypeEntityLabel = "internet_gateway_attached"
+	vpcLabelFlowLogsEnabled         TypeEntityLabel = "flow_logs_enabled"
+	vpcLabelPeeringConnectionCount  TypeEntityLabel = "peering_connection_count"
+	vpcLabelCIDRBlocks              TypeEntityLabel = "cidr_blocks"
+	vpcLabelCIDRBlockCount          TypeEntityLabel = "cidr_block_count"
 )
 
 // VPC represents an AWS VPC
 type VPC struct {
 	Entity
-	ID   string
-	Name string
+	ID                     string
+	Name                   string
+	isDefault              bool
+	eniCount               int64
+	peeringConnectionCount int64
+	svc                    ec2iface.EC2API
+}
+
+// VPCResources counts what is in a vpc or attached to it
+type VPCResources struct {
+	SubnetCount             int64
+	ENICount                int64
+	InternetGatewayAttached bool
+	FlowLogsEnabled         bool
+	// PeeringConnectionCount counts the active peering connections the vpc accepted or requested
+	PeeringConnectionCount int64
 }
 
 // GetID returns the id of the VPC
@@ -38,9 +68,12 @@ func (v *VPC) GetOwner() string {
 }
 
 // NewVpc returns a new vpc entity
-func NewVpc(vpc *ec2.Vpc, region string) *VPC {
+func NewVpc(vpc *ec2.Vpc, resources VPCResources, region string, svc ec2iface.EC2API) *VPC {
 	entity := &VPC{
-		Entity: NewEntity(),
+		Entity:                 NewEntity(),
+		eniCount:               resources.ENICount,
+		peeringConnectionCount: resources.PeeringConnectionCount,
+		svc:                    svc,
 	}
 	if vpc == nil {
 		return entity
@@ -62,27 +95,405 @@ func NewVpc(vpc *ec2.Vpc, region string) *VPC {
 		entity.AddTag(tag.Key, tag.Value)
 	}
 
-	entity.AddBoolLabel("is_default", vpc.IsDefault)
+	var cidrBlocks []string
+	for _, association := range vpc.CidrBlockAssociationSet {
+		if association.CidrBlockState == nil || aws.StringValue(association.CidrBlockState.State) == ec2.VpcCidrBlockStateCodeAssociated {
+			cidrBlocks = append(cidrBlocks, aws.StringValue(association.CidrBlock))
+		}
+	}
+	for _, association := range vpc.Ipv6CidrBlockAssociationSet {
+		if association.Ipv6CidrBlockState == nil || aws.StringValue(association.Ipv6CidrBlockState.State) == ec2.VpcCidrBlockStateCodeAssociated {
+			cidrBlocks = append(cidrBlocks, aws.StringValue(association.Ipv6CidrBlock))
+		}
+	}
+	// vpcs always have their primary block, older responses may not list it as an association
+	if len(cidrBlocks) == 0 && vpc.CidrBlock != nil {
+		cidrBlocks = append(cidrBlocks, *vpc.CidrBlock)
+	}
+	cidrBlockCount := int64(len(cidrBlocks))
+
+	entity.isDefault = aws.BoolValue(vpc.IsDefault)
+	entity.
+		AddBoolLabel(vpcLabelIsDefault, vpc.IsDefault).
+		AddInt64Label(vpcLabelSubnetCount, &resources.SubnetCount).
+		AddInt64Label(vpcLabelENICount, &resources.ENICount).
+		AddBoolLabel(vpcLabelInternetGatewayAttached, &resources.InternetGatewayAttached).
+		AddBoolLabel(vpcLabelFlowLogsEnabled, &resources.FlowLogsEnabled).
+		AddInt64Label(vpcLabelPeeringConnectionCount, &resources.PeeringConnectionCount).
+		AddLabel(vpcLabelCIDRBlocks, aws.String(strings.Join(cidrBlocks, ","))).
+		AddInt64Label(vpcLabelCIDRBlockCount, &cidrBlockCount)
 
 	return entity
 }
 
+// Delete deletes the vpc if it is empty
+func (v *VPC) Delete() error {
+	return v.Remediate(policy.Remediation{Action: policy.ActionDelete})
+}
+
+// Remediate deletes an empty, non-default vpc. Its internet gateways, subnets, route tables and
+// network acls are deleted first, since AWS won't delete a vpc that has them. Everything that
+// makes a vpc not empty is checked before anything is deleted, so a refused vpc is left as it was.
+func (v *VPC) Remediate(remediation policy.Remediation) error {
+	ctx := context.Background()
+	if remediation.Action != policy.ActionDelete {
+		return errors.Errorf("vpc %s does not support the %s action", v.ID, remediation.Action)
+	}
+	blockers, err := v.blockers(ctx)
+	if err != nil {
+		return err
+	}
+	if len(blockers) > 0 {
+		return errors.Errorf("vpc %s is not empty: %s", v.ID, strings.Join(blockers, "; "))
+	}
+
+	log.Warnf("Deleting vpc %s and its dependencies", v.ID)
+	for _, deleteDependencies := range []func(context.Context) error{
+		v.deleteInternetGateways,
+		v.deleteSubnets,
+		v.deleteRouteTables,
+		v.deleteNetworkACLs,
+	} {
+		err = deleteDependencies(ctx)
+		if err != nil {
+			return err
+		}
+	}
+	_, err = v.svc.DeleteVpcWithContext(ctx, &ec2.DeleteVpcInput{VpcId: &v.ID})
+	return errors.Wrapf(err, "could not delete vpc %s", v.ID)
+}
+
+// vpcPeeringBlockingStates are the states of peering connections which keep a vpc from being deleted
+var vpcPeeringBlockingStates = []string{
+	ec2.VpcPeeringConnectionStateReasonCodeInitiatingRequest,
+	ec2.VpcPeeringConnectionStateReasonCodePendingAcceptance,
+	ec2.VpcPeeringConnectionStateReasonCodeProvisioning,
+	ec2.VpcPeeringConnectionStateReasonCodeActive,
+}
+
+// blockers lists why the vpc can't be deleted: it is the default vpc, or it has network
+// interfaces, endpoints, vpn or egress only internet gateways, peering connections, security
+// groups besides the default one or a default security group other vpcs reference. Most are
+// looked up again since they might have been created since we evaluated the vpc.
+func (v *VPC) blockers(ctx context.Context) ([]string, error) {
+	var blockers []string
+	if v.isDefault {
+		blockers = append(blockers, "it is the default vpc")
+	}
+
+	eniCounts, err := countVPCENIs(ctx, v.svc, v.filter("vpc-id"))
+	if err != nil {
+		return nil, err
+	}
+	if eniCounts[v.ID] > 0 || v.eniCount > 0 {
+		blockers = append(blockers, "it has network interfaces")
+	}
+
+	var endpoints []string
+	err = v.svc.DescribeVpcEndpointsPagesWithContext(ctx, &ec2.DescribeVpcEndpointsInput{Filters: v.filter("vpc-id")}, func(output *ec2.DescribeVpcEndpointsOutput, lastPage bool) bool {
+		for _, endpoint := range output.VpcEndpoints {
+			if !strings.EqualFold(aws.StringValue(endpoint.State), ec2.StateDeleted) {
+				endpoints = append(endpoints, aws.StringValue(endpoint.VpcEndpointId))
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe the endpoints of vpc %s", v.ID)
+	}
+	if len(endpoints) > 0 {
+		blockers = append(blockers, fmt.Sprintf("it has endpoints %s", strings.Join(endpoints, ", ")))
+	}
+
+	vpnGateways, err := v.svc.DescribeVpnGatewaysWithContext(ctx, &ec2.DescribeVpnGatewaysInput{Filters: v.filter("attachment.vpc-id")})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe the vpn gateways of vpc %s", v.ID)
+	}
+	for _, vgw := range vpnGateways.VpnGateways {
+		for _, attachment := range vgw.VpcAttachments {
+			if aws.StringValue(attachment.VpcId) == v.ID && aws.StringValue(attachment.State) != ec2.AttachmentStatusDetached {
+				blockers = append(blockers, fmt.Sprintf("vpn gateway %s is attached", aws.StringValue(vgw.VpnGatewayId)))
+			}
+		}
+	}
+
+	// egress only internet gateways can only be filtered by tag
+	err = v.svc.DescribeEgressOnlyInternetGatewaysPagesWithContext(ctx, &ec2.DescribeEgressOnlyInternetGatewaysInput{}, func(output *ec2.DescribeEgressOnlyInternetGatewaysOutput, lastPage bool) bool {
+		for _, eigw := range output.EgressOnlyInternetGateways {
+			for _, attachment := range eigw.Attachments {
+				if aws.StringValue(attachment.VpcId) == v.ID && aws.StringValue(attachment.State) != ec2.AttachmentStatusDetached {
+					blockers = append(blockers, fmt.Sprintf("egress only internet gateway %s is attached", aws.StringValue(eigw.EgressOnlyInternetGatewayId)))
+				}
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe the egress only internet gateways of vpc %s", v.ID)
+	}
+
+	peerings := map[string]bool{}
+	for _, side := range []string{"requester-vpc-info.vpc-id", "accepter-vpc-info.vpc-id"} {
+		err = v.svc.DescribeVpcPeeringConnectionsPagesWithContext(ctx, &ec2.DescribeVpcPeeringConnectionsInput{Filters: v.filter(side)}, func(output *ec2.DescribeVpcPeeringConnectionsOutput, lastPage bool) bool {
+			for _, peering := range output.VpcPeeringConnections {
+				if peering.Status != nil && containsString(vpcPeeringBlockingStates, aws.StringValue(peering.Status.Code)) {
+					peerings[aws.StringValue(peering.VpcPeeringConnectionId)] = true
+				}
+			}
+			return true
+		})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not describe the peering connections of vpc %s", v.ID)
+		}
+	}
+	if len(peerings) > 0 || v.peeringConnectionCount > 0 {
+		blockers = append(blockers, "it has peering connections")
+	}
+
+	securityGroups, err := v.svc.DescribeSecurityGroupsWithContext(ctx, &ec2.DescribeSecurityGroupsInput{Filters: v.filter("vpc-id")})
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe the security groups of vpc %s", v.ID)
+	}
+	var defaultGroups []*string
+	for _, sg := range securityGroups.SecurityGroups {
+		if aws.StringValue(sg.GroupName) == "default" {
+			defaultGroups = append(defaultGroups, sg.GroupId)
+			continue
+		}
+		blockers = append(blockers, fmt.Sprintf("it has security group %s", aws.StringValue(sg.GroupId)))
+	}
+	if len(defaultGroups) > 0 {
+		references, err := v.svc.DescribeSecurityGroupReferencesWithContext(ctx, &ec2.DescribeSecurityGroupReferencesInput{GroupId: defaultGroups})
+		if err != nil {
+			return nil, errors.Wrapf(err, "could not describe references to the default security group of vpc %s", v.ID)
+		}
+		for _, reference := range references.SecurityGroupReferenceSet {
+			blockers = append(blockers, fmt.Sprintf("vpc %s references its default security group", aws.StringValue(reference.ReferencingVpcId)))
+		}
+	}
+	return blockers, nil
+}
+
+// filter returns a filter on name matching the vpc's id
+func (v *VPC) filter(name string) []*ec2.Filter {
+	return []*ec2.Filter{{Name: aws.String(name), Values: aws.StringSlice([]string{v.ID})}}
+}
+
+func (v *VPC) deleteInternetGateways(ctx context.Context) error {
+	input := &ec2.DescribeInternetGatewaysInput{Filters: v.filter("attachment.vpc-id")}
+	output, err := v.svc.DescribeInternetGatewaysWithContext(ctx, input)
+	if err != nil {
+		return errors.Wrapf(err, "could not describe the internet gateways of vpc %s", v.ID)
+	}
+	for _, igw := range output.InternetGateways {
+		log.Infof("Detaching and deleting internet gateway %s from vpc %s", aws.StringValue(igw.InternetGatewayId), v.ID)
+		_, err = v.svc.DetachInternetGatewayWithContext(ctx, &ec2.DetachInternetGatewayInput{InternetGatewayId: igw.InternetGatewayId, VpcId: &v.ID})
+		if err != nil {
+			return errors.Wrapf(err, "could not detach internet gateway %s from vpc %s", aws.StringValue(igw.InternetGatewayId), v.ID)
+		}
+		_, err = v.svc.DeleteInternetGatewayWithContext(ctx, &ec2.DeleteInternetGatewayInput{InternetGatewayId: igw.InternetGatewayId})
+		if err != nil {
+			return errors.Wrapf(err, "could not delete internet gateway %s", aws.StringValue(igw.InternetGatewayId))
+		}
+	}
+	return nil
+}
+
+func (v *VPC) deleteSubnets(ctx context.Context) error {
+	output, err := v.svc.DescribeSubnetsWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: v.filter("vpc-id")})
+	if err != nil {
+		return errors.Wrapf(err, "could not describe the subnets of vpc %s", v.ID)
+	}
+	for _, subnet := range output.Subnets {
+		log.Infof("Deleting subnet %s of vpc %s", aws.StringValue(subnet.SubnetId), v.ID)
+		_, err = v.svc.DeleteSubnetWithContext(ctx, &ec2.DeleteSubnetInput{SubnetId: subnet.SubnetId})
+		if err != nil {
+			return errors.Wrapf(err, "could not delete subnet %s", aws.StringValue(subnet.SubnetId))
+		}
+	}
+	return nil
+}
+
+// deleteRouteTables deletes all but the main route table, which is deleted with the vpc
+func (v *VPC) deleteRouteTables(ctx context.Context) error {
+	output, err := v.svc.DescribeRouteTablesWithContext(ctx, &ec2.DescribeRouteTablesInput{Filters: v.filter("vpc-id")})
+	if err != nil {
+		return errors.Wrapf(err, "could not describe the route tables of vpc %s", v.ID)
+	}
+	for _, routeTable := range output.RouteTables {
+		main := false
+		for _, association := range routeTable.Associations {
+			main = main || aws.BoolValue(association.Main)
+		}
+		if main {
+			continue
+		}
+		log.Infof("Deleting route table %s of vpc %s", aws.StringValue(routeTable.RouteTableId), v.ID)
+		_, err = v.svc.DeleteRouteTableWithContext(ctx, &ec2.DeleteRouteTableInput{RouteTableId: routeTable.RouteTableId})
+		if err != nil {
+			return errors.Wrapf(err, "could not delete route table %s", aws.StringValue(routeTable.RouteTableId))
+		}
+	}
+	return nil
+}
+
+// deleteNetworkACLs deletes all but the default network acl, which is deleted with the vpc
+func (v *VPC) deleteNetworkACLs(ctx context.Context) error {
+	output, err := v.svc.DescribeNetworkAclsWithContext(ctx, &ec2.DescribeNetworkAclsInput{Filters: v.filter("vpc-id")})
+	if err != nil {
+		return errors.Wrapf(err, "could not describe the network acls of vpc %s", v.ID)
+	}
+	for _, acl := range output.NetworkAcls {
+		if aws.BoolValue(acl.IsDefault) {
+			continue
+		}
+		log.Infof("Deleting network acl %s of vpc %s", aws.StringValue(acl.NetworkAclId), v.ID)
+		_, err = v.svc.DeleteNetworkAclWithContext(ctx, &ec2.DeleteNetworkAclInput{NetworkAclId: acl.NetworkAclId})
+		if err != nil {
+			return errors.Wrapf(err, "could not delete network acl %s", aws.StringValue(acl.NetworkAclId))
+		}
+	}
+	return nil
+}
+
+// countVPCENIs counts the network interfaces in each vpc
+func countVPCENIs(ctx context.Context, svc ec2iface.EC2API, filters []*ec2.Filter) (map[string]int64, error) {
+	counts := map[string]int64{}
+	input := &ec2.DescribeNetworkInterfacesInput{Filters: filters}
+	err := svc.DescribeNetworkInterfacesPagesWithContext(ctx, input, func(output *ec2.DescribeNetworkInterfacesOutput, lastPage bool) bool {
+		for _, eni := range output.NetworkInterfaces {
+			counts[aws.StringValue(eni.VpcId)]++
+		}
+		return true
+	})
+	return counts, errors.Wrap(err, "could not describe network interfaces")
+}
+
+// getVPCResources counts what is in or attached to each vpc in a region. filters narrow the lookup
+// down to a single vpc.
+func getVPCResources(ctx context.Context, svc ec2iface.EC2API, filters func(name string) []*ec2.Filter) (map[string]*VPCResources, error) {
+	resources := map[string]*VPCResources{}
+	get := func(vpcID string) *VPCResources {
+		if _, ok := resources[vpcID]; !ok {
+			resources[vpcID] = &VPCResources{}
+		}
+		return resources[vpcID]
+	}
+
+	err := svc.DescribeSubnetsPagesWithContext(ctx, &ec2.DescribeSubnetsInput{Filters: filters("vpc-id")}, func(output *ec2.DescribeSubnetsOutput, lastPage bool) bool {
+		for _, subnet := range output.Subnets {
+			get(aws.StringValue(subnet.VpcId)).SubnetCount++
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe subnets")
+	}
+
+	eniCounts, err := countVPCENIs(ctx, svc, filters("vpc-id"))
+	if err != nil {
+		return nil, err
+	}
+	for vpcID, count := range eniCounts {
+		get(vpcID).ENICount = count
+	}
+
+	err = svc.DescribeInternetGatewaysPagesWithContext(ctx, &ec2.DescribeInternetGatewaysInput{Filters: filters("attachment.vpc-id")}, func(output *ec2.DescribeInternetGatewaysOutput, lastPage bool) bool {
+		for _, igw := range output.InternetGateways {
+			for _, attachment := range igw.Attachments {
+				get(aws.StringValue(attachment.VpcId)).InternetGatewayAttached = true
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe internet gateways")
+	}
+
+	err = svc.DescribeFlowLogsPagesWithContext(ctx, &ec2.DescribeFlowLogsInput{Filter: filters("resource-id")}, func(output *ec2.DescribeFlowLogsOutput, lastPage bool) bool {
+		for _, flowLog := range output.FlowLogs {
+			if aws.StringValue(flowLog.FlowLogStatus) == "ACTIVE" {
+				get(aws.StringValue(flowLog.ResourceId)).FlowLogsEnabled = true
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe flow logs")
+	}
+
+	err = svc.DescribeVpcPeeringConnectionsPagesWithContext(ctx, &ec2.DescribeVpcPeeringConnectionsInput{}, func(output *ec2.DescribeVpcPeeringConnectionsOutput, lastPage bool) bool {
+		for _, peering := range output.VpcPeeringConnections {
+			if peering.Status == nil || aws.StringValue(peering.Status.Code) != ec2.VpcPeeringConnectionStateReasonCodeActive {
+				continue
+			}
+			if peering.AccepterVpcInfo != nil {
+				get(aws.StringValue(peering.AccepterVpcInfo.VpcId)).PeeringConnectionCount++
+			}
+			if peering.RequesterVpcInfo != nil {
+				get(aws.StringValue(peering.RequesterVpcInfo.VpcId)).PeeringConnectionCount++
+			}
+		}
+		return true
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "could not describe vpc peering connections")
+	}
+	return resources, nil
+}
+
+// vpcResourcesOf returns the resources counted for a vpc
+func vpcResourcesOf(resources map[string]*VPCResources, vpcID string) VPCResources {
+	if r, ok := resources[vpcID]; ok {
+		return *r
+	}
+	return VPCResources{}
+}
+
 // EvalVPC will evaluate policy for a vpc
 func (c *Client) EvalVPC(accounts []*policy.Account, p policy.Policy, regions []string, f func(policy.Violation)) error {
-	var errs error
+	var errs *multierror.Error
 	ctx := context.Background()
 
-	err := c.WalkAccountsAndRegions(accounts, regions, func(client *cziAws.Client, account *policy.Account, region string) {
-		err := client.EC2.GetAllVPCs(ctx, func(vpc *ec2.Vpc) {
-			v := NewVpc(vpc, region)
-			if p.Match(v) {
-				violation := policy.NewViolation(p, v, false, account)
-				f(violation)
+	err := c.WalkAccountsAndRegions(accounts, regions, func(client *AccountClient, account *policy.Account, region string) {
+		resources, err := getVPCResources(ctx, client.EC2, func(string) []*ec2.Filter { return nil })
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not evaluate vpcs in %s %s", account.Name, region))
+			return
+		}
+		err = client.EC2.DescribeVpcsPagesWithContext(ctx, &ec2.DescribeVpcsInput{}, func(output *ec2.DescribeVpcsOutput, lastPage bool) bool {
+			for _, vpc := range output.Vpcs {
+				v := NewVpc(vpc, vpcResourcesOf(resources, aws.StringValue(vpc.VpcId)), region, client.EC2)
+				if p.Match(v) {
+					violation := policy.NewViolation(p, v, p.Expired(v), account)
+					f(violation)
+				}
 			}
-
+			return true
 		})
-		errs = multierror.Append(errs, err)
+		if err != nil {
+			errs = multierror.Append(errs, errors.Wrapf(err, "could not describe vpcs in %s %s", account.Name, region))
+		}
 	})
 	errs = multierror.Append(errs, err)
-	return nil
+	return errs.ErrorOrNil()
+}
+
+func (c *Client) getVPC(ctx context.Context, account *policy.Account, region string, id string) (policy.Subject, error) {
+	client := c.Get(account.ID, account.Role, account.ExternalID, region)
+	input := &ec2.DescribeVpcsInput{VpcIds: []*string{aws.String(id)}}
+	output, err := client.EC2.DescribeVpcsWithContext(ctx, input)
+	if err != nil {
+		return nil, errors.Wrapf(err, "could not describe vpc %s", id)
+	}
+	resources, err := getVPCResources(ctx, client.EC2, func(name string) []*ec2.Filter {
+		return []*ec2.Filter{{Name: aws.String(name), Values: aws.StringSlice([]string{id})}}
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, vpc := range output.Vpcs {
+		return NewVpc(vpc, vpcResourcesOf(resources, id), region, client.EC2), nil
+	}
+	return nil, errors.Errorf("vpc %s not found in %s", id, region)
 }
@@ -0,0 +1,144 @@
+package aws_test
+
+import (
+	"testing"
+
+	"github.com/aws/aws-sdk-go/aws"
+	"github.com/aws/aws-sdk-go/service/ec2"
+	reaperAws "github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewVpc(t *testing.T) {
+	a := assert.New(t)
+
+	vpc := reaperAws.NewVpc(&ec2.Vpc{
+		VpcId:     aws.String("vpc-1"),
+		CidrBlock: aws.String("10.0.0.0/16"),
+		CidrBlockAssociationSet: []*ec2.VpcCidrBlockAssociation{
+			{CidrBlock: aws.String("10.0.0.0/16"), CidrBlockState: &ec2.VpcCidrBlockState{State: aws.String(ec2.VpcCidrBlockStateCodeAssociated)}},
+			{CidrBlock: aws.String("10.1.0.0/16"), CidrBlockState: &ec2.VpcCidrBlockState{State: aws.String(ec2.VpcCidrBlockStateCodeDisassociated)}},
+		},
+		Ipv6CidrBlockAssociationSet: []*ec2.VpcIpv6CidrBlockAssociation{
+			{Ipv6CidrBlock: aws.String("2600:1f18::/56"), Ipv6CidrBlockState: &ec2.VpcCidrBlockState{State: aws.String(ec2.VpcCidrBlockStateCodeAssociated)}},
+		},
+	}, reaperAws.VPCResources{SubnetCount: 3, InternetGatewayAttached: true, PeeringConnectionCount: 1}, "us-west-2", nil)
+	labels := vpc.GetLabels()
+	a.Equal("3", labels["subnet_count"])
+	a.Equal("0", labels["eni_count"])
+	a.Equal("true", labels["internet_gateway_attached"])
+	a.Equal("1", labels["peering_connection_count"])
+	a.Equal("10.0.0.0/16,2600:1f18::/56", labels["cidr_blocks"])
+	a.Equal("2", labels["cidr_block_count"])
+	a.NotContains(labels, "flow_logs_enabled")
+	a.NotContains(labels, "is_default")
+}
+
+func TestVPCRemediate(t *testing.T) {
+	a := assert.New(t)
+	vpc1 := aws.String("vpc-1")
+
+	// emptyVPC returns a fake serving the dependencies of an empty vpc, which reaper deletes
+	emptyVPC := func() *fakeEC2 {
+		return &fakeEC2{
+			internetGateways: []*ec2.InternetGateway{{InternetGatewayId: aws.String("igw-1")}},
+			subnets:          []*ec2.Subnet{{SubnetId: aws.String("subnet-1")}},
+			routeTables: []*ec2.RouteTable{
+				{RouteTableId: aws.String("rtb-main"), Associations: []*ec2.RouteTableAssociation{{Main: aws.Bool(true)}}},
+				{RouteTableId: aws.String("rtb-1")},
+			},
+			networkACLs:    []*ec2.NetworkAcl{{NetworkAclId: aws.String("acl-default"), IsDefault: aws.Bool(true)}},
+			securityGroups: []*ec2.SecurityGroup{{GroupId: aws.String("sg-default"), GroupName: aws.String("default")}},
+			// detached from this vpc, or attached to another
+			vpnGateways: []*ec2.VpnGateway{{VpnGatewayId: aws.String("vgw-1"), VpcAttachments: []*ec2.VpcAttachment{{VpcId: vpc1, State: aws.String(ec2.AttachmentStatusDetached)}}}},
+			egressOnlyInternetGateways: []*ec2.EgressOnlyInternetGateway{
+				{EgressOnlyInternetGatewayId: aws.String("eigw-1"), Attachments: []*ec2.InternetGatewayAttachment{{VpcId: aws.String("vpc-2"), State: aws.String(ec2.AttachmentStatusAttached)}}},
+			},
+			peeringConnections: []*ec2.VpcPeeringConnection{{VpcPeeringConnectionId: aws.String("pcx-1"), Status: &ec2.VpcPeeringConnectionStateReason{Code: aws.String(ec2.VpcPeeringConnectionStateReasonCodeDeleted)}}},
+			vpcEndpoints:       []*ec2.VpcEndpoint{{VpcEndpointId: aws.String("vpce-1"), State: aws.String("deleted")}},
+		}
+	}
+
+	tests := []struct {
+		name      string
+		vpc       *ec2.Vpc
+		resources reaperAws.VPCResources
+		block     func(*fakeEC2)
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name:  "default vpc",
+			vpc:   &ec2.Vpc{VpcId: vpc1, IsDefault: aws.Bool(true)},
+			block: func(*fakeEC2) {},
+		},
+		{
+			name: "network interface",
+			block: func(f *fakeEC2) {
+				f.enis = []*ec2.NetworkInterface{{VpcId: vpc1}}
+			},
+		},
+		{
+			name: "endpoint",
+			block: func(f *fakeEC2) {
+				f.vpcEndpoints = []*ec2.VpcEndpoint{{VpcEndpointId: aws.String("vpce-2"), State: aws.String("available")}}
+			},
+		},
+		{
+			name: "vpn gateway",
+			block: func(f *fakeEC2) {
+				f.vpnGateways[0].VpcAttachments[0].State = aws.String(ec2.AttachmentStatusAttached)
+			},
+		},
+		{
+			name: "egress only internet gateway",
+			block: func(f *fakeEC2) {
+				f.egressOnlyInternetGateways[0].Attachments[0].VpcId = vpc1
+			},
+		},
+		{
+			name: "pending peering connection",
+			block: func(f *fakeEC2) {
+				f.peeringConnections[0].Status.Code = aws.String(ec2.VpcPeeringConnectionStateReasonCodePendingAcceptance)
+			},
+		},
+		{
+			name:      "active peering connection when evaluated",
+			resources: reaperAws.VPCResources{PeeringConnectionCount: 1},
+			block:     func(*fakeEC2) {},
+		},
+		{
+			name: "security group",
+			block: func(f *fakeEC2) {
+				f.securityGroups = append(f.securityGroups, &ec2.SecurityGroup{GroupId: aws.String("sg-1"), GroupName: aws.String("web")})
+			},
+		},
+		{
+			name: "default security group referenced from a peered vpc",
+			block: func(f *fakeEC2) {
+				f.securityGroupReferences = []*ec2.SecurityGroupReference{{GroupId: aws.String("sg-default"), ReferencingVpcId: aws.String("vpc-2")}}
+			},
+		},
+	}
+
+	for _, test := range tests {
+		svc := emptyVPC()
+		if test.block != nil {
+			test.block(svc)
+		}
+		vpc := test.vpc
+		if vpc == nil {
+			vpc = &ec2.Vpc{VpcId: vpc1}
+		}
+		err := reaperAws.NewVpc(vpc, test.resources, "us-west-2", svc).Remediate(policy.Remediation{Action: policy.ActionDelete})
+		if test.block == nil {
+			a.NoError(err, test.name)
+			a.Equal([]string{"detach igw-1", "delete igw-1", "delete subnet-1", "delete rtb-1", "delete vpc-1"}, svc.calls, test.name)
+			continue
+		}
+		a.Error(err, test.name)
+		a.Empty(svc.calls, test.name)
+	}
+}
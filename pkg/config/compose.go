@@ -0,0 +1,272 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/pkg/errors"
+	"github.com/spf13/afero"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// envPattern matches ${VAR} and the escaped form $${VAR}
+var envPattern = regexp.MustCompile(`\$?\$\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// loader reads config files, following includes, and merges them into a single config
+type loader struct {
+	fs     afero.Fs
+	config *Config
+	// loaded tracks files we have already read so includes can't loop or load a file twice
+	loaded               map[string]bool
+	files                []string
+	versionSource        string
+	filterSource         string
+	trustedAdvisorSource string
+	policySources        map[string]string
+	accountSources       map[int64]string
+}
+
+func newLoader(fs afero.Fs) *loader {
+	return &loader{
+		fs:             fs,
+		config:         &Config{},
+		loaded:         map[string]bool{},
+		policySources:  map[string]string{},
+		accountSources: map[int64]string{},
+	}
+}
+
+// Files returns every file FromFile reads for path, following includes
+func Files(fs afero.Fs, path string) ([]string, error) {
+	l := newLoader(fs)
+	err := l.loadPath(path)
+	return l.files, err
+}
+
+// resolve expands a path to the config files it refers to. Paths can be files, directories,
+// in which case we read every .yml and .yaml file in them, or globs.
+func (l *loader) resolve(path string) ([]string, error) {
+	if strings.ContainsAny(path, "*?[") {
+		matches, err := afero.Glob(l.fs, path)
+		if err != nil {
+			return nil, errors.Wrapf(err, "Invalid glob %s", path)
+		}
+		if len(matches) == 0 {
+			return nil, errors.Errorf("No config files match %s", path)
+		}
+		return matches, nil
+	}
+
+	isDir, err := afero.IsDir(l.fs, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not open file %s", path)
+	}
+	if !isDir {
+		return []string{path}, nil
+	}
+
+	infos, err := afero.ReadDir(l.fs, path)
+	if err != nil {
+		return nil, errors.Wrapf(err, "Could not read directory %s", path)
+	}
+	files := []string{}
+	for _, info := range infos {
+		ext := filepath.Ext(info.Name())
+		if !info.IsDir() && (ext == ".yml" || ext == ".yaml") {
+			files = append(files, filepath.Join(path, info.Name()))
+		}
+	}
+	if len(files) == 0 {
+		return nil, errors.Errorf("No config files in directory %s", path)
+	}
+	return files, nil
+}
+
+func (l *loader) loadPath(path string) error {
+	files, err := l.resolve(path)
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		err = l.loadFile(f)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (l *loader) loadFile(fileName string) error {
+	fileName = filepath.Clean(fileName)
+	if l.loaded[fileName] {
+		return nil
+	}
+	l.loaded[fileName] = true
+	l.files = append(l.files, fileName)
+
+	f, err := l.fs.Open(fileName)
+	if err != nil {
+		return errors.Wrapf(err, "Could not open file %s", fileName)
+	}
+	defer f.Close()
+	bytes, err := ioutil.ReadAll(f)
+	if err != nil {
+		return errors.Wrapf(err, "Could not read config file %s contents", fileName)
+	}
+
+	var raw interface{}
+	err = yaml.Unmarshal(bytes, &raw)
+	if err != nil {
+		return errors.Wrapf(err, "Could not Unmarshal config %s", fileName)
+	}
+	raw, err = interpolate("config", raw)
+	if err != nil {
+		return errors.Wrapf(err, "Could not interpolate config %s", fileName)
+	}
+	err = Schema().Validate(raw)
+	if err != nil {
+		return errors.Wrapf(err, "Invalid config %s", fileName)
+	}
+
+	// round trip through yaml so the interpolated values end up in the config
+	bytes, err = yaml.Marshal(raw)
+	if err != nil {
+		return errors.Wrapf(err, "Could not Marshal config %s", fileName)
+	}
+	c := &Config{}
+	err = yaml.Unmarshal(bytes, c)
+	if err != nil {
+		return errors.Wrapf(err, "Could not Unmarshal config %s", fileName)
+	}
+
+	err = l.merge(fileName, c)
+	if err != nil {
+		return err
+	}
+
+	for _, include := range c.Include {
+		if !filepath.IsAbs(include) {
+			include = filepath.Join(filepath.Dir(fileName), include)
+		}
+		err = l.loadPath(include)
+		if err != nil {
+			return errors.Wrapf(err, "Could not include %s from %s", include, fileName)
+		}
+	}
+	return nil
+}
+
+// merge adds c, read from fileName, into the loader's config
+func (l *loader) merge(fileName string, c *Config) error {
+	if c.Version != 0 {
+		if l.config.Version != 0 && l.config.Version != c.Version {
+			return errors.Errorf("%s has version %d but %s has version %d", fileName, c.Version, l.versionSource, l.config.Version)
+		}
+		l.config.Version = c.Version
+		l.versionSource = fileName
+	}
+
+	for _, p := range c.Policies {
+		if source, ok := l.policySources[p.Name]; ok {
+			return duplicateError("policy "+p.Name, source, fileName)
+		}
+		l.policySources[p.Name] = fileName
+		p.source = fileName
+		l.config.Policies = append(l.config.Policies, p)
+	}
+
+	for _, a := range c.Accounts {
+		if source, ok := l.accountSources[a.ID]; ok {
+			return duplicateError(fmt.Sprintf("account %d", a.ID), source, fileName)
+		}
+		l.accountSources[a.ID] = fileName
+		a.source = fileName
+		l.config.Accounts = append(l.config.Accounts, a)
+	}
+
+	for _, region := range c.AWSRegions {
+		if !containsString(l.config.AWSRegions, region) {
+			l.config.AWSRegions = append(l.config.AWSRegions, region)
+		}
+	}
+
+	if c.RegionFilter != nil {
+		if l.config.RegionFilter != nil {
+			return duplicateError("region_filter", l.filterSource, fileName)
+		}
+		l.config.RegionFilter = c.RegionFilter
+		l.filterSource = fileName
+	}
+
+	if c.TrustedAdvisor != nil {
+		if l.config.TrustedAdvisor != nil {
+			return duplicateError("trusted_advisor", l.trustedAdvisorSource, fileName)
+		}
+		l.config.TrustedAdvisor = c.TrustedAdvisor
+		l.trustedAdvisorSource = fileName
+	}
+
+	l.config.IdentityMap = append(l.config.IdentityMap, c.IdentityMap...)
+	return nil
+}
+
+func duplicateError(what string, first string, second string) error {
+	if first == second {
+		return errors.Errorf("%s is defined twice in %s", what, first)
+	}
+	return errors.Errorf("%s is defined in both %s and %s", what, first, second)
+}
+
+// interpolate replaces ${VAR} in every string value with the VAR environment variable.
+// $${VAR} is left as the literal ${VAR}.
+func interpolate(path string, v interface{}) (interface{}, error) {
+	switch value := v.(type) {
+	case string:
+		var missing []string
+		res := envPattern.ReplaceAllStringFunc(value, func(match string) string {
+			if strings.HasPrefix(match, "$$") {
+				return match[1:]
+			}
+			name := envPattern.FindStringSubmatch(match)[1]
+			env, ok := os.LookupEnv(name)
+			if !ok {
+				missing = append(missing, name)
+			}
+			return env
+		})
+		if len(missing) > 0 {
+			return nil, errors.Errorf("%s: environment variables %s are not set", path, strings.Join(missing, ", "))
+		}
+		return res, nil
+	case []interface{}:
+		for i, item := range value {
+			interpolated, err := interpolate(fmt.Sprintf("%s[%d]", path, i), item)
+			if err != nil {
+				return nil, err
+			}
+			value[i] = interpolated
+		}
+	case map[interface{}]interface{}:
+		for k, item := range value {
+			interpolated, err := interpolate(fmt.Sprintf("%s.%v", path, k), item)
+			if err != nil {
+				return nil, err
+			}
+			value[k] = interpolated
+		}
+	}
+	return v, nil
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
@@ -1,16 +1,19 @@
 package config
 
 import (
-	"io/ioutil"
+	"strings"
 	"time"
 
+	"github.com/chanzuckerberg/reaper/pkg/aws"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
 	"github.com/pkg/errors"
 	"github.com/spf13/afero"
-	yaml "gopkg.in/yaml.v2"
 	"k8s.io/apimachinery/pkg/labels"
 )
 
+// ValidVersions lists the config versions this version of reaper understands
+var ValidVersions = []int{1, 2}
+
 // TypeResource describes the type of resource
 type TypeResource string
 
@@ -41,51 +44,123 @@ func (d *Duration) Duration() *time.Duration {
 	return &duration
 }
 
+// Regions is a list of regions to scan, or "all" to scan every region enabled in an account
+type Regions []string
+
+// UnmarshalYAML accepts either a list of regions or "all"
+func (r *Regions) UnmarshalYAML(unmarshal func(v interface{}) error) error {
+	var s string
+	err := unmarshal(&s)
+	if err == nil {
+		if s != policy.AllRegions {
+			return errors.Errorf("regions must be a list or %s, not %s", policy.AllRegions, s)
+		}
+		*r = Regions{policy.AllRegions}
+		return nil
+	}
+
+	var regions []string
+	err = unmarshal(&regions)
+	if err != nil {
+		return errors.Wrap(err, "yaml: Unmarshal error")
+	}
+	*r = regions
+	return nil
+}
+
+// RegionFilterConfig filters the regions we scan, which is most useful along with discovery
+type RegionFilterConfig struct {
+	Allow []string `yaml:"allow" description:"only scan regions matching one of these globs, e.g. us-*"`
+	Deny  []string `yaml:"deny" description:"never scan regions matching one of these globs"`
+}
+
 // NotificationConfig is a notification config
 type NotificationConfig struct {
-	Recipient       string `yaml:"recipient"`
-	MessageTemplate string `yaml:"message_template"`
+	Recipient       string `yaml:"recipient" required:"true" description:"email address or slack channel to notify, or $owner for the resource owner"`
+	MessageTemplate string `yaml:"message_template" required:"true" description:"go template for the message"`
 }
 
 // PolicyConfig is the configuration for a policy
 type PolicyConfig struct {
-	Name             string  `yaml:"name"`
-	ResourceSelector string  `yaml:"resource_selector"`
-	TagSelector      *string `yaml:"tag_selector"`
-	LabelSelector    *string `yaml:"label_selector"`
+	Name             string  `yaml:"name" required:"true" description:"name of the policy"`
+	ResourceSelector string  `yaml:"resource_selector" required:"true" description:"selects the resource types this policy applies to, e.g. name in (ec2_instance)"`
+	TagSelector      *string `yaml:"tag_selector" description:"selects resources based on their tags"`
+	LabelSelector    *string `yaml:"label_selector" description:"selects resources based on labels reaper computes for each resource type"`
 	// MaxAge for this resource
 	// If it matches the policy and exceeds MaxAge remediation will be taken.
-	MaxAge *Duration `yaml:"max_age"`
+	MaxAge *Duration `yaml:"max_age" description:"resources older than this are expired"`
+	// Lookback for labels computed from usage metrics, such as bytes processed by a nat gateway
+	Lookback *Duration `yaml:"lookback" description:"how far back labels computed from CloudWatch metrics look, defaults to 720h"`
+
+	Notifications NotificationsConfig `yaml:"notifications" description:"notifications to send for resources that match the policy"`
+	Remediation   *RemediationConfig  `yaml:"remediation" description:"action to take on expired resources, when running non-interactively or after confirmation"`
 
-	Notifications NotificationsConfig `yaml:"notifications"`
+	// source is the file this policy was read from
+	source string
 }
 
 type NotificationsConfig struct {
-	Warnings []NotificationConfig `yaml:"warnings"`
+	Warnings []NotificationConfig `yaml:"warnings" description:"sent for every resource that matches the policy"`
+}
+
+// RemediationConfig configures the action taken on expired resources
+type RemediationConfig struct {
+	Action               string    `yaml:"action" required:"true" description:"remediation action, which every resource type selected by the policy must support"`
+	IgnoreAge            bool      `yaml:"ignore_age" description:"act on every resource the policy matches rather than only expired ones, for resource types AWS reports no creation time for. Requires a policy without max_age"`
+	RetentionDays        *int64    `yaml:"retention_days" description:"retention period in days, required by the set_retention action"`
+	GracePeriod          *Duration `yaml:"grace_period" description:"for iam_access_key, how long to wait between deactivating and deleting a key. Defaults to 336h"`
+	PendingWindowDays    *int64    `yaml:"pending_window_days" description:"for kms_key, the days AWS keeps a key for after scheduling its deletion, between 7 and 30. Defaults to 30"`
+	MaxBucketSizeBytes   *int64    `yaml:"max_bucket_size_bytes" description:"s3 buckets larger than this are not deleted. Defaults to 1 GiB"`
+	AllowLargeBuckets    bool      `yaml:"allow_large_buckets" description:"delete s3 buckets regardless of their size"`
+	SnapshotBeforeDelete bool      `yaml:"snapshot_before_delete" description:"take a snapshot of ebs_volume volumes, and wait for it to complete, before deleting them"`
 }
 
 //AccountConfig identifies an AWS account we want to monitor
 type AccountConfig struct {
-	Name       string `yaml:"name"`
-	ID         int64  `yaml:"id"`
-	Role       string `yaml:"role"`
-	Owner      string `yaml:"owner"`
-	ExternalID string `yaml:"external_id"`
+	Name       string `yaml:"name" required:"true" description:"name of the account, used in output"`
+	ID         int64  `yaml:"id" required:"true" description:"AWS account id"`
+	Role       string `yaml:"role" required:"true" description:"name of the role to assume in the account"`
+	Owner      string `yaml:"owner" description:"default owner for resources in this account"`
+	ExternalID string `yaml:"external_id" description:"external id to pass when assuming the role"`
+
+	// Regions, RegionFilter, Policies and OwnerTags require version 2
+	Regions      Regions                `yaml:"regions" description:"regions to scan in this account, overrides aws_regions. Requires version 2"`
+	RegionFilter *RegionFilterConfig    `yaml:"region_filter" description:"filters the regions scanned in this account, overrides the global region_filter. Requires version 2"`
+	Policies     *AccountPoliciesConfig `yaml:"policies" description:"limits the policies evaluated in this account. Requires version 2"`
+	OwnerTags    []string               `yaml:"owner_tags" description:"tags checked in order for a resource's owner when it has no owner tag, before falling back to owner. Requires version 2"`
+
+	// source is the file this account was read from
+	source string
+}
+
+// AccountPoliciesConfig limits the policies evaluated in an account
+type AccountPoliciesConfig struct {
+	Include []string `yaml:"include" description:"only evaluate these policies in this account"`
+	Exclude []string `yaml:"exclude" description:"never evaluate these policies in this account"`
 }
 
 //IdentityMapConfig will allow mapping group email lists to slack channels
 type IdentityMapConfig struct {
-	Email string `yaml:"email"`
-	Slack string `yaml:"slack"`
+	Email string `yaml:"email" required:"true" description:"email address, usually a list"`
+	Slack string `yaml:"slack" required:"true" description:"slack channel to notify instead"`
+}
+
+// TrustedAdvisorConfig configures how reaper reads Trusted Advisor checks
+type TrustedAdvisorConfig struct {
+	Refresh        bool      `yaml:"refresh" description:"refresh the Trusted Advisor checks of every account, and wait for them to finish, before evaluating policies selecting trusted_advisor_finding"`
+	RefreshTimeout *Duration `yaml:"refresh_timeout" description:"how long to wait for the checks of every account to refresh, defaults to 10m"`
 }
 
 // Config is the configuration
 type Config struct {
-	Version     int                 `yaml:version`
-	Policies    []PolicyConfig      `yaml:"policies"`
-	AWSRegions  []string            `yaml:"aws_regions"`
-	Accounts    []AccountConfig     `yaml:"accounts"`
-	IdentityMap []IdentityMapConfig `yaml:"identity_map"`
+	Version        int                   `yaml:"version" description:"version of the config format, required in at least one file"`
+	Include        []string              `yaml:"include" description:"files, directories or globs to merge into this config, relative to this file"`
+	Policies       []PolicyConfig        `yaml:"policies" description:"policies to enforce"`
+	AWSRegions     Regions               `yaml:"aws_regions" description:"regions to scan. When omitted or all we scan every region enabled in each account"`
+	RegionFilter   *RegionFilterConfig   `yaml:"region_filter" description:"filters the regions scanned"`
+	Accounts       []AccountConfig       `yaml:"accounts" description:"AWS accounts to scan"`
+	IdentityMap    []IdentityMapConfig   `yaml:"identity_map" description:"maps email addresses to slack channels, for cases we can't look up"`
+	TrustedAdvisor *TrustedAdvisorConfig `yaml:"trusted_advisor" description:"configures how Trusted Advisor checks are read"`
 }
 
 // GetPolicies gets the policies from a config
@@ -94,14 +169,14 @@ func (c *Config) GetPolicies() ([]policy.Policy, error) {
 	for i, cp := range c.Policies {
 		rs, err := labels.Parse(cp.ResourceSelector)
 		if err != nil {
-			return nil, errors.Wrapf(err, "Invalid selector: %s", cp.ResourceSelector)
+			return nil, errors.Wrapf(err, "Invalid selector in policy %s (%s): %s", cp.Name, cp.source, cp.ResourceSelector)
 		}
 
 		var ls labels.Selector
 		if cp.LabelSelector != nil {
 			ls, err = labels.Parse(*cp.LabelSelector)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Invalid selector: %s", *cp.LabelSelector)
+				return nil, errors.Wrapf(err, "Invalid selector in policy %s (%s): %s", cp.Name, cp.source, *cp.LabelSelector)
 			}
 		}
 
@@ -109,7 +184,7 @@ func (c *Config) GetPolicies() ([]policy.Policy, error) {
 		if cp.TagSelector != nil {
 			ts, err = labels.Parse(*cp.TagSelector)
 			if err != nil {
-				return nil, errors.Wrapf(err, "Invalid selector: %s", *cp.TagSelector)
+				return nil, errors.Wrapf(err, "Invalid selector in policy %s (%s): %s", cp.Name, cp.source, *cp.TagSelector)
 			}
 		}
 
@@ -127,18 +202,165 @@ func (c *Config) GetPolicies() ([]policy.Policy, error) {
 			LabelSelector:    ls,
 			TagSelector:      ts,
 			MaxAge:           cp.MaxAge.Duration(),
+			Lookback:         cp.Lookback.Duration(),
 			Notifications:    notifications,
 		}
+		if cp.Remediation != nil {
+			err = validateRemediation(rs, cp.MaxAge, cp.Remediation)
+			if err != nil {
+				return nil, errors.Wrapf(err, "Invalid remediation in policy %s (%s)", cp.Name, cp.source)
+			}
+			p.Remediation = &policy.Remediation{
+				Action:    cp.Remediation.Action,
+				Policy:    cp.Name,
+				IgnoreAge: cp.Remediation.IgnoreAge,
+				Delete: policy.DeleteOptions{
+					GracePeriod:       cp.Remediation.GracePeriod.Duration(),
+					PendingWindowDays: cp.Remediation.PendingWindowDays,
+					MaxBucketSize:     cp.Remediation.MaxBucketSizeBytes,
+					AllowLargeBuckets: cp.Remediation.AllowLargeBuckets,
+					SnapshotFirst:     cp.Remediation.SnapshotBeforeDelete,
+				},
+			}
+			if cp.Remediation.RetentionDays != nil {
+				p.Remediation.SetRetention.Days = *cp.Remediation.RetentionDays
+			}
+		}
 		policies[i] = p
 	}
 	return policies, nil
 }
 
+// remediationOption is a remediation setting only one action of some resource types reads
+type remediationOption struct {
+	name          string
+	action        string
+	resourceTypes []string
+	isSet         func(r *RemediationConfig) bool
+}
+
+// remediationOptions lists which action and resource types read each remediation setting
+var remediationOptions = []remediationOption{
+	{
+		name:          "retention_days",
+		action:        policy.ActionSetRetention,
+		resourceTypes: []string{"log_group"},
+		isSet:         func(r *RemediationConfig) bool { return r.RetentionDays != nil },
+	},
+	{
+		name:          "grace_period",
+		action:        policy.ActionDelete,
+		resourceTypes: []string{"iam_access_key"},
+		isSet:         func(r *RemediationConfig) bool { return r.GracePeriod != nil },
+	},
+	{
+		name:          "pending_window_days",
+		action:        policy.ActionDelete,
+		resourceTypes: []string{"kms_key"},
+		isSet:         func(r *RemediationConfig) bool { return r.PendingWindowDays != nil },
+	},
+	{
+		name:          "max_bucket_size_bytes",
+		action:        policy.ActionDelete,
+		resourceTypes: []string{"s3"},
+		isSet:         func(r *RemediationConfig) bool { return r.MaxBucketSizeBytes != nil },
+	},
+	{
+		name:          "allow_large_buckets",
+		action:        policy.ActionDelete,
+		resourceTypes: []string{"s3"},
+		isSet:         func(r *RemediationConfig) bool { return r.AllowLargeBuckets },
+	},
+	{
+		name:          "snapshot_before_delete",
+		action:        policy.ActionDelete,
+		resourceTypes: []string{"ebs_volume"},
+		isSet:         func(r *RemediationConfig) bool { return r.SnapshotBeforeDelete },
+	},
+}
+
+// validateRemediation checks that every resource type the selector matches supports the action,
+// that the action has the settings it needs, and that every setting applies to the action and
+// every resource type
+func validateRemediation(rs labels.Selector, maxAge *Duration, r *RemediationConfig) error {
+	action := r.Action
+	if r.IgnoreAge && maxAge != nil {
+		return errors.New("ignore_age can't be combined with max_age")
+	}
+	// violations are only remediated once they expire, which takes a max_age
+	if !r.IgnoreAge && maxAge == nil {
+		return errors.New("the policy has no max_age, so nothing it matches ever expires. Set max_age, or ignore_age to remediate everything it matches")
+	}
+	if (action == policy.ActionSetRetention) != (r.RetentionDays != nil) {
+		return errors.Errorf("retention_days is required by and only valid for the %s action", policy.ActionSetRetention)
+	}
+	if r.PendingWindowDays != nil && (*r.PendingWindowDays < aws.KMSMinPendingWindowDays || *r.PendingWindowDays > aws.KMSMaxPendingWindowDays) {
+		return errors.Errorf("pending_window_days must be between %d and %d", aws.KMSMinPendingWindowDays, aws.KMSMaxPendingWindowDays)
+	}
+	if r.MaxBucketSizeBytes != nil && r.AllowLargeBuckets {
+		return errors.New("max_bucket_size_bytes has no effect with allow_large_buckets")
+	}
+	var resourceTypes []string
+	for _, rt := range aws.ResourceTypes {
+		if !rs.Matches(labels.Set{"name": rt.Name}) {
+			continue
+		}
+		if !rt.SupportsAction(action) {
+			return errors.Errorf("%s does not support the %s action", rt.Name, action)
+		}
+		if r.IgnoreAge && !rt.NoCreationTime {
+			return errors.Errorf("%s has a creation time, use max_age rather than ignore_age", rt.Name)
+		}
+		if maxAge != nil && rt.NoCreationTime {
+			return errors.Errorf("%s has no creation time and never expires, use ignore_age rather than max_age", rt.Name)
+		}
+		resourceTypes = append(resourceTypes, rt.Name)
+	}
+	if len(resourceTypes) == 0 {
+		return errors.New("the resource_selector does not select any resource types")
+	}
+	for _, option := range remediationOptions {
+		if !option.isSet(r) {
+			continue
+		}
+		if action != option.action {
+			return errors.Errorf("%s is only valid for the %s action", option.name, option.action)
+		}
+		for _, rt := range resourceTypes {
+			if !containsString(option.resourceTypes, rt) {
+				return errors.Errorf("%s does not apply to %s, only to %s", option.name, rt, strings.Join(option.resourceTypes, ", "))
+			}
+		}
+	}
+	return nil
+}
+
 //GetAccounts will return policy.Account objects
 func (c *Config) GetAccounts() ([]*policy.Account, error) {
 	var accounts []*policy.Account
 	for _, a := range c.Accounts {
-		accounts = append(accounts, &policy.Account{Name: a.Name, ID: a.ID, Role: a.Role, Owner: a.Owner, ExternalID: a.ExternalID})
+		account := &policy.Account{
+			Name:       a.Name,
+			ID:         a.ID,
+			Role:       a.Role,
+			Owner:      a.Owner,
+			ExternalID: a.ExternalID,
+			Regions:    a.Regions,
+			OwnerTags:  a.OwnerTags,
+		}
+		if a.Policies != nil {
+			account.IncludePolicies = a.Policies.Include
+			account.ExcludePolicies = a.Policies.Exclude
+		}
+		regionFilter := c.RegionFilter
+		if a.RegionFilter != nil {
+			regionFilter = a.RegionFilter
+		}
+		if regionFilter != nil {
+			account.AllowRegions = regionFilter.Allow
+			account.DenyRegions = regionFilter.Deny
+		}
+		accounts = append(accounts, account)
 	}
 	return accounts, nil
 }
@@ -152,17 +374,17 @@ func (c *Config) GetIdentityMap() (map[string]string, error) {
 	return m, nil
 }
 
-// FromFile reads a config from a file
+// FromFile reads a config from a file, every .yml and .yaml file in a directory, or every file
+// matching a glob. Files are merged together along with any files they include.
 func FromFile(fs afero.Fs, fileName string) (*Config, error) {
-	f, err := fs.Open(fileName)
+	l := newLoader(fs)
+	err := l.loadPath(fileName)
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not open file %s", fileName)
+		return nil, err
 	}
-	bytes, err := ioutil.ReadAll(f)
+	err = l.config.validateVersion()
 	if err != nil {
-		return nil, errors.Wrapf(err, "Could not read config file %s contents", fileName)
+		return nil, err
 	}
-	config := &Config{}
-	err = yaml.Unmarshal(bytes, config)
-	return config, errors.Wrapf(err, "Could not Unmarshal config %s", fileName)
+	return l.config, nil
 }
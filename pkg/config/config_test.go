@@ -8,6 +8,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/chanzuckerberg/reaper/pkg/config"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
 )
 
 func TestFromFileNoFile(t *testing.T) {
@@ -26,6 +27,519 @@ func TestFromFileInvalidYaml(t *testing.T) {
 	a.Error(err)
 }
 
+const validConfig = `
+version: 1
+accounts:
+  - name: hub
+    id: 123456789
+    role: reaper
+    owner: infra@example.com
+aws_regions:
+  - us-east-1
+policies:
+  - name: owner-ec2
+    resource_selector: "name in (ec2_instance)"
+    tag_selector: "!owner"
+    label_selector: ""
+    max_age: 720h
+    notifications:
+      warnings:
+        - recipient: $owner
+          message_template: "{{.ResourceID}} has no owner"
+`
+
+func TestFromFileValid(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", validConfig))
+
+	c, err := config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	a.Equal(1, c.Version)
+	a.Len(c.Policies, 1)
+	a.Len(c.Policies[0].Notifications.Warnings, 1)
+	a.Equal(int64(123456789), c.Accounts[0].ID)
+}
+
+func TestFromFileSchemaViolations(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", `
+version: 3
+accounts:
+  - name: hub
+    id: "123456789"
+policies:
+  - name: owner-ec2
+    resource_selector: "name in (ec2_instance)"
+    max_age: 30d
+    notification: {}
+`))
+
+	_, err := config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "config.version: must be one of [1 2]")
+	a.Contains(err.Error(), "config.accounts[0].id: must be an integer")
+	a.Contains(err.Error(), "config.accounts[0]: missing required field role")
+	a.Contains(err.Error(), "config.policies[0].max_age")
+	a.Contains(err.Error(), "config.policies[0]: unknown field notification")
+}
+
+func TestSchemaDescribesResourceTypes(t *testing.T) {
+	a := assert.New(t)
+	s := config.Schema()
+
+	labels, ok := s.Definitions["ebs_volume_labels"]
+	a.True(ok)
+	a.Contains(labels.Properties["state"].Enum, "available")
+	a.Contains(s.Properties["policies"].Items.Properties["resource_selector"].Examples, "name in (kms_key)")
+}
+
+func TestFromFileDirectoryAndIncludes(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "conf/base.yml", `
+version: 1
+include:
+  - ../shared/*.yml
+accounts:
+  - name: hub
+    id: 1
+    role: reaper
+aws_regions:
+  - us-east-1
+`))
+	a.NoError(writeFile(fs, "conf/team.yaml", `
+aws_regions:
+  - us-east-1
+  - us-west-2
+policies:
+  - name: team
+    resource_selector: "name in (s3)"
+`))
+	a.NoError(writeFile(fs, "conf/README.md", "not a config"))
+	a.NoError(writeFile(fs, "shared/accounts.yml", `
+accounts:
+  - name: other
+    id: 2
+    role: reaper
+    external_id: ${REAPER_TEST_EXTERNAL_ID}
+policies:
+  - name: shared
+    resource_selector: "name in (vpc)"
+    notifications:
+      warnings:
+        - recipient: $owner
+          message_template: "costs $${DOLLARS}"
+`))
+	a.NoError(os.Setenv("REAPER_TEST_EXTERNAL_ID", "secret"))
+	defer os.Unsetenv("REAPER_TEST_EXTERNAL_ID")
+
+	c, err := config.FromFile(fs, "conf")
+	a.NoError(err)
+	a.Equal(1, c.Version)
+	a.Equal(config.Regions{"us-east-1", "us-west-2"}, c.AWSRegions)
+	a.Len(c.Accounts, 2)
+	a.Equal("secret", c.Accounts[1].ExternalID)
+	a.Len(c.Policies, 2)
+	a.Equal("$owner", c.Policies[0].Notifications.Warnings[0].Recipient)
+	a.Equal("costs ${DOLLARS}", c.Policies[0].Notifications.Warnings[0].MessageTemplate)
+
+	c, err = config.FromFile(fs, "shared/*.yml")
+	a.NoError(err)
+	a.Len(c.Accounts, 1)
+}
+
+func TestFromFileDuplicates(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "a.yml", `
+version: 1
+policies:
+  - name: dup
+    resource_selector: "name in (s3)"
+`))
+	a.NoError(writeFile(fs, "b.yml", `
+policies:
+  - name: dup
+    resource_selector: "name in (s3)"
+`))
+	_, err := config.FromFile(fs, "*.yml")
+	a.EqualError(err, "policy dup is defined in both a.yml and b.yml")
+
+	a.NoError(writeFile(fs, "b.yml", `
+accounts:
+  - {name: one, id: 1, role: reaper}
+  - {name: two, id: 1, role: reaper}
+`))
+	_, err = config.FromFile(fs, "*.yml")
+	a.EqualError(err, "account 1 is defined twice in b.yml")
+
+	a.NoError(writeFile(fs, "a.yml", `
+version: 1
+trusted_advisor: {refresh: true, refresh_timeout: 5m}
+`))
+	a.NoError(writeFile(fs, "b.yml", `
+trusted_advisor: {refresh: false}
+`))
+	_, err = config.FromFile(fs, "*.yml")
+	a.EqualError(err, "trusted_advisor is defined in both a.yml and b.yml")
+}
+
+func TestFromFileMissingEnv(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+accounts:
+  - {name: one, id: 1, role: reaper, external_id: "${REAPER_TEST_UNSET}"}
+`))
+	_, err := config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "config.accounts[0].external_id: environment variables REAPER_TEST_UNSET are not set")
+	a.Contains(err.Error(), "config.yml")
+}
+
+func TestFromFileRegions(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", `
+version: 2
+aws_regions: all
+region_filter:
+  deny: [ap-*]
+accounts:
+  - {name: hub, id: 1, role: reaper}
+  - {name: other, id: 2, role: reaper, regions: [us-east-1], region_filter: {allow: [us-*]}}
+`))
+	c, err := config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	a.Equal(config.Regions{"all"}, c.AWSRegions)
+	accounts, err := c.GetAccounts()
+	a.NoError(err)
+	a.Equal([]string{"ap-*"}, accounts[0].DenyRegions)
+	a.Equal([]string{"us-*"}, accounts[1].AllowRegions)
+	a.Empty(accounts[1].DenyRegions)
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+aws_regions: some
+`))
+	_, err = config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "config.aws_regions: must be one of [all] or a list")
+}
+
+func TestGetPoliciesRemediation(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: old-databases
+    resource_selector: "name in (rds_instance, rds_cluster)"
+    max_age: 720h
+    remediation:
+      action: stop
+`))
+	c, err := config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	policies, err := c.GetPolicies()
+	a.NoError(err)
+	a.Equal("stop", policies[0].Remediation.Action)
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: old-instances
+    resource_selector: "name in (ec2_instance, rds_instance)"
+    max_age: 720h
+    remediation:
+      action: stop
+`))
+	c, err = config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	_, err = c.GetPolicies()
+	a.Error(err)
+	a.Contains(err.Error(), "ec2_instance does not support the stop action")
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: old-instances
+    resource_selector: "name in (rds_instance)"
+    max_age: 720h
+    remediation:
+      action: terminate
+`))
+	_, err = config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "config.policies[0].remediation.action: must be one of")
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: log-retention
+    resource_selector: "name in (log_group)"
+    label_selector: "never_expire"
+    max_age: 720h
+    remediation:
+      action: set_retention
+      retention_days: 90
+`))
+	c, err = config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	policies, err = c.GetPolicies()
+	a.NoError(err)
+	a.Equal(int64(90), policies[0].Remediation.SetRetention.Days)
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: log-retention
+    resource_selector: "name in (log_group)"
+    max_age: 720h
+    remediation:
+      action: set_retention
+`))
+	c, err = config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	_, err = c.GetPolicies()
+	a.Error(err)
+	a.Contains(err.Error(), "retention_days is required by and only valid for the set_retention action")
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: log-retention
+    resource_selector: "name in (log_group)"
+    max_age: 720h
+    remediation:
+      action: set_retention
+      retention_days: 42
+`))
+	_, err = config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "config.policies[0].remediation.retention_days: must be one of")
+}
+
+func TestBucketSizeRemediation(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: empty-buckets
+    resource_selector: "name in (s3)"
+    max_age: 720h
+    remediation:
+      action: delete
+`))
+	c, err := config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	policies, err := c.GetPolicies()
+	a.NoError(err)
+	a.Equal(policy.DefaultMaxBucketSize, policies[0].Remediation.Delete.MaxBucketSizeOrDefault())
+	a.False(policies[0].Remediation.Delete.AllowLargeBuckets)
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: small-buckets
+    resource_selector: "name in (s3)"
+    max_age: 720h
+    remediation:
+      action: delete
+      max_bucket_size_bytes: 1048576
+`))
+	c, err = config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	policies, err = c.GetPolicies()
+	a.NoError(err)
+	a.Equal(int64(1048576), policies[0].Remediation.Delete.MaxBucketSizeOrDefault())
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: any-buckets
+    resource_selector: "name in (s3)"
+    max_age: 720h
+    remediation:
+      action: delete
+      max_bucket_size_bytes: 1048576
+      allow_large_buckets: true
+`))
+	c, err = config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	_, err = c.GetPolicies()
+	a.Error(err)
+	a.Contains(err.Error(), "max_bucket_size_bytes has no effect with allow_large_buckets")
+}
+
+func TestRemediationOptions(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+
+	tests := []struct {
+		name        string
+		remediation string
+		err         string
+	}{
+		{
+			name: "option of the resource type",
+			remediation: `
+    resource_selector: "name in (ebs_volume)"
+    max_age: 720h
+    remediation:
+      action: delete
+      snapshot_before_delete: true`,
+		},
+		{
+			name: "options of the resource type",
+			remediation: `
+    resource_selector: "name in (s3)"
+    max_age: 720h
+    remediation:
+      action: delete
+      max_bucket_size_bytes: 1048576
+      pending_window_days: 7`,
+			err: "pending_window_days does not apply to s3, only to kms_key",
+		},
+		{
+			name: "ignoring the age of resources without a creation time",
+			remediation: `
+    resource_selector: "name in (elastic_ip)"
+    remediation:
+      action: release
+      ignore_age: true`,
+		},
+		{
+			name: "ignoring the age of resources with a creation time",
+			remediation: `
+    resource_selector: "name in (network_interface, nat_gateway)"
+    remediation:
+      action: delete
+      ignore_age: true`,
+			err: "nat_gateway has a creation time, use max_age rather than ignore_age",
+		},
+		{
+			name: "no max age",
+			remediation: `
+    resource_selector: "name in (ebs_volume)"
+    remediation:
+      action: delete`,
+			err: "the policy has no max_age, so nothing it matches ever expires. Set max_age, or ignore_age",
+		},
+		{
+			name: "max age of resources without a creation time",
+			remediation: `
+    resource_selector: "name in (elastic_ip)"
+    max_age: 720h
+    remediation:
+      action: release`,
+			err: "elastic_ip has no creation time and never expires, use ignore_age rather than max_age",
+		},
+		{
+			name: "ignoring the age with a max age",
+			remediation: `
+    resource_selector: "name in (elastic_ip)"
+    max_age: 720h
+    remediation:
+      action: release
+      ignore_age: true`,
+			err: "ignore_age can't be combined with max_age",
+		},
+		{
+			name: "pending window",
+			remediation: `
+    resource_selector: "name in (kms_key)"
+    max_age: 720h
+    remediation:
+      action: delete
+      pending_window_days: 7`,
+		},
+		{
+			name: "pending window too short",
+			remediation: `
+    resource_selector: "name in (kms_key)"
+    max_age: 720h
+    remediation:
+      action: delete
+      pending_window_days: 6`,
+			err: "pending_window_days must be between 7 and 30",
+		},
+		{
+			name: "pending window too long",
+			remediation: `
+    resource_selector: "name in (kms_key)"
+    max_age: 720h
+    remediation:
+      action: delete
+      pending_window_days: 31`,
+			err: "pending_window_days must be between 7 and 30",
+		},
+		{
+			name: "grace period of kms keys",
+			remediation: `
+    resource_selector: "name in (kms_key)"
+    max_age: 720h
+    remediation:
+      action: delete
+      grace_period: 720h`,
+			err: "grace_period does not apply to kms_key, only to iam_access_key",
+		},
+		{
+			name: "option of another resource type",
+			remediation: `
+    resource_selector: "name in (ebs_volume)"
+    max_age: 720h
+    remediation:
+      action: delete
+      allow_large_buckets: true`,
+			err: "allow_large_buckets does not apply to ebs_volume, only to s3",
+		},
+		{
+			name: "option of only some resource types",
+			remediation: `
+    resource_selector: "name in (s3, ebs_volume)"
+    max_age: 720h
+    remediation:
+      action: delete
+      max_bucket_size_bytes: 1048576`,
+			err: "max_bucket_size_bytes does not apply to ebs_volume, only to s3",
+		},
+		{
+			name: "option of another action",
+			remediation: `
+    resource_selector: "name in (iam_access_key)"
+    max_age: 720h
+    remediation:
+      action: deactivate
+      grace_period: 720h`,
+			err: "grace_period is only valid for the delete action",
+		},
+	}
+
+	for _, test := range tests {
+		a.NoError(writeFile(fs, "config.yml", `
+version: 1
+policies:
+  - name: test`+test.remediation+"\n"))
+		c, err := config.FromFile(fs, "config.yml")
+		a.NoError(err, test.name)
+		_, err = c.GetPolicies()
+		if test.err == "" {
+			a.NoError(err, test.name)
+			continue
+		}
+		a.Error(err, test.name)
+		if err != nil {
+			a.Contains(err.Error(), test.err, test.name)
+		}
+	}
+}
+
 // lifted from fogg, we need to refactor to go-misc
 func writeFile(fs afero.Fs, path string, contents string) error {
 	f, e := fs.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0755)
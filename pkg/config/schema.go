@@ -0,0 +1,295 @@
+package config
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chanzuckerberg/reaper/pkg/aws"
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+)
+
+const schemaDraft = "http://json-schema.org/draft-07/schema#"
+
+// JSONSchema is the subset of JSON Schema we need to describe and validate the config
+type JSONSchema struct {
+	Schema               string                 `json:"$schema,omitempty"`
+	Title                string                 `json:"title,omitempty"`
+	Description          string                 `json:"description,omitempty"`
+	Type                 string                 `json:"type,omitempty"`
+	Properties           map[string]*JSONSchema `json:"properties,omitempty"`
+	AdditionalProperties *bool                  `json:"additionalProperties,omitempty"`
+	Required             []string               `json:"required,omitempty"`
+	Items                *JSONSchema            `json:"items,omitempty"`
+	Enum                 []interface{}          `json:"enum,omitempty"`
+	Pattern              string                 `json:"pattern,omitempty"`
+	Examples             []interface{}          `json:"examples,omitempty"`
+	AnyOf                []*JSONSchema          `json:"anyOf,omitempty"`
+	Definitions          map[string]*JSONSchema `json:"definitions,omitempty"`
+}
+
+// schemaProvider is implemented by config types which can not be described by reflection alone
+type schemaProvider interface {
+	JSONSchema() *JSONSchema
+}
+
+var schemaProviderType = reflect.TypeOf((*schemaProvider)(nil)).Elem()
+
+// JSONSchema describes a Duration
+func (Duration) JSONSchema() *JSONSchema {
+	return &JSONSchema{
+		Type:     "string",
+		Pattern:  `^([0-9]+(\.[0-9]+)?(ns|us|µs|ms|s|m|h))+$`,
+		Examples: []interface{}{"24h", "720h"},
+	}
+}
+
+// JSONSchema describes Regions
+func (Regions) JSONSchema() *JSONSchema {
+	return &JSONSchema{
+		AnyOf: []*JSONSchema{
+			{Type: "string", Enum: []interface{}{policy.AllRegions}},
+			{Type: "array", Items: &JSONSchema{Type: "string"}},
+		},
+	}
+}
+
+// Schema returns the JSON Schema for the reaper config. FromFile validates against it.
+func Schema() *JSONSchema {
+	s := reflectSchema(reflect.TypeOf(Config{}))
+	s.Schema = schemaDraft
+	s.Title = "reaper config"
+	s.Properties["version"].Enum = []interface{}{}
+	for _, v := range ValidVersions {
+		s.Properties["version"].Enum = append(s.Properties["version"].Enum, v)
+	}
+
+	// document the resource types and the labels each of them sets
+	names := []string{}
+	noCreationTime := []string{}
+	actions := []interface{}{}
+	s.Definitions = map[string]*JSONSchema{}
+	resourceSelector := s.Properties["policies"].Items.Properties["resource_selector"]
+	for _, rt := range aws.ResourceTypes {
+		names = append(names, rt.Name)
+		if rt.NoCreationTime {
+			noCreationTime = append(noCreationTime, rt.Name)
+		}
+		resourceSelector.Examples = append(resourceSelector.Examples, fmt.Sprintf("name in (%s)", rt.Name))
+
+		labels := &JSONSchema{
+			Type:        "object",
+			Description: fmt.Sprintf("labels set on %s, usable in label_selector", rt.Description),
+			Properties:  map[string]*JSONSchema{},
+		}
+		for _, l := range rt.Labels {
+			label := &JSONSchema{Type: "string", Description: l.Description}
+			for _, v := range l.Values {
+				label.Enum = append(label.Enum, v)
+			}
+			labels.Properties[l.Name] = label
+		}
+		s.Definitions[rt.Name+"_labels"] = labels
+
+		for _, a := range rt.Actions {
+			if !containsAction(actions, a) {
+				actions = append(actions, a)
+			}
+		}
+	}
+	remediation := s.Properties["policies"].Items.Properties["remediation"]
+	remediation.Properties["action"].Enum = actions
+	for _, d := range aws.LogGroupRetentionDays {
+		remediation.Properties["retention_days"].Enum = append(remediation.Properties["retention_days"].Enum, d)
+	}
+	remediation.Properties["ignore_age"].Description += fmt.Sprintf(". Resource types without a creation time are %s", strings.Join(noCreationTime, ", "))
+	resourceSelector.Description = fmt.Sprintf("%s. Resource types are %s", resourceSelector.Description, strings.Join(names, ", "))
+	s.Properties["policies"].Items.Properties["label_selector"].Description += ". See the *_labels definitions for the labels each resource type sets"
+	return s
+}
+
+func reflectSchema(t reflect.Type) *JSONSchema {
+	if t.Kind() == reflect.Ptr {
+		return reflectSchema(t.Elem())
+	}
+	if t.Implements(schemaProviderType) {
+		return reflect.Zero(t).Interface().(schemaProvider).JSONSchema()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &JSONSchema{Type: "string"}
+	case reflect.Int, reflect.Int32, reflect.Int64:
+		return &JSONSchema{Type: "integer"}
+	case reflect.Bool:
+		return &JSONSchema{Type: "boolean"}
+	case reflect.Slice:
+		return &JSONSchema{Type: "array", Items: reflectSchema(t.Elem())}
+	case reflect.Struct:
+		additionalProperties := false
+		s := &JSONSchema{
+			Type:                 "object",
+			Properties:           map[string]*JSONSchema{},
+			AdditionalProperties: &additionalProperties,
+		}
+		for i := 0; i < t.NumField(); i++ {
+			field := t.Field(i)
+			name := strings.Split(field.Tag.Get("yaml"), ",")[0]
+			if field.PkgPath != "" || name == "" || name == "-" {
+				continue
+			}
+			property := reflectSchema(field.Type)
+			property.Description = field.Tag.Get("description")
+			s.Properties[name] = property
+			if field.Tag.Get("required") == "true" {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	panic(fmt.Sprintf("can not generate a json schema for %s", t))
+}
+
+// Validate checks that v, as unmarshaled from yaml, conforms to the schema
+func (s *JSONSchema) Validate(v interface{}) error {
+	var errs *multierror.Error
+	s.validate("config", v, func(path string, format string, a ...interface{}) {
+		errs = multierror.Append(errs, errors.Errorf("%s: %s", path, fmt.Sprintf(format, a...)))
+	})
+	return errs.ErrorOrNil()
+}
+
+// typeNames are the yaml names for json schema types, for error messages
+var typeNames = map[string]string{
+	"object":  "map",
+	"array":   "list",
+	"string":  "string",
+	"integer": "integer",
+	"boolean": "boolean",
+}
+
+type reportFunc func(path string, format string, a ...interface{})
+
+func (s *JSONSchema) validate(path string, v interface{}, report reportFunc) {
+	// an empty yaml value leaves the field at its zero value
+	if v == nil {
+		return
+	}
+
+	if len(s.AnyOf) > 0 {
+		s.validateAnyOf(path, v, report)
+	}
+
+	if len(s.Enum) > 0 {
+		found := false
+		for _, e := range s.Enum {
+			if fmt.Sprint(e) == fmt.Sprint(v) {
+				found = true
+			}
+		}
+		if !found {
+			report(path, "must be one of %v", s.Enum)
+		}
+	}
+
+	switch s.Type {
+	case "object":
+		s.validateObject(path, v, report)
+	case "array":
+		items, ok := v.([]interface{})
+		if !ok {
+			report(path, "must be a list")
+			return
+		}
+		for i, item := range items {
+			s.Items.validate(fmt.Sprintf("%s[%d]", path, i), item, report)
+		}
+	case "string":
+		str, ok := v.(string)
+		if !ok {
+			report(path, "must be a string")
+			return
+		}
+		if s.Pattern != "" && !regexp.MustCompile(s.Pattern).MatchString(str) {
+			report(path, "%q does not match %s", str, s.Pattern)
+		}
+	case "integer":
+		switch v.(type) {
+		case int, int64, uint64:
+		default:
+			report(path, "must be an integer")
+		}
+	case "boolean":
+		if _, ok := v.(bool); !ok {
+			report(path, "must be a boolean")
+		}
+	}
+}
+
+func (s *JSONSchema) validateAnyOf(path string, v interface{}, report reportFunc) {
+	alternatives := []string{}
+	for _, alternative := range s.AnyOf {
+		valid := true
+		alternative.validate(path, v, func(string, string, ...interface{}) {
+			valid = false
+		})
+		if valid {
+			return
+		}
+		if len(alternative.Enum) > 0 {
+			alternatives = append(alternatives, fmt.Sprintf("one of %v", alternative.Enum))
+		} else {
+			alternatives = append(alternatives, "a "+typeNames[alternative.Type])
+		}
+	}
+	report(path, "must be %s", strings.Join(alternatives, " or "))
+}
+
+func (s *JSONSchema) validateObject(path string, v interface{}, report reportFunc) {
+	m, ok := v.(map[interface{}]interface{})
+	if !ok {
+		report(path, "must be a map")
+		return
+	}
+
+	keys := []string{}
+	for k := range m {
+		key, ok := k.(string)
+		if !ok {
+			report(path, "key %v must be a string", k)
+			continue
+		}
+		keys = append(keys, key)
+	}
+	// sort for stable error messages
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		property, ok := s.Properties[key]
+		if !ok {
+			if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+				report(path, "unknown field %s", key)
+			}
+			continue
+		}
+		property.validate(path+"."+key, m[key], report)
+	}
+	for _, required := range s.Required {
+		if _, ok := m[required]; !ok {
+			report(path, "missing required field %s", required)
+		}
+	}
+}
+
+func containsAction(actions []interface{}, action string) bool {
+	for _, a := range actions {
+		if a == action {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,92 @@
+package config
+
+import (
+	"bytes"
+
+	multierror "github.com/hashicorp/go-multierror"
+	"github.com/pkg/errors"
+	yamlv3 "gopkg.in/yaml.v3"
+)
+
+// validateVersion checks that the config only uses features available in its version
+func (c *Config) validateVersion() error {
+	var errs *multierror.Error
+	policies := map[string]bool{}
+	for _, p := range c.Policies {
+		policies[p.Name] = true
+	}
+
+	for _, a := range c.Accounts {
+		if c.Version < 2 {
+			if len(a.Regions) > 0 || a.RegionFilter != nil || a.Policies != nil || len(a.OwnerTags) > 0 {
+				errs = multierror.Append(errs, errors.Errorf("account %s (%s) sets regions, region_filter, policies or owner_tags, which require version 2", a.Name, a.source))
+			}
+			continue
+		}
+		if a.Policies == nil {
+			continue
+		}
+		if len(a.Policies.Include) > 0 && len(a.Policies.Exclude) > 0 {
+			errs = multierror.Append(errs, errors.Errorf("account %s (%s) can not both include and exclude policies", a.Name, a.source))
+		}
+		for _, name := range append(a.Policies.Include, a.Policies.Exclude...) {
+			if !policies[name] {
+				errs = multierror.Append(errs, errors.Errorf("account %s (%s) refers to unknown policy %s", a.Name, a.source, name))
+			}
+		}
+	}
+	return errs.ErrorOrNil()
+}
+
+// Migrate rewrites a version 1 config file as version 2, preserving comments. aws_regions is
+// left in place, version 2 still uses it for every account that doesn't set its own regions,
+// which may live in another of the composed files. Files without a version, such as included
+// fragments, are left as they are.
+func Migrate(in []byte) ([]byte, error) {
+	doc := &yamlv3.Node{}
+	err := yamlv3.Unmarshal(in, doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not parse config")
+	}
+	if len(doc.Content) == 0 {
+		return in, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yamlv3.MappingNode {
+		return nil, errors.New("config must be a map")
+	}
+
+	version := mappingValue(root, "version")
+	if version != nil {
+		switch version.Value {
+		case "2":
+			return in, nil
+		case "1":
+			version.Value = "2"
+		default:
+			return nil, errors.Errorf("can not migrate config version %s", version.Value)
+		}
+	}
+
+	if version == nil {
+		return in, nil
+	}
+
+	out := bytes.NewBuffer(nil)
+	encoder := yamlv3.NewEncoder(out)
+	encoder.SetIndent(2)
+	err = encoder.Encode(doc)
+	if err != nil {
+		return nil, errors.Wrap(err, "could not write config")
+	}
+	return out.Bytes(), nil
+}
+
+func mappingValue(mapping *yamlv3.Node, key string) *yamlv3.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == key {
+			return mapping.Content[i+1]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,107 @@
+package config_test
+
+import (
+	"testing"
+
+	"github.com/chanzuckerberg/reaper/pkg/config"
+	"github.com/spf13/afero"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestVersion2Accounts(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", `
+version: 2
+aws_regions: [us-east-1]
+accounts:
+  - name: hub
+    id: 1
+    role: reaper
+    owner: infra@example.com
+    owner_tags: [team]
+    regions: [us-west-2]
+    policies:
+      exclude: [noisy]
+  - name: other
+    id: 2
+    role: reaper
+policies:
+  - name: noisy
+    resource_selector: "name in (s3)"
+`))
+
+	c, err := config.FromFile(fs, "config.yml")
+	a.NoError(err)
+	accounts, err := c.GetAccounts()
+	a.NoError(err)
+	a.Equal([]string{"us-west-2"}, accounts[0].Regions)
+	a.Equal([]string{"team"}, accounts[0].OwnerTags)
+	a.False(accounts[0].EvaluatesPolicy("noisy"))
+	a.Empty(accounts[1].Regions)
+	a.True(accounts[1].EvaluatesPolicy("noisy"))
+}
+
+func TestVersion2FieldsRequireVersion2(t *testing.T) {
+	a := assert.New(t)
+	fs := afero.NewMemMapFs()
+	a.NoError(writeFile(fs, "config.yml", `
+version: 1
+accounts:
+  - {name: hub, id: 1, role: reaper, regions: [us-west-2]}
+`))
+	_, err := config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "account hub (config.yml) sets regions, region_filter, policies or owner_tags, which require version 2")
+
+	a.NoError(writeFile(fs, "config.yml", `
+version: 2
+accounts:
+  - {name: hub, id: 1, role: reaper, policies: {include: [typo]}}
+`))
+	_, err = config.FromFile(fs, "config.yml")
+	a.Error(err)
+	a.Contains(err.Error(), "account hub (config.yml) refers to unknown policy typo")
+}
+
+func TestMigrate(t *testing.T) {
+	a := assert.New(t)
+	out, err := config.Migrate([]byte(`# the version
+version: 1
+aws_regions:
+  - us-east-1
+accounts:
+  - name: hub # the hub
+    id: 1
+    role: reaper
+  - name: other
+    id: 2
+    role: reaper
+    regions: [us-west-2]
+`))
+	a.NoError(err)
+	// aws_regions stays the default of every account without regions, wherever they are defined
+	a.Equal(`# the version
+version: 2
+aws_regions:
+  - us-east-1
+accounts:
+  - name: hub # the hub
+    id: 1
+    role: reaper
+  - name: other
+    id: 2
+    role: reaper
+    regions: [us-west-2]
+`, string(out))
+
+	again, err := config.Migrate(out)
+	a.NoError(err)
+	a.Equal(out, again)
+
+	// fragments without a version are left as they are
+	fragment := "aws_regions: [us-east-1]\naccounts:\n  - {name: hub, id: 1, role: reaper}\n"
+	out, err = config.Migrate([]byte(fragment))
+	a.NoError(err)
+	a.Equal(fragment, string(out))
+}
@@ -0,0 +1,160 @@
+// Package ipclass classifies ip ranges by whether they contain addresses reachable from the internet
+package ipclass
+
+import (
+	"net"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// Class describes how much of an ip range is public
+type Class int
+
+// ip range classes
+const (
+	// Private ranges only contain special purpose addresses, e.g. 10.0.0.0/16
+	Private Class = iota
+	// PartiallyPublic ranges contain both, e.g. 0.0.0.0/0
+	PartiallyPublic
+	// Public ranges only contain public addresses, e.g. 1.1.1.1/32
+	Public
+)
+
+func (c Class) String() string {
+	switch c {
+	case Private:
+		return "private"
+	case PartiallyPublic:
+		return "partially_public"
+	case Public:
+		return "public"
+	default:
+		return "unknown"
+	}
+}
+
+// nonPublicRanges are the special purpose ranges whose addresses are not reachable from the internet
+var nonPublicRanges = mustParseCIDRs(
+	// ipv4
+	"0.0.0.0/8",          // this network
+	"10.0.0.0/8",         // private, rfc 1918
+	"172.16.0.0/12",      // private, rfc 1918
+	"192.168.0.0/16",     // private, rfc 1918
+	"100.64.0.0/10",      // carrier grade nat, rfc 6598
+	"127.0.0.0/8",        // loopback
+	"169.254.0.0/16",     // link local
+	"192.0.2.0/24",       // documentation, TEST-NET-1
+	"198.51.100.0/24",    // documentation, TEST-NET-2
+	"203.0.113.0/24",     // documentation, TEST-NET-3
+	"198.18.0.0/15",      // benchmarking, rfc 2544
+	"224.0.0.0/4",        // multicast
+	"240.0.0.0/4",        // reserved
+	"255.255.255.255/32", // limited broadcast
+	// ipv6
+	"::1/128",       // loopback
+	"fe80::/10",     // link local
+	"fc00::/7",      // unique local
+	"2001:db8::/32", // documentation
+	"ff00::/8",      // multicast
+	"64:ff9b::/96",  // ipv4/ipv6 translation, rfc 6052
+	"::ffff:0:0/96", // ipv4-mapped, only reached for ranges wider than /96, see ClassifyNetwork
+)
+
+// ipv4Mapped is the range of ipv4-mapped ipv6 addresses, e.g. ::ffff:10.0.0.1
+var ipv4Mapped = mustParseCIDRs("::ffff:0:0/96")[0]
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	var networks []*net.IPNet
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// Parse parses a CIDR, or a single ipv4 or ipv6 address
+func Parse(cidr string) (*net.IPNet, error) {
+	if !strings.Contains(cidr, "/") {
+		ip := net.ParseIP(cidr)
+		if ip == nil {
+			return nil, errors.Errorf("%q is not an ip address or CIDR", cidr)
+		}
+		if ip4 := ip.To4(); ip4 != nil {
+			return &net.IPNet{IP: ip4, Mask: net.CIDRMask(32, 32)}, nil
+		}
+		return &net.IPNet{IP: ip, Mask: net.CIDRMask(128, 128)}, nil
+	}
+	_, network, err := net.ParseCIDR(cidr)
+	if err != nil {
+		return nil, errors.Wrapf(err, "%q is not an ip address or CIDR", cidr)
+	}
+	return network, nil
+}
+
+// Classify reports whether the addresses in a CIDR are private, public or both
+func Classify(cidr string) (Class, error) {
+	network, err := Parse(cidr)
+	if err != nil {
+		return Private, err
+	}
+	return ClassifyNetwork(network), nil
+}
+
+// ClassifyNetwork reports whether the addresses in a network are private, public or both
+func ClassifyNetwork(network *net.IPNet) Class {
+	if mapped := embeddedIPv4(network); mapped != nil {
+		network = mapped
+	}
+	overlaps := false
+	for _, nonPublic := range nonPublicRanges {
+		if contains(nonPublic, network) {
+			return Private
+		}
+		overlaps = overlaps || contains(network, nonPublic)
+	}
+	if overlaps {
+		return PartiallyPublic
+	}
+	return Public
+}
+
+// embeddedIPv4 returns the ipv4 network an ipv4-mapped ipv6 network stands for, or nil if network
+// isn't entirely ipv4-mapped
+func embeddedIPv4(network *net.IPNet) *net.IPNet {
+	if !contains(ipv4Mapped, network) {
+		return nil
+	}
+	ones, _ := network.Mask.Size()
+	return &net.IPNet{IP: network.IP.To4(), Mask: net.CIDRMask(ones-96, 32)}
+}
+
+// contains returns true if every address in needle is in haystack. Networks of different address
+// families never contain each other.
+func contains(haystack, needle *net.IPNet) bool {
+	haystackOnes, haystackBits := haystack.Mask.Size()
+	needleOnes, needleBits := needle.Mask.Size()
+	if haystackBits != needleBits || haystackOnes > needleOnes {
+		return false
+	}
+	// compare the addresses in their family's length, net.IPNet.Contains would treat ipv4-mapped
+	// ipv6 addresses as ipv4
+	haystackIP, needleIP := familyIP(haystack.IP, haystackBits), familyIP(needle.IP, needleBits)
+	for i := range haystackIP {
+		if haystackIP[i]&haystack.Mask[i] != needleIP[i]&haystack.Mask[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// familyIP returns ip in the length of its address family, 4 bytes for ipv4 and 16 for ipv6
+func familyIP(ip net.IP, bits int) net.IP {
+	if bits == 32 {
+		return ip.To4()
+	}
+	return ip.To16()
+}
@@ -0,0 +1,91 @@
+package ipclass_test
+
+import (
+	"testing"
+
+	"github.com/chanzuckerberg/reaper/pkg/ipclass"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestClassify(t *testing.T) {
+	a := assert.New(t)
+
+	tests := []struct {
+		cidr  string
+		class ipclass.Class
+	}{
+		// ipv4
+		{"0.0.0.0/0", ipclass.PartiallyPublic},
+		{"1.1.1.1/32", ipclass.Public},
+		{"1.1.1.1", ipclass.Public},
+		{"8.0.0.0/8", ipclass.Public},
+		{"10.0.0.0/8", ipclass.Private},
+		{"10.1.2.0/24", ipclass.Private},
+		{"10.0.0.0/7", ipclass.PartiallyPublic},
+		{"172.16.0.0/12", ipclass.Private},
+		{"172.32.0.0/16", ipclass.Public},
+		{"192.168.1.1", ipclass.Private},
+		{"100.64.0.0/10", ipclass.Private},
+		{"100.100.1.0/24", ipclass.Private},
+		{"100.128.0.0/16", ipclass.Public},
+		{"127.0.0.1/32", ipclass.Private},
+		{"169.254.169.254", ipclass.Private},
+		{"192.0.2.0/24", ipclass.Private},
+		{"198.51.100.7/32", ipclass.Private},
+		{"203.0.113.0/25", ipclass.Private},
+		{"203.0.0.0/16", ipclass.PartiallyPublic},
+		{"0.0.0.0/8", ipclass.Private},
+		{"0.0.0.0", ipclass.Private},
+		{"198.18.0.0/15", ipclass.Private},
+		{"198.19.1.0/24", ipclass.Private},
+		{"224.0.0.1", ipclass.Private},
+		{"239.255.255.250/32", ipclass.Private},
+		{"240.0.0.0/4", ipclass.Private},
+		{"255.255.255.255", ipclass.Private},
+		{"192.0.0.0/2", ipclass.PartiallyPublic},
+		// ipv6
+		{"::/0", ipclass.PartiallyPublic},
+		{"::1/128", ipclass.Private},
+		{"::1", ipclass.Private},
+		{"fe80::1/64", ipclass.Private},
+		{"fd12:3456:789a::/48", ipclass.Private},
+		{"fc00::/7", ipclass.Private},
+		{"2001:db8::/32", ipclass.Private},
+		{"2001:db8:1::/48", ipclass.Private},
+		{"2600:1f18::/32", ipclass.Public},
+		{"2000::/3", ipclass.PartiallyPublic},
+		{"ff02::1", ipclass.Private},
+		{"ff00::/8", ipclass.Private},
+		{"64:ff9b::/96", ipclass.Private},
+		{"64:ff9b::808:808/128", ipclass.Private},
+		// ipv4-mapped ipv6 is classified by the embedded ipv4 address
+		{"::ffff:10.0.0.1", ipclass.Private},
+		{"::ffff:10.0.0.0/104", ipclass.Private},
+		{"::ffff:1.1.1.1", ipclass.Public},
+		{"::ffff:1.1.1.0/120", ipclass.Public},
+		{"::ffff:0:0/96", ipclass.PartiallyPublic},
+		{"::ffff:0:0/95", ipclass.PartiallyPublic},
+	}
+
+	for _, test := range tests {
+		class, err := ipclass.Classify(test.cidr)
+		a.NoError(err, test.cidr)
+		a.Equal(test.class, class, test.cidr)
+	}
+}
+
+func TestClassifyInvalid(t *testing.T) {
+	a := assert.New(t)
+
+	for _, cidr := range []string{"", "not an ip", "10.0.0.0/33", "10.0.0/8", "::1/129", "1.1.1.1/"} {
+		_, err := ipclass.Classify(cidr)
+		a.Error(err, cidr)
+	}
+}
+
+func TestClassString(t *testing.T) {
+	a := assert.New(t)
+	a.Equal("private", ipclass.Private.String())
+	a.Equal("partially_public", ipclass.PartiallyPublic.String())
+	a.Equal("public", ipclass.Public.String())
+}
@@ -68,12 +68,7 @@ func (n *Notifier) Send(v policy.Violation, skipPrompt bool) error {
 func (n *Notifier) Recipient(notification policy.Notification, v policy.Violation) (string, bool, error) {
 	var email string
 	if notification.Recipient == "$owner" {
-		owner := v.Subject.GetOwner()
-		if owner != "" {
-			email = owner
-		} else {
-			email = v.Account.Owner
-		}
+		email, _ = v.Account.ResolveOwner(v.Subject)
 	} else {
 		email = notification.Recipient
 	}
@@ -1,5 +1,18 @@
 package policy
 
+// OwnerSource describes where the owner of a subject came from
+type OwnerSource string
+
+// owner sources
+const (
+	OwnerSourceResource  OwnerSource = "resource"
+	OwnerSourceOwnerTags OwnerSource = "account owner_tags"
+	OwnerSourceAccount   OwnerSource = "account"
+)
+
+// AllRegions can be used in place of a list of regions to scan every region enabled in an account
+const AllRegions = "all"
+
 // Account is an aws account. It should probably be in pkg/aws, but then we end up with a cycle.
 type Account struct {
 	Name       string
@@ -7,4 +20,47 @@ type Account struct {
 	Role       string
 	Owner      string
 	ExternalID string
+	// Regions overrides the globally configured regions when set. It may be AllRegions.
+	Regions []string
+	// AllowRegions and DenyRegions are glob patterns filtering the regions we scan
+	AllowRegions []string
+	DenyRegions  []string
+	// IncludePolicies, when set, limits the policies evaluated in this account
+	IncludePolicies []string
+	// ExcludePolicies are never evaluated in this account
+	ExcludePolicies []string
+	// OwnerTags are checked in order when a resource doesn't name its owner
+	OwnerTags []string
+}
+
+// ResolveOwner returns the owner of s. When the resource does not name one we check the
+// account's owner tags, then fall back to the account owner.
+func (a *Account) ResolveOwner(s Subject) (string, OwnerSource) {
+	if owner := s.GetOwner(); owner != "" {
+		return owner, OwnerSourceResource
+	}
+	tags := s.GetTags()
+	for _, tag := range a.OwnerTags {
+		if owner := tags[tag]; owner != "" {
+			return owner, OwnerSourceOwnerTags
+		}
+	}
+	return a.Owner, OwnerSourceAccount
+}
+
+// EvaluatesPolicy returns true if the named policy should be evaluated in this account
+func (a *Account) EvaluatesPolicy(name string) bool {
+	if len(a.IncludePolicies) > 0 && !contains(a.IncludePolicies, name) {
+		return false
+	}
+	return !contains(a.ExcludePolicies, name)
+}
+
+func contains(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
 }
@@ -0,0 +1,40 @@
+package policy_test
+
+import (
+	"testing"
+
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestResolveOwner(t *testing.T) {
+	a := assert.New(t)
+	account := &policy.Account{Owner: "infra@example.com", OwnerTags: []string{"team", "created_by"}}
+
+	s := &subject{tags: labels.Set{"created_by": "me@example.com"}}
+	owner, source := account.ResolveOwner(s)
+	a.Equal("me@example.com", owner)
+	a.Equal(policy.OwnerSourceOwnerTags, source)
+
+	s.tags["team"] = "team@example.com"
+	owner, _ = account.ResolveOwner(s)
+	a.Equal("team@example.com", owner)
+
+	s.tags["owner"] = "owner@example.com"
+	owner, source = account.ResolveOwner(s)
+	a.Equal("owner@example.com", owner)
+	a.Equal(policy.OwnerSourceResource, source)
+
+	owner, source = account.ResolveOwner(&subject{})
+	a.Equal("infra@example.com", owner)
+	a.Equal(policy.OwnerSourceAccount, source)
+}
+
+func TestEvaluatesPolicy(t *testing.T) {
+	a := assert.New(t)
+	a.True((&policy.Account{}).EvaluatesPolicy("a"))
+	a.True((&policy.Account{IncludePolicies: []string{"a"}}).EvaluatesPolicy("a"))
+	a.False((&policy.Account{IncludePolicies: []string{"a"}}).EvaluatesPolicy("b"))
+	a.False((&policy.Account{ExcludePolicies: []string{"a"}}).EvaluatesPolicy("a"))
+}
@@ -0,0 +1,94 @@
+package policy
+
+import (
+	"time"
+
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+// RequirementExplanation records how a single selector requirement evaluated
+type RequirementExplanation struct {
+	Requirement string
+	Matched     bool
+}
+
+// SelectorExplanation records how a selector evaluated against a set of tags or labels
+type SelectorExplanation struct {
+	Selector     string
+	Matched      bool
+	Requirements []RequirementExplanation
+}
+
+// Explanation records how a policy evaluated against a single subject
+type Explanation struct {
+	ResourceType string
+	Resource     SelectorExplanation
+	// Tags and Labels are nil when the policy does not define the selector, in which case
+	// the policy never matches.
+	Tags   *SelectorExplanation
+	Labels *SelectorExplanation
+	// AccountEvaluates is false when the account's policies include or exclude leave the policy out
+	AccountEvaluates bool
+	Matched          bool
+
+	Age     *time.Duration
+	MaxAge  *time.Duration
+	Expired bool
+	// IgnoreAge is set when the policy's remediation acts on subjects whatever their age
+	IgnoreAge bool
+	// ShouldRemediate is set when a run would take the policy's remediation on the subject
+	ShouldRemediate bool
+
+	Owner       string
+	OwnerSource OwnerSource
+}
+
+func explainSelector(s labels.Selector, set labels.Set) SelectorExplanation {
+	e := SelectorExplanation{
+		Selector: s.String(),
+		Matched:  s.Matches(set),
+	}
+	requirements, _ := s.Requirements()
+	for _, r := range requirements {
+		e.Requirements = append(e.Requirements, RequirementExplanation{
+			Requirement: r.String(),
+			Matched:     r.Matches(set),
+		})
+	}
+	return e
+}
+
+// Explain evaluates every part of the policy against s, which is of type resourceType
+// and lives in account a. The policy only matches if the account evaluates it.
+func (p *Policy) Explain(resourceType string, s Subject, a *Account) Explanation {
+	evaluates := a.EvaluatesPolicy(p.Name)
+	e := Explanation{
+		ResourceType:     resourceType,
+		Resource:         explainSelector(p.ResourceSelector, labels.Set{"name": resourceType}),
+		AccountEvaluates: evaluates,
+		Matched:          evaluates && p.Match(s),
+		MaxAge:           p.MaxAge,
+		Expired:          p.Expired(s),
+	}
+	if p.TagSelector != nil {
+		tags := explainSelector(p.TagSelector, s.GetTags())
+		e.Tags = &tags
+	}
+	if p.LabelSelector != nil {
+		l := explainSelector(p.LabelSelector, s.GetLabels())
+		e.Labels = &l
+	}
+	if createdAt := s.GetCreatedAt(); createdAt != nil {
+		age := time.Since(*createdAt)
+		e.Age = &age
+	}
+	if p.Remediation != nil {
+		e.IgnoreAge = p.Remediation.IgnoreAge
+	}
+	if e.Matched {
+		v := NewViolation(*p, s, e.Expired, a)
+		e.ShouldRemediate = v.ShouldRemediate()
+	}
+	e.Owner, e.OwnerSource = a.ResolveOwner(s)
+	return e
+}
@@ -0,0 +1,123 @@
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+type subject struct {
+	createdAt *time.Time
+	tags      labels.Set
+	labels    labels.Set
+}
+
+func (s *subject) Delete() error            { return nil }
+func (s *subject) GetCreatedAt() *time.Time { return s.createdAt }
+func (s *subject) GetID() string            { return "i-123" }
+func (s *subject) GetLabels() labels.Set    { return s.labels }
+func (s *subject) GetName() string          { return "" }
+func (s *subject) GetOwner() string         { return s.tags["owner"] }
+func (s *subject) GetTags() labels.Set      { return s.tags }
+func (s *subject) GetConsoleURL() string    { return "" }
+func (s *subject) GetRegion() string        { return "us-east-1" }
+
+func TestExplain(t *testing.T) {
+	a := assert.New(t)
+	createdAt := time.Now().Add(-48 * time.Hour)
+	maxAge := 24 * time.Hour
+
+	p := policy.New()
+	p.ResourceSelector = labels.SelectorFromSet(labels.Set{"name": "ec2_instance"})
+	p.MaxAge = &maxAge
+	_, err := p.WithTagSelector("!owner,env=test")
+	a.NoError(err)
+
+	s := &subject{
+		createdAt: &createdAt,
+		tags:      labels.Set{"env": "test"},
+		labels:    labels.Set{},
+	}
+	account := &policy.Account{Name: "hub", Owner: "infra@example.com"}
+
+	e := p.Explain("ec2_instance", s, account)
+	a.True(e.Resource.Matched)
+	a.NotNil(e.Tags)
+	a.True(e.Tags.Matched)
+	a.Len(e.Tags.Requirements, 2)
+	a.Nil(e.Labels)
+	a.False(e.Matched)
+	a.True(e.Expired)
+	a.Equal("infra@example.com", e.Owner)
+	a.Equal(policy.OwnerSourceAccount, e.OwnerSource)
+
+	s.tags["owner"] = "me@example.com"
+	e = p.Explain("ec2_instance", s, account)
+	a.False(e.Tags.Matched)
+	a.Equal("me@example.com", e.Owner)
+	a.Equal(policy.OwnerSourceResource, e.OwnerSource)
+}
+
+func TestExplainAccountPolicies(t *testing.T) {
+	a := assert.New(t)
+	p := policy.New()
+	p.Name = "owner-ec2"
+	p.ResourceSelector = labels.SelectorFromSet(labels.Set{"name": "ec2_instance"})
+	_, err := p.WithTagSelector("")
+	a.NoError(err)
+	_, err = p.AddLabelSelector("")
+	a.NoError(err)
+	s := &subject{tags: labels.Set{}, labels: labels.Set{}}
+
+	e := p.Explain("ec2_instance", s, &policy.Account{Name: "hub"})
+	a.True(e.AccountEvaluates)
+	a.True(e.Matched)
+
+	e = p.Explain("ec2_instance", s, &policy.Account{Name: "hub", ExcludePolicies: []string{"owner-ec2"}})
+	a.False(e.AccountEvaluates)
+	a.False(e.Matched)
+
+	e = p.Explain("ec2_instance", s, &policy.Account{Name: "hub", IncludePolicies: []string{"other"}})
+	a.False(e.AccountEvaluates)
+	a.False(e.Matched)
+}
+
+func TestExplainRemediation(t *testing.T) {
+	a := assert.New(t)
+	createdAt := time.Now().Add(-48 * time.Hour)
+	maxAge := 24 * time.Hour
+	longMaxAge := 72 * time.Hour
+
+	tests := []struct {
+		name            string
+		maxAge          *time.Duration
+		remediation     *policy.Remediation
+		tagSelector     string
+		ignoreAge       bool
+		shouldRemediate bool
+	}{
+		{"expired", &maxAge, &policy.Remediation{Action: policy.ActionDelete}, "", false, true},
+		{"not expired", &longMaxAge, &policy.Remediation{Action: policy.ActionDelete}, "", false, false},
+		{"no remediation", &maxAge, nil, "", false, false},
+		{"ignoring the age", nil, &policy.Remediation{Action: policy.ActionRelease, IgnoreAge: true}, "", true, true},
+		{"ignoring the age without a match", nil, &policy.Remediation{Action: policy.ActionRelease, IgnoreAge: true}, "env=prod", true, false},
+	}
+	for _, test := range tests {
+		p := policy.New()
+		p.ResourceSelector = labels.SelectorFromSet(labels.Set{"name": "elastic_ip"})
+		p.MaxAge = test.maxAge
+		p.Remediation = test.remediation
+		_, err := p.WithTagSelector(test.tagSelector)
+		a.NoError(err, test.name)
+		_, err = p.AddLabelSelector("")
+		a.NoError(err, test.name)
+
+		s := &subject{createdAt: &createdAt, tags: labels.Set{"env": "test"}, labels: labels.Set{}}
+		e := p.Explain("elastic_ip", s, &policy.Account{Name: "hub"})
+		a.Equal(test.ignoreAge, e.IgnoreAge, test.name)
+		a.Equal(test.shouldRemediate, e.ShouldRemediate, test.name)
+	}
+}
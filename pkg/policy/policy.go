@@ -21,6 +21,9 @@ type Subject interface {
 	GetRegion() string
 }
 
+// DefaultLookback is how far back we look at usage metrics when a policy doesn't say
+const DefaultLookback = 30 * 24 * time.Hour
+
 // Policy is an enforcement policy
 type Policy struct {
 	Name string
@@ -33,6 +36,10 @@ type Policy struct {
 	// MaxAge how old can this object be and still be selected by this policy
 	MaxAge        *time.Duration
 	Notifications []Notification
+	// Remediation is taken on subjects which match and are expired, nil for none
+	Remediation *Remediation
+	// Lookback is how far back labels computed from usage metrics look, nil for DefaultLookback
+	Lookback *time.Duration
 }
 
 // String satisfies Stringer interface
@@ -48,6 +55,9 @@ func (p *Policy) String() string {
 	if p.LabelSelector != nil {
 		res = append(res, fmt.Sprintf("LabelSelector: %s", p.LabelSelector.String()))
 	}
+	if p.Remediation != nil {
+		res = append(res, fmt.Sprintf("Remediation: %s", p.Remediation.Action))
+	}
 	return strings.Join(res, "\n")
 }
 
@@ -69,6 +79,34 @@ func (p *Policy) Match(s Subject) bool {
 	return labelsMatch && tagsMatch
 }
 
+// MightMatch returns false if the subject can't match the policy whatever values the labels in
+// pendingLabels, and the tags when tagsPending, turn out to have. Evaluations use it to skip
+// looking up expensive labels, such as CloudWatch metrics, for subjects the cheap ones rule out.
+func (p *Policy) MightMatch(s Subject, pendingLabels []string, tagsPending bool) bool {
+	if p.LabelSelector == nil || p.TagSelector == nil {
+		return false
+	}
+	requirements, _ := p.LabelSelector.Requirements()
+	for _, requirement := range requirements {
+		if containsString(pendingLabels, requirement.Key()) {
+			continue
+		}
+		if !requirement.Matches(s.GetLabels()) {
+			return false
+		}
+	}
+	return tagsPending || p.TagSelector.Matches(s.GetTags())
+}
+
+func containsString(haystack []string, needle string) bool {
+	for _, s := range haystack {
+		if s == needle {
+			return true
+		}
+	}
+	return false
+}
+
 // Expired returns true if a resource is older than maxAge
 func (p *Policy) Expired(s Subject) bool {
 	createdAt := s.GetCreatedAt()
@@ -78,6 +116,14 @@ func (p *Policy) Expired(s Subject) bool {
 	return time.Since(*createdAt) > *p.MaxAge
 }
 
+// LookbackPeriod returns how far back labels computed from usage metrics should look
+func (p *Policy) LookbackPeriod() time.Duration {
+	if p.Lookback == nil {
+		return DefaultLookback
+	}
+	return *p.Lookback
+}
+
 // New returns a new policy
 func New() *Policy {
 	return &Policy{}
@@ -0,0 +1,77 @@
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/labels"
+)
+
+func TestLookbackPeriod(t *testing.T) {
+	a := assert.New(t)
+	p := policy.New()
+	a.Equal(policy.DefaultLookback, p.LookbackPeriod())
+
+	lookback := 24 * time.Hour
+	p.Lookback = &lookback
+	a.Equal(lookback, p.LookbackPeriod())
+}
+
+func TestShouldRemediate(t *testing.T) {
+	a := assert.New(t)
+	remediation := &policy.Remediation{Action: policy.ActionDelete}
+	ignoreAge := &policy.Remediation{Action: policy.ActionRelease, IgnoreAge: true}
+
+	tests := []struct {
+		name        string
+		remediation *policy.Remediation
+		expired     bool
+		remediate   bool
+	}{
+		{"expired", remediation, true, true},
+		{"not expired", remediation, false, false},
+		{"no remediation", nil, true, false},
+		{"ignoring age", ignoreAge, false, true},
+	}
+
+	for _, test := range tests {
+		p := policy.New()
+		p.Remediation = test.remediation
+		v := policy.Violation{Policy: *p, Expired: test.expired}
+		a.Equal(test.remediate, v.ShouldRemediate(), test.name)
+	}
+}
+
+func TestMightMatch(t *testing.T) {
+	a := assert.New(t)
+	p, err := policy.New().WithTagSelector("env=dev")
+	a.NoError(err)
+	p, err = p.AddLabelSelector("runtime=python2.7,invocations=0")
+	a.NoError(err)
+
+	tests := []struct {
+		name          string
+		labels        labels.Set
+		tags          labels.Set
+		pendingLabels []string
+		tagsPending   bool
+		mightMatch    bool
+	}{
+		{"matching", labels.Set{"runtime": "python2.7", "invocations": "0"}, labels.Set{"env": "dev"}, nil, false, true},
+		{"pending label", labels.Set{"runtime": "python2.7"}, labels.Set{"env": "dev"}, []string{"invocations"}, false, true},
+		{"pending label set to another value", labels.Set{"runtime": "python2.7", "invocations": "10"}, labels.Set{"env": "dev"}, []string{"invocations"}, false, true},
+		{"other label doesn't match", labels.Set{"runtime": "python3.12"}, labels.Set{"env": "dev"}, []string{"invocations"}, false, false},
+		{"pending tags", labels.Set{"runtime": "python2.7"}, nil, []string{"invocations"}, true, true},
+		{"tags don't match", labels.Set{"runtime": "python2.7"}, labels.Set{"env": "prod"}, []string{"invocations"}, false, false},
+	}
+
+	for _, test := range tests {
+		s := &subject{labels: test.labels, tags: test.tags}
+		a.Equal(test.mightMatch, p.MightMatch(s, test.pendingLabels, test.tagsPending), test.name)
+	}
+
+	// policies missing a selector never match
+	a.False(policy.New().MightMatch(&subject{}, nil, true))
+}
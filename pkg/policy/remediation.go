@@ -0,0 +1,109 @@
+package policy
+
+import (
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// remediation actions
+const (
+	ActionDelete  = "delete"
+	ActionStop    = "stop"
+	ActionRelease = "release"
+	// ActionDeactivate disables a credential without deleting it
+	ActionDeactivate = "deactivate"
+	// ActionSetRetention sets how long a resource keeps its data, see SetRetentionOptions
+	ActionSetRetention = "set_retention"
+	// ActionRevokePublicIngress removes the firewall rules letting public ranges in
+	ActionRevokePublicIngress = "revoke_public_ingress"
+)
+
+// Remediation is the action to take on subjects which match a policy and are expired, see
+// Violation.ShouldRemediate
+type Remediation struct {
+	Action string
+	// Policy is the name of the policy taking the action, which subjects that can be tagged record
+	Policy string
+	// IgnoreAge takes the action on every subject the policy matches rather than only expired ones,
+	// for resource types AWS reports no creation time for
+	IgnoreAge bool
+	// SetRetention configures ActionSetRetention
+	SetRetention SetRetentionOptions
+	// Delete configures ActionDelete, for the resource types that take options
+	Delete DeleteOptions
+}
+
+// SetRetentionOptions configures ActionSetRetention
+type SetRetentionOptions struct {
+	// Days is the retention period to set
+	Days int64
+}
+
+// DeleteOptions configures ActionDelete. Each option is only read by the resource types its
+// comment names, the config rejects it for any other.
+type DeleteOptions struct {
+	// GracePeriod is how long iam_access_key waits between deactivating and deleting a key, nil for
+	// DefaultGracePeriod
+	GracePeriod *time.Duration
+	// PendingWindowDays is how long AWS keeps kms_key keys for after scheduling their deletion, nil
+	// for DefaultPendingWindowDays
+	PendingWindowDays *int64
+	// MaxBucketSize is the size in bytes above which s3 buckets are not deleted, nil for
+	// DefaultMaxBucketSize
+	MaxBucketSize *int64
+	// AllowLargeBuckets lets s3 buckets of any size be deleted
+	AllowLargeBuckets bool
+	// SnapshotFirst has ebs_volume volumes snapshotted before they are deleted
+	SnapshotFirst bool
+}
+
+// DefaultGracePeriod is how long two phase deletions wait when a policy doesn't say
+const DefaultGracePeriod = 14 * 24 * time.Hour
+
+// DefaultPendingWindowDays is how long scheduled key deletions wait when a policy doesn't say, the
+// same as AWS
+const DefaultPendingWindowDays int64 = 30
+
+// DefaultMaxBucketSize is the size above which buckets are not deleted when a policy doesn't say
+const DefaultMaxBucketSize int64 = 1 << 30
+
+// MaxBucketSizeOrDefault returns the size in bytes above which buckets should not be deleted
+func (o *DeleteOptions) MaxBucketSizeOrDefault() int64 {
+	if o.MaxBucketSize == nil {
+		return DefaultMaxBucketSize
+	}
+	return *o.MaxBucketSize
+}
+
+// GracePeriodOrDefault returns how long two phase deletions should wait between phases
+func (o *DeleteOptions) GracePeriodOrDefault() time.Duration {
+	if o.GracePeriod == nil {
+		return DefaultGracePeriod
+	}
+	return *o.GracePeriod
+}
+
+// PendingWindowDaysOrDefault returns how many days scheduled key deletions should wait
+func (o *DeleteOptions) PendingWindowDaysOrDefault() int64 {
+	if o.PendingWindowDays == nil {
+		return DefaultPendingWindowDays
+	}
+	return *o.PendingWindowDays
+}
+
+// Remediable is implemented by subjects which support remediation actions beyond Delete
+type Remediable interface {
+	Remediate(r Remediation) error
+}
+
+// Apply takes the remediation action on s
+func (r *Remediation) Apply(s Subject) error {
+	if remediable, ok := s.(Remediable); ok {
+		return remediable.Remediate(*r)
+	}
+	if r.Action == ActionDelete {
+		return s.Delete()
+	}
+	return errors.Errorf("%s does not support the %s action", s.GetID(), r.Action)
+}
@@ -0,0 +1,44 @@
+package policy_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/chanzuckerberg/reaper/pkg/policy"
+	"github.com/stretchr/testify/assert"
+)
+
+type remediableSubject struct {
+	subject
+	actions []string
+}
+
+func (s *remediableSubject) Remediate(r policy.Remediation) error {
+	s.actions = append(s.actions, r.Action)
+	return nil
+}
+
+func TestRemediationApply(t *testing.T) {
+	a := assert.New(t)
+
+	s := &remediableSubject{}
+	r := &policy.Remediation{Action: policy.ActionStop}
+	a.NoError(r.Apply(s))
+	a.Equal([]string{policy.ActionStop}, s.actions)
+
+	// subjects which only support Delete can only be deleted
+	a.NoError((&policy.Remediation{Action: policy.ActionDelete}).Apply(&subject{}))
+	err := r.Apply(&subject{})
+	a.Error(err)
+	a.Contains(err.Error(), "i-123 does not support the stop action")
+}
+
+func TestGracePeriodOrDefault(t *testing.T) {
+	a := assert.New(t)
+	r := &policy.Remediation{Action: policy.ActionDelete}
+	a.Equal(policy.DefaultGracePeriod, r.Delete.GracePeriodOrDefault())
+
+	week := 7 * 24 * time.Hour
+	r.Delete.GracePeriod = &week
+	a.Equal(week, r.Delete.GracePeriodOrDefault())
+}
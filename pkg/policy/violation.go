@@ -10,6 +10,15 @@ type Violation struct {
 	Account     *Account
 }
 
+// ShouldRemediate returns true if the policy has a remediation to take on the subject. Only expired
+// subjects are remediated, unless the remediation ignores their age.
+func (v *Violation) ShouldRemediate() bool {
+	if v.Policy.Remediation == nil {
+		return false
+	}
+	return v.Expired || v.Policy.Remediation.IgnoreAge
+}
+
 // NewViolation creates a new Violation struct
 func NewViolation(policy Policy, subject Subject, expired bool, account *Account) Violation {
 	return Violation{
@@ -1,7 +1,7 @@
 package runner
 
 import (
-	"github.com/aws/aws-sdk-go/service/support"
+	"github.com/aws/aws-sdk-go/service/elbv2"
 	cziAws "github.com/chanzuckerberg/reaper/pkg/aws"
 	"github.com/chanzuckerberg/reaper/pkg/config"
 	"github.com/chanzuckerberg/reaper/pkg/policy"
@@ -12,6 +12,11 @@ import (
 // Runner takes a config and generates all the violations
 type Runner struct {
 	Config *config.Config
+	// Record lets Run keep what it needs between runs on the resources, like when a volume was first
+	// seen available. Dry runs leave it off so they don't change anything.
+	Record bool
+
+	awsClient *cziAws.Client
 }
 
 // New will construct a Runner object with the given Config
@@ -35,19 +40,23 @@ func (r *Runner) Run(only []string) ([]policy.Violation, error) {
 	if err != nil {
 		return nil, err
 	}
+	r.awsClient = awsClient
 
 	regions := r.Config.AWSRegions
 
 	var errs *multierror.Error
 	var violations []policy.Violation
 
-	r.UpdateTrustedAdvisorChecks(awsClient, accounts)
+	err = r.UpdateTrustedAdvisorChecks(awsClient, accounts, policies, only)
+	errs = multierror.Append(errs, err)
 
+	allAccounts := accounts
 	for _, p := range policies {
 		if len(only) > 0 && !contains(only, p.Name) {
 			log.Infof("skipping %s", p.Name)
 			continue
 		}
+		accounts := policyAccounts(allAccounts, p)
 		log.Infof("Executing policy: \n%s \n=================", p.String())
 		if p.MatchResource(map[string]string{"name": "s3"}) {
 			v, err := awsClient.EvalS3(accounts, p)
@@ -117,42 +126,266 @@ func (r *Runner) Run(only []string) ([]policy.Violation, error) {
 			}
 		}
 
+		if p.MatchResource(map[string]string{"name": "rds_instance"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalRDSInstance(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "rds_cluster"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalRDSCluster(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "ebs_snapshot"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalEBSSnapshot(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "ami"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalAMI(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "elastic_ip"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalElasticIP(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "network_interface"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalNetworkInterface(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "nat_gateway"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalNatGateway(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "elb_classic"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalELBClassic(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "alb"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalLoadBalancerV2(accounts, p, regions, elbv2.LoadBalancerTypeEnumApplication, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "nlb"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalLoadBalancerV2(accounts, p, regions, elbv2.LoadBalancerTypeEnumNetwork, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "lambda_function"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalLambdaFunction(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "cloudformation_stack"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalCloudFormationStack(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "ecs_cluster"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalECSCluster(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "ecs_service"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalECSService(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "eks_cluster"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalEKSCluster(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "eks_nodegroup"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalEKSNodegroup(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "ecr_repository"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalECRRepository(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "dynamodb_table"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalDynamoDBTable(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "sqs_queue"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalSQSQueue(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "sns_topic"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalSNSTopic(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "kinesis_stream"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalKinesisStream(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "log_group"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalLogGroup(accounts, p, regions, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "iam_role"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalIAMRole(accounts, p, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "iam_policy"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalIAMPolicy(accounts, p, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+
+		if p.MatchResource(map[string]string{"name": "trusted_advisor_finding"}) {
+			log.Infof("Evaluating policy: %s", p.Name)
+			err := awsClient.EvalTrustedAdvisorFinding(accounts, p, func(v policy.Violation) {
+				violations = append(violations, v)
+			})
+			errs = multierror.Append(errs, err)
+		}
+	}
+
+	if r.Record {
+		errs = multierror.Append(errs, awsClient.RecordEbsVolumesFirstSeen())
 	}
 
 	return violations, errs.ErrorOrNil()
 }
 
-// UpdateTrustedAdvisorChecks will walk all accounts, all checks and update them if they are stale
-func (r *Runner) UpdateTrustedAdvisorChecks(client *cziAws.Client, accounts []*policy.Account) {
-	for _, a := range accounts {
-		log.Debugf("ta for %s", a.Name)
-		c := client.Get(a.ID, a.Role, a.ExternalID, "us-east-1")
-		en := "en"
-		input := &support.DescribeTrustedAdvisorChecksInput{
-			Language: &en,
-		}
-		output, _ := c.Support.Svc.DescribeTrustedAdvisorChecks(input)
-		checkIds := []*string{}
-		for _, check := range output.Checks {
-			// log.Debugf("check: %#v", check)
-			checkIds = append(checkIds, check.Id)
-		}
-		refreshStatusInput := &support.DescribeTrustedAdvisorCheckRefreshStatusesInput{
-			CheckIds: checkIds,
-		}
-		out, _ := c.Support.Svc.DescribeTrustedAdvisorCheckRefreshStatuses(refreshStatusInput)
-		for _, status := range out.Statuses {
-			log.Debugf("status: %#v", status)
-			if status.MillisUntilNextRefreshable != nil && *status.MillisUntilNextRefreshable == 0 {
-				log.Debugf("refreshing %s", *status.CheckId)
-				refreshInput := &support.RefreshTrustedAdvisorCheckInput{
-					CheckId: status.CheckId,
-				}
-				c.Support.Svc.RefreshTrustedAdvisorCheck(refreshInput)
-				// TODO wait until no longer pending
+// WaitForRemediations waits for the remediations of the violations Run returned that finish in the
+// background, like cloudformation stack deletes, and returns an error for those that failed
+func (r *Runner) WaitForRemediations() error {
+	if r.awsClient == nil {
+		return nil
+	}
+	return r.awsClient.WaitForStackDeletes()
+}
+
+// UpdateTrustedAdvisorChecks refreshes the Trusted Advisor checks of the accounts evaluating a
+// policy that selects trusted_advisor_finding, when the config asks for it, and waits for them to
+// finish so the policies read fresh results
+func (r *Runner) UpdateTrustedAdvisorChecks(client *cziAws.Client, accounts []*policy.Account, policies []policy.Policy, only []string) error {
+	if r.Config.TrustedAdvisor == nil || !r.Config.TrustedAdvisor.Refresh {
+		return nil
+	}
+	var refresh []*policy.Account
+	for _, p := range policies {
+		if len(only) > 0 && !contains(only, p.Name) {
+			continue
+		}
+		if !p.MatchResource(map[string]string{"name": "trusted_advisor_finding"}) {
+			continue
+		}
+		for _, a := range policyAccounts(accounts, p) {
+			if !containsAccount(refresh, a) {
+				refresh = append(refresh, a)
 			}
 		}
 	}
+	return client.RefreshTrustedAdvisorChecks(refresh, r.Config.TrustedAdvisor.RefreshTimeout.Duration())
+}
+
+// containsAccount returns true if accounts contains account
+func containsAccount(accounts []*policy.Account, account *policy.Account) bool {
+	for _, a := range accounts {
+		if a.ID == account.ID {
+			return true
+		}
+	}
+	return false
+}
+
+// policyAccounts returns the accounts which evaluate the policy
+func policyAccounts(accounts []*policy.Account, p policy.Policy) []*policy.Account {
+	var res []*policy.Account
+	for _, a := range accounts {
+		if a.EvaluatesPolicy(p.Name) {
+			res = append(res, a)
+		}
+	}
+	return res
 }
 
 func contains(haystack []string, needle string) bool {
@@ -69,3 +69,19 @@ func (i *Interactive) Prompt(msg, recipient, method string) bool {
 	}
 	return yes == "Y" || yes == "y"
 }
+
+// Confirm asks the user `question`, defaulting to no since we use it before destructive actions
+func (i *Interactive) Confirm(question string) bool {
+	yes, err := i.prompt.Ask(question, &input.Options{
+		Required: true,
+		Default:  "N",
+	})
+	if err != nil {
+		if err.Error() == "interrupted" {
+			os.Exit(-1)
+		}
+		log.Infof("error: %#v", err)
+		return false
+	}
+	return yes == "Y" || yes == "y"
+}
@@ -4,23 +4,16 @@ import (
 	"net"
 
 	"github.com/apparentlymart/go-cidr/cidr"
+	"github.com/chanzuckerberg/reaper/pkg/ipclass"
 )
 
-// ContainsPublicIps is legit
-func ContainsPublicIps(cidrblock string) bool {
-
-	_, a, _ := net.ParseCIDR("10.0.0.0/8")
-	_, b, _ := net.ParseCIDR("172.16.0.0/12")
-	_, c, _ := net.ParseCIDR("192.168.0.0/16")
-
-	_, network, _ := net.ParseCIDR(cidrblock)
-
-	if SubnetContainsRange(a, network) ||
-		SubnetContainsRange(b, network) ||
-		SubnetContainsRange(c, network) {
-		return false
+// ContainsPublicIps returns true if any address in the ipv4 or ipv6 CIDR is public, see ipclass
+func ContainsPublicIps(cidrblock string) (bool, error) {
+	class, err := ipclass.Classify(cidrblock)
+	if err != nil {
+		return false, err
 	}
-	return true
+	return class != ipclass.Private, nil
 }
 
 func SubnetContainsRange(haystack, needle *net.IPNet) bool {
@@ -10,8 +10,24 @@ import (
 
 func TestContainsPublicIps(t *testing.T) {
 	a := assert.New(t)
-	one := "1.1.1.1/0"
-	a.True(util.ContainsPublicIps(one))
+	tests := []struct {
+		cidr   string
+		public bool
+	}{
+		{"1.1.1.1/0", true},
+		{"10.0.0.0/16", false},
+		{"100.64.0.0/10", false},
+		{"::/0", true},
+		{"fd00::/8", false},
+	}
+	for _, test := range tests {
+		public, err := util.ContainsPublicIps(test.cidr)
+		a.NoError(err, test.cidr)
+		a.Equal(test.public, public, test.cidr)
+	}
+
+	_, err := util.ContainsPublicIps("10.0.0.0/33")
+	a.Error(err)
 }
 
 func TestSubnetContainsRange(t *testing.T) {